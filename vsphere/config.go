@@ -19,6 +19,7 @@ import (
 	"github.com/vmware/govmomi/vapi/rest"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/pbm"
@@ -52,6 +53,19 @@ type Client struct {
 
 	// client timeout for certain operations
 	timeout time.Duration
+
+	// Options for uploading the files of OVF/OVA templates
+	ovfUploadOptions ovfdeploy.UploadOptions
+
+	// The cache for remote OVF/OVA templates, nil if disabled
+	ovfDownloadCache *ovfdeploy.DownloadCache
+
+	// The OVF descriptors read while planning, ie: to validate vApp properties
+	ovfDescriptorCache *ovfdeploy.DescriptorCache
+
+	// The tag and custom attribute that protect objects from deletion
+	deletionProtectionTag             string
+	deletionProtectionCustomAttribute string
 }
 
 // TagsManager returns the embedded tags manager used for tags, after determining
@@ -100,6 +114,14 @@ type Config struct {
 	RestSessionPath string
 	KeepAlive       int
 	APITimeout      time.Duration
+
+	OvfUploadParallelism    int
+	OvfUploadRetries        int
+	OvfDownloadCacheDir     string
+	OvfDownloadCacheMaxSize int
+
+	DeletionProtectionTag             string
+	DeletionProtectionCustomAttribute string
 }
 
 // NewConfig returns a new Config from a supplied ResourceData.
@@ -133,6 +155,14 @@ func NewConfig(d *schema.ResourceData) (*Config, error) {
 		RestSessionPath: d.Get("rest_session_path").(string),
 		KeepAlive:       d.Get("vim_keep_alive").(int),
 		APITimeout:      timeout,
+
+		OvfUploadParallelism:    d.Get("ovf_upload_parallelism").(int),
+		OvfUploadRetries:        d.Get("ovf_upload_retries").(int),
+		OvfDownloadCacheDir:     d.Get("ovf_download_cache_dir").(string),
+		OvfDownloadCacheMaxSize: d.Get("ovf_download_cache_max_size").(int),
+
+		DeletionProtectionTag:             d.Get("deletion_protection_tag").(string),
+		DeletionProtectionCustomAttribute: d.Get("deletion_protection_custom_attribute").(string),
 	}
 
 	return c, nil
@@ -223,6 +253,20 @@ func (c *Config) Client() (*Client, error) {
 	}
 
 	client.timeout = c.APITimeout
+	client.ovfUploadOptions = ovfdeploy.DefaultUploadOptions()
+	if c.OvfUploadParallelism > 0 {
+		client.ovfUploadOptions.Parallelism = c.OvfUploadParallelism
+	}
+	client.ovfUploadOptions.Retries = c.OvfUploadRetries
+	client.ovfDescriptorCache = ovfdeploy.NewDescriptorCache()
+	client.deletionProtectionTag = c.DeletionProtectionTag
+	client.deletionProtectionCustomAttribute = c.DeletionProtectionCustomAttribute
+	if c.OvfDownloadCacheDir != "" {
+		client.ovfDownloadCache, err = ovfdeploy.NewDownloadCache(c.OvfDownloadCacheDir, int64(c.OvfDownloadCacheMaxSize)*1024*1024)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return client, nil
 }
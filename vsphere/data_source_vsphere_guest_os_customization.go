@@ -119,6 +119,12 @@ func dataSourceVSphereGuestOSCustomization() *schema.Resource {
 										Sensitive:   true,
 										Description: "The new administrator password for this virtual machine.",
 									},
+									"admin_password_encrypted": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Sensitive:   true,
+										Description: "The new administrator password for this virtual machine, encrypted with the public key of the vCenter Server.",
+									},
 									"time_zone": {
 										Type:        schema.TypeInt,
 										Computed:    true,
@@ -142,6 +148,12 @@ func dataSourceVSphereGuestOSCustomization() *schema.Resource {
 										Sensitive:   true,
 										Description: "The user account used to join this virtual machine to the Active Directory domain.",
 									},
+									"domain_admin_password_encrypted": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Sensitive:   true,
+										Description: "The password of the user account used to join this virtual machine to the Active Directory domain, encrypted with the public key of the vCenter Server.",
+									},
 									"workgroup": {
 										Type:        schema.TypeString,
 										Computed:    true,
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+)
+
+func dataSourceVSphereHostProfile() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereHostProfileRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the host profile.",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The description of the host profile.",
+			},
+			"reference_host_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The managed object ID of the host the profile was extracted from.",
+			},
+			"entity_ids": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "The managed object IDs of the hosts and clusters the profile is attached to.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceVSphereHostProfileRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	if err := viapi.ValidateVirtualCenter(client); err != nil {
+		return err
+	}
+	props, err := hostProfileFromName(client, d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(props.Self.Value)
+	_ = d.Set("description", hostProfileConfigInfo(props).Annotation)
+	_ = d.Set("reference_host_id", "")
+	if props.ReferenceHost != nil {
+		_ = d.Set("reference_host_id", props.ReferenceHost.Value)
+	}
+	var entities []string
+	for _, e := range props.Entity {
+		entities = append(entities, e.Value)
+	}
+	if err := d.Set("entity_ids", entities); err != nil {
+		return fmt.Errorf("error setting entity_ids: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func dataSourceVSphereHostProfileCompliance() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereHostProfileComplianceRead,
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "The managed object ID of the host to check.",
+				ConflictsWith: []string{"compute_cluster_id"},
+			},
+			"compute_cluster_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "The managed object ID of the cluster to check.",
+				ConflictsWith: []string{"host_system_id"},
+			},
+			"host_profile_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The managed object ID of the host profile to check against. If not set, the profile attached to the host or cluster is used.",
+			},
+			"compliance_status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The compliance status of the hosts: compliant, nonCompliant or unknown.",
+			},
+			"compliance_failure": schemaHostProfileComplianceFailure(),
+		},
+	}
+}
+
+func dataSourceVSphereHostProfileComplianceRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	if err := viapi.ValidateVirtualCenter(client); err != nil {
+		return err
+	}
+	var entity types.ManagedObjectReference
+	if id, ok := d.GetOk("host_system_id"); ok {
+		entity = types.ManagedObjectReference{Type: "HostSystem", Value: id.(string)}
+	} else if id, ok := d.GetOk("compute_cluster_id"); ok {
+		entity = types.ManagedObjectReference{Type: "ClusterComputeResource", Value: id.(string)}
+	} else {
+		return errors.New("one of host_system_id or compute_cluster_id must be set")
+	}
+	var profile *types.ManagedObjectReference
+	if id, ok := d.GetOk("host_profile_id"); ok {
+		profile = &types.ManagedObjectReference{Type: "HostProfile", Value: id.(string)}
+	}
+
+	results, err := checkHostProfileCompliance(client, profile, []types.ManagedObjectReference{entity})
+	if err != nil {
+		return fmt.Errorf("error checking host profile compliance: %s", err)
+	}
+	status, failures := hostProfileComplianceStatus(results)
+	d.SetId(entity.Value)
+	_ = d.Set("compliance_status", status)
+	if err := d.Set("compliance_failure", failures); err != nil {
+		return fmt.Errorf("error setting compliance_failure: %s", err)
+	}
+	return nil
+}
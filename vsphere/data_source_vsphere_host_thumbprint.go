@@ -4,10 +4,7 @@
 package vsphere
 
 import (
-	"bytes"
-	"crypto/sha1"
 	"crypto/tls"
-	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -44,15 +41,6 @@ func dataSourceVSphereHostThumbprintRead(d *schema.ResourceData, _ interface{})
 		return err
 	}
 	cert := conn.ConnectionState().PeerCertificates[0]
-	fingerprint := sha1.Sum(cert.Raw)
-
-	var buf bytes.Buffer
-	for i, f := range fingerprint {
-		if i > 0 {
-			_, _ = fmt.Fprintf(&buf, ":")
-		}
-		_, _ = fmt.Fprintf(&buf, "%02X", f)
-	}
-	d.SetId(buf.String())
+	d.SetId(hostCertificateThumbprint(cert))
 	return nil
 }
@@ -5,6 +5,7 @@ package vsphere
 
 import (
 	"fmt"
+	"log"
 	"reflect"
 
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/vmworkflow"
 )
 
@@ -106,6 +108,152 @@ func dataSourceVSphereOvfVMTemplate() *schema.Resource {
 			Description: "The type of SCSI bus this virtual machine will have. Can be one of lsilogic, lsilogic-sas or pvscsi.",
 		},
 	}
+	ovfDescriptorSchema := map[string]*schema.Schema{
+		"properties": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The properties in the product sections of the OVF descriptor.",
+			Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+				"key": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The key of the property, as used in vapp.properties.",
+				},
+				"qualified_key": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The key of the property qualified with the class and instance of its product section, in the form class.key.instance, as used in vapp.properties.",
+				},
+				"class_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The class of the product section the property is defined in.",
+				},
+				"instance_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The instance of the product section the property is defined in.",
+				},
+				"type": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The type of the property, such as string, boolean, or uint16.",
+				},
+				"qualifiers": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The qualifiers restricting the values of the property, such as MinLen(1) or ValueMap{\"a\",\"b\"}.",
+				},
+				"default_value": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The default value of the property.",
+				},
+				"user_configurable": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether the property can be set at deployment time.",
+				},
+				"password": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether the property is a password.",
+				},
+				"label": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The label of the property.",
+				},
+				"description": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The description of the property.",
+				},
+			}},
+		},
+		"networks": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The networks in the network section of the OVF descriptor, as used in ovf_network_map.",
+			Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The name of the network.",
+				},
+				"description": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The description of the network.",
+				},
+			}},
+		},
+		"disks": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The disks in the disk section of the OVF descriptor.",
+			Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+				"disk_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The ID of the disk.",
+				},
+				"file_ref": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The ID of the file the disk is backed by.",
+				},
+				"capacity": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The capacity of the disk, in bytes.",
+				},
+				"populated_size": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The populated size of the disk, in bytes.",
+				},
+				"format": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The format of the disk.",
+				},
+			}},
+		},
+		"deployment_options": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The deployment options in the deployment option section of the OVF descriptor.",
+			Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+				"id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The ID of the deployment option, as used in deployment_option.",
+				},
+				"label": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The label of the deployment option.",
+				},
+				"description": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The description of the deployment option.",
+				},
+				"default": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether this is the default deployment option.",
+				},
+			}},
+		},
+		"eula": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The license agreements in the EULA sections of the OVF descriptor.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+	}
 	s := map[string]*schema.Schema{
 		"name": {
 			Type:        schema.TypeString,
@@ -137,6 +285,7 @@ func dataSourceVSphereOvfVMTemplate() *schema.Resource {
 	}
 	structure.MergeSchema(s, vmworkflow.VirtualMachineOvfDeploySchema())
 	structure.MergeSchema(s, vmConfigSpecSchema)
+	structure.MergeSchema(s, ovfDescriptorSchema)
 
 	return &schema.Resource{
 		Read:   dataSourceVSphereOvfVMTemplateRead,
@@ -146,19 +295,21 @@ func dataSourceVSphereOvfVMTemplate() *schema.Resource {
 
 func NewOvfHelperParamsFromVMDatasource(d *schema.ResourceData) *ovfdeploy.OvfHelperParams {
 	ovfParams := &ovfdeploy.OvfHelperParams{
-		AllowUnverifiedSSL: d.Get("allow_unverified_ssl_cert").(bool),
-		DatastoreID:        d.Get("datastore_id").(string),
-		DeploymentOption:   d.Get("deployment_option").(string),
-		DiskProvisioning:   d.Get("disk_provisioning").(string),
-		FilePath:           d.Get("local_ovf_path").(string),
-		Folder:             d.Get("folder").(string),
-		HostID:             d.Get("host_system_id").(string),
-		IPAllocationPolicy: d.Get("ip_allocation_policy").(string),
-		IPProtocol:         d.Get("ip_protocol").(string),
-		Name:               d.Get("name").(string),
-		NetworkMappings:    d.Get("ovf_network_map").(map[string]interface{}),
-		OvfURL:             d.Get("remote_ovf_url").(string),
-		PoolID:             d.Get("resource_pool_id").(string),
+		AllowUnverifiedSSL:  d.Get("allow_unverified_ssl_cert").(bool),
+		DatastoreID:         d.Get("datastore_id").(string),
+		DeploymentOption:    d.Get("deployment_option").(string),
+		DiskProvisioning:    d.Get("disk_provisioning").(string),
+		FilePath:            d.Get("local_ovf_path").(string),
+		Folder:              d.Get("folder").(string),
+		HostID:              d.Get("host_system_id").(string),
+		IPAllocationPolicy:  d.Get("ip_allocation_policy").(string),
+		IPProtocol:          d.Get("ip_protocol").(string),
+		Name:                d.Get("name").(string),
+		NetworkMappings:     d.Get("ovf_network_map").(map[string]interface{}),
+		OvfURL:              d.Get("remote_ovf_url").(string),
+		PoolID:              d.Get("resource_pool_id").(string),
+		SourceDatastoreID:   d.Get("source_datastore_id").(string),
+		SourceDatastorePath: d.Get("source_datastore_path").(string),
 	}
 	return ovfParams
 }
@@ -230,7 +381,96 @@ func dataSourceVSphereOvfVMTemplateRead(d *schema.ResourceData, meta interface{}
 	_ = d.Set("sata_controller_count", controllers["sata"])
 	_ = d.Set("ide_controller_count", controllers["ide"])
 
+	if err := flattenOvfDescriptor(d, ovfHelper.OvfDescriptor); err != nil {
+		return err
+	}
+
 	d.SetId(d.Get("name").(string))
 
 	return nil
 }
+
+// flattenOvfDescriptor saves the properties, networks, disks, deployment
+// options, and EULAs of the supplied OVF descriptor to the data source.
+func flattenOvfDescriptor(d *schema.ResourceData, ovfDescriptor string) error {
+	e, err := ovfdeploy.ParseOvfDescriptor(ovfDescriptor)
+	if err != nil {
+		return err
+	}
+
+	var properties []map[string]interface{}
+	for _, section := range ovfdeploy.ProductSections(e) {
+		for _, p := range section.Property {
+			properties = append(properties, map[string]interface{}{
+				"key":               p.Key,
+				"qualified_key":     virtualmachine.VAppPropertyQualifiedID(structure.StringNilEmpty(section.Class), p.Key, structure.StringNilEmpty(section.Instance)),
+				"class_id":          structure.StringNilEmpty(section.Class),
+				"instance_id":       structure.StringNilEmpty(section.Instance),
+				"type":              p.Type,
+				"qualifiers":        structure.StringNilEmpty(p.Qualifiers),
+				"default_value":     structure.StringNilEmpty(p.Default),
+				"user_configurable": structure.BoolNilFalse(p.UserConfigurable),
+				"password":          structure.BoolNilFalse(p.Password),
+				"label":             structure.StringNilEmpty(p.Label),
+				"description":       structure.StringNilEmpty(p.Description),
+			})
+		}
+	}
+
+	var networks []map[string]interface{}
+	if e.Network != nil {
+		for _, n := range e.Network.Networks {
+			networks = append(networks, map[string]interface{}{
+				"name":        n.Name,
+				"description": n.Description,
+			})
+		}
+	}
+
+	var disks []map[string]interface{}
+	if e.Disk != nil {
+		for _, disk := range e.Disk.Disks {
+			m := map[string]interface{}{
+				"disk_id":  disk.DiskID,
+				"file_ref": structure.StringNilEmpty(disk.FileRef),
+				"format":   structure.StringNilEmpty(disk.Format),
+			}
+			// The capacity can refer to a property, in which case it is only
+			// known at deployment time.
+			if capacity, err := ovfdeploy.DiskCapacityBytes(disk); err == nil {
+				m["capacity"] = capacity
+			} else {
+				log.Printf("[DEBUG] Skipping capacity of OVF disk: %s", err)
+			}
+			if disk.PopulatedSize != nil {
+				m["populated_size"] = *disk.PopulatedSize
+			}
+			disks = append(disks, m)
+		}
+	}
+
+	var deploymentOptions []map[string]interface{}
+	if e.DeploymentOption != nil {
+		for _, c := range e.DeploymentOption.Configuration {
+			deploymentOptions = append(deploymentOptions, map[string]interface{}{
+				"id":          c.ID,
+				"label":       c.Label,
+				"description": c.Description,
+				"default":     structure.BoolNilFalse(c.Default),
+			})
+		}
+	}
+
+	var eula []string
+	for _, section := range ovfdeploy.EulaSections(e) {
+		eula = append(eula, section.License)
+	}
+
+	return structure.SetBatch(d, map[string]interface{}{
+		"properties":         properties,
+		"networks":           networks,
+		"disks":              disks,
+		"deployment_options": deploymentOptions,
+		"eula":               eula,
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func dataSourceVSphereVirtualMachineSnapshots() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVSphereVirtualMachineSnapshotsRead,
+
+		Schema: map[string]*schema.Schema{
+			"virtual_machine_uuid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The UUID of the virtual machine.",
+			},
+			"consolidation_needed": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the disks of the virtual machine need to be consolidated.",
+			},
+			"current_snapshot_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The managed object ID of the current snapshot of the virtual machine.",
+			},
+			"snapshots": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The snapshots of the virtual machine, with each snapshot followed by its children.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object ID of the snapshot.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the snapshot.",
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The description of the snapshot.",
+						},
+						"parent_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The managed object ID of the parent snapshot, empty for a root snapshot.",
+						},
+						"depth": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The depth of the snapshot in the snapshot tree, 0 for a root snapshot.",
+						},
+						"create_time": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time the snapshot was taken, in RFC3339 format.",
+						},
+						"quiesced": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the guest file system was quiesced when the snapshot was taken.",
+						},
+						"memory": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the snapshot includes the memory of the virtual machine.",
+						},
+						"current": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether this is the current snapshot of the virtual machine.",
+						},
+						"size": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The size of the files of the snapshot on the datastore, in bytes.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVSphereVirtualMachineSnapshotsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	uuid := d.Get("virtual_machine_uuid").(string)
+	vm, err := virtualmachine.FromUUID(client, uuid)
+	if err != nil {
+		return fmt.Errorf("cannot locate virtual machine with UUID %q: %s", uuid, err)
+	}
+	props, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return fmt.Errorf("error fetching virtual machine properties: %s", err)
+	}
+
+	var current *types.ManagedObjectReference
+	var tree []types.VirtualMachineSnapshotTree
+	if props.Snapshot != nil {
+		current = props.Snapshot.CurrentSnapshot
+		tree = props.Snapshot.RootSnapshotList
+	}
+	currentID := ""
+	if current != nil {
+		currentID = current.Value
+	}
+
+	var snapshots []interface{}
+	var walk func([]types.VirtualMachineSnapshotTree, *types.ManagedObjectReference, int)
+	walk = func(list []types.VirtualMachineSnapshotTree, parent *types.ManagedObjectReference, depth int) {
+		for _, s := range list {
+			parentID := ""
+			if parent != nil {
+				parentID = parent.Value
+			}
+			isCurrent := s.Snapshot.Value == currentID
+			var size int
+			if props.LayoutEx != nil {
+				size = object.SnapshotSize(s.Snapshot, parent, props.LayoutEx, isCurrent)
+			}
+			snapshots = append(snapshots, map[string]interface{}{
+				"id":          s.Snapshot.Value,
+				"name":        s.Name,
+				"description": s.Description,
+				"parent_id":   parentID,
+				"depth":       depth,
+				"create_time": s.CreateTime.Format(time.RFC3339),
+				"quiesced":    s.Quiesced,
+				"memory":      s.State == types.VirtualMachinePowerStatePoweredOn,
+				"current":     isCurrent,
+				"size":        size,
+			})
+			ref := s.Snapshot
+			walk(s.ChildSnapshotList, &ref, depth+1)
+		}
+	}
+	walk(tree, nil, 0)
+
+	d.SetId(uuid)
+	_ = d.Set("consolidation_needed", props.Runtime.ConsolidationNeeded != nil && *props.Runtime.ConsolidationNeeded)
+	_ = d.Set("current_snapshot_id", currentID)
+	if err := d.Set("snapshots", snapshots); err != nil {
+		return fmt.Errorf("error setting snapshots: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/customattribute"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// deletionProtectionKey is the attribute key for deletion protection on the
+// resources that support it.
+const deletionProtectionKey = "deletion_protection"
+
+// vSphereContentLibraryType is the object type of content libraries, used to
+// look up the tags attached to a library.
+const vSphereContentLibraryType = "com.vmware.content.Library"
+
+// deletionProtectionSchema returns the schema for the deletion_protection
+// attribute for each resource that supports it.
+func deletionProtectionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Prevent Terraform from destroying this object. Set to false and apply before destroying or replacing the object.",
+	}
+}
+
+// checkDeletionProtection returns an error if the deletion of the object is
+// blocked, either by the deletion_protection attribute of the resource, or by
+// the tag or custom attribute configured in the provider through
+// deletion_protection_tag and deletion_protection_custom_attribute.
+//
+// Custom attributes are only checked on managed entities, ie: not on content
+// libraries.
+func checkDeletionProtection(d *schema.ResourceData, meta interface{}, ref types.ManagedObjectReference) error {
+	if d.Get(deletionProtectionKey).(bool) {
+		return fmt.Errorf("cannot destroy %s %q: deletion_protection is enabled, set it to false and apply before destroying", ref.Type, ref.Value)
+	}
+	client := meta.(*Client)
+
+	if client.deletionProtectionTag != "" {
+		protected, err := hasDeletionProtectionTag(client, ref)
+		if err != nil {
+			return fmt.Errorf("cannot verify the deletion protection tag of %s %q: %s", ref.Type, ref.Value, err)
+		}
+		if protected {
+			return fmt.Errorf("cannot destroy %s %q: it is tagged with the deletion protection tag %q", ref.Type, ref.Value, client.deletionProtectionTag)
+		}
+	}
+
+	if client.deletionProtectionCustomAttribute != "" && ref.Type != vSphereContentLibraryType {
+		value, err := deletionProtectionCustomAttributeValue(client, ref)
+		if err != nil {
+			return fmt.Errorf("cannot verify the deletion protection custom attribute of %s %q: %s", ref.Type, ref.Value, err)
+		}
+		if value != "" && !strings.EqualFold(value, "false") {
+			return fmt.Errorf("cannot destroy %s %q: the deletion protection custom attribute %q is set to %q", ref.Type, ref.Value, client.deletionProtectionCustomAttribute, value)
+		}
+	}
+	return nil
+}
+
+// hasDeletionProtectionTag returns true if the tag configured in
+// deletion_protection_tag, in the form category/tag, is attached to the
+// object.
+func hasDeletionProtectionTag(client *Client, ref types.ManagedObjectReference) (bool, error) {
+	parts := strings.SplitN(client.deletionProtectionTag, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return false, fmt.Errorf("invalid deletion_protection_tag %q, expected <category>/<tag>", client.deletionProtectionTag)
+	}
+	tm, err := client.TagsManager()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	attached, err := tm.GetAttachedTags(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	for _, tag := range attached {
+		if tag.Name != parts[1] {
+			continue
+		}
+		category, err := tm.GetCategory(ctx, tag.CategoryID)
+		if err != nil {
+			return false, err
+		}
+		if category.Name == parts[0] {
+			log.Printf("[DEBUG] %s %q is tagged with deletion protection tag %q", ref.Type, ref.Value, client.deletionProtectionTag)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deletionProtectionCustomAttributeValue returns the value of the custom
+// attribute configured in deletion_protection_custom_attribute on the
+// object, or an empty string if it is not set.
+func deletionProtectionCustomAttributeValue(client *Client, ref types.ManagedObjectReference) (string, error) {
+	if err := customattribute.VerifySupport(client.vimClient); err != nil {
+		return "", err
+	}
+	fm, err := object.GetCustomFieldsManager(client.vimClient.Client)
+	if err != nil {
+		return "", err
+	}
+	def, err := customattribute.ByName(fm, client.deletionProtectionCustomAttribute)
+	if err == object.ErrKeyNameNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	var entity mo.ManagedEntity
+	if err := object.NewCommon(client.vimClient.Client, ref).Properties(ctx, ref, []string{"customValue"}, &entity); err != nil {
+		return "", err
+	}
+	for _, v := range entity.CustomValue {
+		if sv, ok := v.(*types.CustomFieldStringValue); ok && sv.Key == def.Key {
+			return sv.Value, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testDeletionProtectionData(t *testing.T, protected bool) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, map[string]*schema.Schema{
+		deletionProtectionKey: deletionProtectionSchema(),
+	}, map[string]interface{}{deletionProtectionKey: protected})
+}
+
+func TestCheckDeletionProtection(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		rc := rest.NewClient(c)
+		if err := rc.Login(ctx, simulator.DefaultLogin); err != nil {
+			t.Fatal(err)
+		}
+		client := &Client{
+			vimClient:  &govmomi.Client{Client: c, SessionManager: session.NewManager(c)},
+			restClient: rc,
+		}
+
+		finder := find.NewFinder(c)
+		dc, err := finder.DefaultDatacenter(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		finder.SetDatacenter(dc)
+		vms, err := finder.VirtualMachineList(ctx, "*")
+		if err != nil || len(vms) < 2 {
+			t.Fatalf("expected at least 2 virtual machines, got %d: %v", len(vms), err)
+		}
+		protectedVM, unprotectedVM := vms[0].Reference(), vms[1].Reference()
+
+		// Unprotected by default.
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, protectedVM); err != nil {
+			t.Fatalf("expected no error without protection, got %s", err)
+		}
+		if err := checkDeletionProtection(testDeletionProtectionData(t, true), client, protectedVM); err == nil || !strings.Contains(err.Error(), "deletion_protection is enabled") {
+			t.Fatalf("expected the deletion_protection attribute to block deletion, got %v", err)
+		}
+
+		// Tag.
+		tm := tags.NewManager(rc)
+		categoryID, err := tm.CreateCategory(ctx, &tags.Category{Name: "protection", Cardinality: "SINGLE"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tagID, err := tm.CreateTag(ctx, &tags.Tag{Name: "protected", CategoryID: categoryID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tm.AttachTag(ctx, tagID, protectedVM); err != nil {
+			t.Fatal(err)
+		}
+		client.deletionProtectionTag = "protection/protected"
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, protectedVM); err == nil || !strings.Contains(err.Error(), "deletion protection tag") {
+			t.Fatalf("expected the tag to block deletion, got %v", err)
+		}
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, unprotectedVM); err != nil {
+			t.Fatalf("expected an untagged object not to be protected, got %s", err)
+		}
+		client.deletionProtectionTag = "other/protected"
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, protectedVM); err != nil {
+			t.Fatalf("expected a tag of another category not to protect the object, got %s", err)
+		}
+		client.deletionProtectionTag = "protected"
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, protectedVM); err == nil || !strings.Contains(err.Error(), "invalid deletion_protection_tag") {
+			t.Fatalf("expected an invalid tag to fail, got %v", err)
+		}
+		client.deletionProtectionTag = ""
+
+		// Custom attribute.
+		client.deletionProtectionCustomAttribute = "protected"
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, protectedVM); err != nil {
+			t.Fatalf("expected an undefined custom attribute not to protect the object, got %s", err)
+		}
+		fm, err := object.GetCustomFieldsManager(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		field, err := fm.Add(ctx, "protected", "", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			value     string
+			protected bool
+		}{
+			{"", false},
+			{"false", false},
+			{"FALSE", false},
+			{"true", true},
+			{"yes", true},
+		} {
+			if err := fm.Set(ctx, protectedVM, field.Key, tc.value); err != nil {
+				t.Fatal(err)
+			}
+			err := checkDeletionProtection(testDeletionProtectionData(t, false), client, protectedVM)
+			if tc.protected != (err != nil) {
+				t.Fatalf("custom attribute set to %q: expected protected %t, got %v", tc.value, tc.protected, err)
+			}
+		}
+
+		// Custom attributes are not checked on content libraries.
+		library := types.ManagedObjectReference{Type: vSphereContentLibraryType, Value: "library-1"}
+		if err := checkDeletionProtection(testDeletionProtectionData(t, false), client, library); err != nil {
+			t.Fatalf("expected the custom attribute not to be checked on content libraries, got %s", err)
+		}
+	})
+}
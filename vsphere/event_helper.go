@@ -5,13 +5,18 @@ package vsphere
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/guest"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -19,6 +24,59 @@ const (
 	eventTypeVMPoweredOffEvent = "VmPoweredOffEvent"
 )
 
+// customizationGuestLogMaxBytes is the maximum amount of the guest
+// customization log, counted from the end of the file, that is included in a
+// customizationFailedError.
+const customizationGuestLogMaxBytes = 16 * 1024
+
+// customizationFailedError is the error returned by the customization waiter
+// when a CustomizationFailed event, or any of its subtypes, is received for a
+// virtual machine.
+type customizationFailedError struct {
+	// The type of the failure event, ie: CustomizationNetworkSetupFailed.
+	EventType string
+
+	// The full formatted message of the event.
+	Message string
+
+	// The reason for the failure, if supplied by the event.
+	Reason string
+
+	// The location of the customization log in the guest.
+	LogLocation string
+
+	// The tail of the customization log, if it could be retrieved from the
+	// guest.
+	GuestLog string
+}
+
+// newCustomizationFailedError returns a customizationFailedError for the
+// supplied failure event.
+func newCustomizationFailedError(e types.BaseCustomizationFailed) *customizationFailedError {
+	cf := e.GetCustomizationFailed()
+	return &customizationFailedError{
+		EventType:   reflect.TypeOf(e).Elem().Name(),
+		Message:     cf.FullFormattedMessage,
+		Reason:      cf.Reason,
+		LogLocation: cf.LogLocation,
+	}
+}
+
+func (e *customizationFailedError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", e.EventType, e.Message)
+	if e.Reason != "" {
+		fmt.Fprintf(&b, "\nReason: %s", e.Reason)
+	}
+	if e.LogLocation != "" {
+		fmt.Fprintf(&b, "\nGuest customization log: %s", e.LogLocation)
+	}
+	if e.GuestLog != "" {
+		fmt.Fprintf(&b, "\n\nGuest customization log contents (last %d bytes):\n\n%s", customizationGuestLogMaxBytes, e.GuestLog)
+	}
+	return b.String()
+}
+
 // virtualMachineCustomizationWaiter is an object that waits for customization
 // of a VirtualMachine to complete, by watching for success or failure events.
 //
@@ -32,6 +90,10 @@ type virtualMachineCustomizationWaiter struct {
 	// itself, timeouts waiting for the completion events, or other API-related
 	// errors. This will always be nil until done is closed.
 	err error
+
+	// The guest credentials used to retrieve the customization log from the
+	// guest on failure. When nil, the log is not retrieved.
+	guestAuth types.BaseGuestAuthentication
 }
 
 // Done returns the done channel. This channel will be closed upon completion,
@@ -54,9 +116,13 @@ func (w *virtualMachineCustomizationWaiter) Err() error {
 //
 // The timeout value is in minutes - a value of less than 1 disables the waiter
 // and returns immediately without error.
-func newVirtualMachineCustomizationWaiter(client *govmomi.Client, vm *object.VirtualMachine, timeout int) *virtualMachineCustomizationWaiter {
+//
+// If guestAuth is not nil, it is used to retrieve the customization log from
+// the guest when customization fails, which is then included in the error.
+func newVirtualMachineCustomizationWaiter(client *govmomi.Client, vm *object.VirtualMachine, timeout int, guestAuth types.BaseGuestAuthentication) *virtualMachineCustomizationWaiter {
 	w := &virtualMachineCustomizationWaiter{
-		done: make(chan struct{}),
+		done:      make(chan struct{}),
+		guestAuth: guestAuth,
 	}
 	go func() {
 		w.err = w.wait(client, vm, timeout)
@@ -68,8 +134,8 @@ func newVirtualMachineCustomizationWaiter(client *govmomi.Client, vm *object.Vir
 // wait waits for the customization of a supplied VirtualMachine to complete,
 // either due to success or error. It does this by watching specifically for
 // CustomizationSucceeded and CustomizationFailed events. If the customization
-// failed due to some sort of error, the full formatted message is returned as
-// an error.
+// failed due to some sort of error, a customizationFailedError carrying the
+// event type, reason, and log location is returned.
 func (w *virtualMachineCustomizationWaiter) wait(client *govmomi.Client, vm *object.VirtualMachine, timeout int) error {
 	// A timeout of less than 1 minute (zero or negative value) skips the waiter,
 	// so we return immediately.
@@ -83,7 +149,7 @@ func (w *virtualMachineCustomizationWaiter) wait(client *govmomi.Client, vm *obj
 		for _, be := range page {
 			switch e := be.(type) {
 			case types.BaseCustomizationFailed:
-				cbErr <- errors.New(e.GetCustomizationFailed().GetEvent().FullFormattedMessage)
+				cbErr <- newCustomizationFailedError(e)
 			case *types.CustomizationSucceeded:
 				close(cbErr)
 			}
@@ -117,9 +183,71 @@ func (w *virtualMachineCustomizationWaiter) wait(client *govmomi.Client, vm *obj
 	case err = <-mgrErr:
 	case err = <-cbErr:
 	}
+	if cfErr, ok := err.(*customizationFailedError); ok && w.guestAuth != nil && cfErr.LogLocation != "" {
+		guestLog, lerr := readGuestFileTail(client, vm, w.guestAuth, cfErr.LogLocation, customizationGuestLogMaxBytes)
+		if lerr != nil {
+			cfErr.GuestLog = fmt.Sprintf("<could not retrieve customization log: %s>", lerr)
+		} else {
+			cfErr.GuestLog = guestLog
+		}
+	}
 	return err
 }
 
+// readGuestFileTail downloads the file at path from the guest of the supplied
+// virtual machine through guest operations and returns at most the last max
+// bytes of its contents. Only the tail of the file is requested, and the rest
+// is skipped if the host does not support range requests.
+func readGuestFileTail(client *govmomi.Client, vm *object.VirtualMachine, auth types.BaseGuestAuthentication, path string, max int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	fm, err := guest.NewOperationsManager(client.Client, vm.Reference()).FileManager(ctx)
+	if err != nil {
+		return "", err
+	}
+	info, err := fm.InitiateFileTransferFromGuest(ctx, auth, path)
+	if err != nil {
+		return "", err
+	}
+	u, err := fm.TransferURL(ctx, info.Url)
+	if err != nil {
+		return "", err
+	}
+	param := soap.DefaultDownload
+	if offset := info.Size - int64(max); offset > 0 {
+		param.Headers = map[string]string{"Range": fmt.Sprintf("bytes=%d-", offset)}
+	}
+	res, err := client.Client.DownloadRequest(ctx, u, &param)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	return guestFileTail(res, info.Size, max)
+}
+
+// guestFileTail returns at most the last max bytes of a guest file of the
+// supplied size from the response to its download. A partial response only
+// holds the tail of the file, while the beginning of a full response is
+// skipped without being kept in memory.
+func guestFileTail(res *http.Response, size int64, max int) (string, error) {
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		if skip := size - int64(max); skip > 0 {
+			if _, err := io.CopyN(io.Discard, res.Body, skip); err != nil {
+				return "", err
+			}
+		}
+	default:
+		return "", fmt.Errorf("error downloading guest file: %s", res.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(res.Body, int64(max)))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 // selectEventsForReference allows you to query events for a specific
 // ManagedObjectReference.
 //
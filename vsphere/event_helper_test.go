@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCustomizationFailedError(t *testing.T) {
+	cases := []struct {
+		name     string
+		event    types.BaseCustomizationFailed
+		expected []string
+	}{
+		{
+			name: "network setup failed",
+			event: &types.CustomizationNetworkSetupFailed{
+				CustomizationFailed: types.CustomizationFailed{
+					CustomizationEvent: types.CustomizationEvent{
+						VmEvent:     types.VmEvent{Event: types.Event{FullFormattedMessage: "Network setup failed in the guest"}},
+						LogLocation: `C:\Windows\TEMP\vmware-imc\guestcust.log`,
+					},
+					Reason: "GuestCustomizationNetworkSetupFailed",
+				},
+			},
+			expected: []string{
+				"CustomizationNetworkSetupFailed: Network setup failed in the guest",
+				"Reason: GuestCustomizationNetworkSetupFailed",
+				`Guest customization log: C:\Windows\TEMP\vmware-imc\guestcust.log`,
+			},
+		},
+		{
+			name: "unknown failure without log",
+			event: &types.CustomizationUnknownFailure{
+				CustomizationFailed: types.CustomizationFailed{
+					CustomizationEvent: types.CustomizationEvent{
+						VmEvent: types.VmEvent{Event: types.Event{FullFormattedMessage: "An error occurred while customizing VM"}},
+					},
+				},
+			},
+			expected: []string{
+				"CustomizationUnknownFailure: An error occurred while customizing VM",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newCustomizationFailedError(tc.event)
+			actual := err.Error()
+			if len(strings.Split(actual, "\n")) != len(tc.expected) {
+				t.Fatalf("expected %d lines, got:\n%s", len(tc.expected), actual)
+			}
+			for _, s := range tc.expected {
+				if !strings.Contains(actual, s) {
+					t.Fatalf("expected error to contain %q, got:\n%s", s, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestGuestFileTail(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		size     int64
+		expected string
+	}{
+		{"partial", http.StatusPartialContent, "6789", 10, "6789"},
+		{"full", http.StatusOK, "0123456789", 10, "6789"},
+		{"small", http.StatusOK, "012", 3, "012"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tc.status, Body: io.NopCloser(strings.NewReader(tc.body))}
+			actual, err := guestFileTail(res, tc.size, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+	res := &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}
+	if _, err := guestFileTail(res, 10, 4); err == nil {
+		t.Fatal("expected an error for a failed download")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostCertificateManagerFromHostSystemID locates a HostCertificateManager
+// from a specified HostSystem managed object ID.
+func hostCertificateManagerFromHostSystemID(client *govmomi.Client, hsID string) (*object.HostCertificateManager, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return hs.ConfigManager().CertificateManager(ctx)
+}
+
+// parseHostCertificatePEM parses all the certificates in a PEM string.
+func parseHostCertificatePEM(s string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(s)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %q", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, errors.New("unexpected data after the last PEM encoded certificate")
+	}
+	return certs, nil
+}
+
+// validateHostCertificatePEM is a schema validation function for attributes
+// holding PEM encoded certificates.
+func validateHostCertificatePEM(v interface{}, k string) ([]string, []error) {
+	if _, err := parseHostCertificatePEM(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s: invalid certificate: %s", k, err)}
+	}
+	return nil, nil
+}
+
+// hostCertificatePEM returns the PEM encoding of a certificate, used to
+// compare certificates regardless of their formatting.
+func hostCertificatePEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// hostCertificateThumbprint returns the SHA-1 thumbprint of a certificate in
+// the format used by vSphere, ie: 01:23:45:...
+func hostCertificateThumbprint(cert *x509.Certificate) string {
+	fingerprint := sha1.Sum(cert.Raw)
+	s := make([]string, len(fingerprint))
+	for i, f := range fingerprint {
+		s[i] = fmt.Sprintf("%02X", f)
+	}
+	return strings.Join(s, ":")
+}
+
+// reconnectHostWithThumbprint reconnects a host to vCenter, accepting the
+// supplied SSL thumbprint. It is used after the certificate of the host is
+// replaced, as vCenter no longer trusts the host until then. Nothing is done
+// on a standalone host.
+func reconnectHostWithThumbprint(client *govmomi.Client, hs *object.HostSystem, thumbprint string) error {
+	if err := viapi.ValidateVirtualCenter(client); err != nil {
+		return nil
+	}
+	log.Printf("[DEBUG] Reconnecting host %q with SSL thumbprint %s", hs.Reference().Value, thumbprint)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	task, err := hs.Reconnect(ctx, hostReconnectSpec(thumbprint), nil)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// hostReconnectSpec returns the connection spec used to reconnect a host
+// with a new SSL thumbprint. Only the thumbprint is set: on reconnect, vCenter
+// Server keeps the current value of every setting that is not in the spec,
+// such as the port, the credentials and the lockdown mode. force is always
+// sent, and false only matters when the host is managed by another vCenter
+// Server.
+func hostReconnectSpec(thumbprint string) *types.HostConnectSpec {
+	return &types.HostConnectSpec{SslThumbprint: thumbprint}
+}
+
+// hostCertificateIsVMCARoot returns true if the PEM encoded certificate is a
+// root certificate of the VMware Certificate Authority (VMCA) of a vCenter
+// Server, ie: a self-signed CA certificate with the VMware Engineering
+// organizational unit in its subject.
+func hostCertificateIsVMCARoot(s string) bool {
+	certs, err := parseHostCertificatePEM(s)
+	if err != nil {
+		return false
+	}
+	for _, cert := range certs {
+		if !cert.IsCA || !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+			continue
+		}
+		for _, ou := range cert.Subject.OrganizationalUnit {
+			if ou == "VMware Engineering" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mergeHostCACertificates returns the CA certificates trusted by the host,
+// current, without the certificates in remove and with the certificates in
+// add. The VMCA root certificates are never removed, so that the host keeps
+// trusting vCenter Server. Certificates are compared regardless of their
+// formatting. The second return value is false if the result is the same as
+// current.
+func mergeHostCACertificates(current, add, remove []string) ([]string, bool) {
+	removed := make(map[string]bool)
+	for _, c := range remove {
+		removed[normalizeHostCertificatePEM(c)] = true
+	}
+	var merged []string
+	seen := make(map[string]bool)
+	changed := false
+	for _, c := range current {
+		n := normalizeHostCertificatePEM(c)
+		if removed[n] && !hostCertificateIsVMCARoot(c) {
+			changed = true
+			continue
+		}
+		seen[n] = true
+		merged = append(merged, c)
+	}
+	for _, c := range add {
+		n := normalizeHostCertificatePEM(c)
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		changed = true
+		merged = append(merged, c)
+	}
+	return merged, changed
+}
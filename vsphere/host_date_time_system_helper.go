@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostDateTimeSystemFromHostSystemID locates a HostDateTimeSystem from a
+// specified HostSystem managed object ID.
+func hostDateTimeSystemFromHostSystemID(client *govmomi.Client, hsID string) (*object.HostDateTimeSystem, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return hs.ConfigManager().DateTimeSystem(ctx)
+}
+
+// hostDateTimeInfo returns the date and time configuration of the supplied
+// HostDateTimeSystem.
+func hostDateTimeInfo(client *govmomi.Client, dts *object.HostDateTimeSystem) (*types.HostDateTimeInfo, error) {
+	var mdts mo.HostDateTimeSystem
+	pc := client.PropertyCollector()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, dts.Reference(), []string{"dateTimeInfo"}, &mdts); err != nil {
+		return nil, fmt.Errorf("error fetching host date and time properties: %s", err)
+	}
+	return &mdts.DateTimeInfo, nil
+}
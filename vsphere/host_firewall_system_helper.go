@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostFirewallSystemFromHostSystemID locates a HostFirewallSystem from a
+// specified HostSystem managed object ID.
+func hostFirewallSystemFromHostSystemID(client *govmomi.Client, hsID string) (*object.HostFirewallSystem, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return hs.ConfigManager().FirewallSystem(ctx)
+}
+
+// hostFirewallRulesetFromKey locates a firewall ruleset on the supplied
+// HostFirewallSystem by key, ie: syslog or nfs41Client.
+func hostFirewallRulesetFromKey(fs *object.HostFirewallSystem, key string) (*types.HostFirewallRuleset, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	info, err := fs.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching host firewall information: %s", err)
+	}
+	for _, rs := range info.Ruleset {
+		if rs.Key == key {
+			return &rs, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find firewall ruleset %s", key)
+}
+
+// updateHostFirewallRuleset sets the hosts that are allowed to connect
+// through a firewall ruleset.
+func updateHostFirewallRuleset(fs *object.HostFirewallSystem, key string, allowed types.HostFirewallRulesetIpList) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err := methods.UpdateRuleset(ctx, fs.Client(), &types.UpdateRuleset{
+		This: fs.Reference(),
+		Id:   key,
+		Spec: types.HostFirewallRulesetRulesetSpec{
+			AllowedHosts: allowed,
+		},
+	})
+	return err
+}
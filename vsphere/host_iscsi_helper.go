@@ -0,0 +1,271 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var hostInternetScsiChapAuthenticationTypeAllowedValues = []string{
+	string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited),
+	string(types.HostInternetScsiHbaChapAuthenticationTypeChapDiscouraged),
+	string(types.HostInternetScsiHbaChapAuthenticationTypeChapPreferred),
+	string(types.HostInternetScsiHbaChapAuthenticationTypeChapRequired),
+}
+
+// schemaHostInternetScsiChap returns the schema of the chap block, used for
+// the CHAP settings of iSCSI adapters and targets.
+func schemaHostInternetScsiChap() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "The CHAP authentication settings.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"method": {
+					Type:         schema.TypeString,
+					Required:     true,
+					Description:  "The CHAP authentication of the initiator by the target. Can be one of chapProhibited, chapDiscouraged, chapPreferred or chapRequired.",
+					ValidateFunc: validation.StringInSlice(hostInternetScsiChapAuthenticationTypeAllowedValues, false),
+				},
+				"name": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The CHAP name of the initiator.",
+				},
+				"secret": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "The CHAP secret of the initiator.",
+				},
+				"mutual_method": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited),
+					Description:  "The CHAP authentication of the target by the initiator. Can be one of chapProhibited or chapRequired.",
+					ValidateFunc: validation.StringInSlice(hostInternetScsiChapAuthenticationTypeAllowedValues, false),
+				},
+				"mutual_name": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The CHAP name of the target for mutual CHAP.",
+				},
+				"mutual_secret": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "The CHAP secret of the target for mutual CHAP.",
+				},
+			},
+		},
+	}
+}
+
+// expandHostInternetScsiChap reads the chap block into authentication
+// properties. Without a chap block, CHAP is disabled on an adapter, and
+// inherited from the adapter on a target.
+func expandHostInternetScsiChap(l []interface{}, target bool) types.HostInternetScsiHbaAuthenticationProperties {
+	if len(l) == 0 || l[0] == nil {
+		if target {
+			return types.HostInternetScsiHbaAuthenticationProperties{
+				ChapInherited:       types.NewBool(true),
+				MutualChapInherited: types.NewBool(true),
+			}
+		}
+		return types.HostInternetScsiHbaAuthenticationProperties{
+			ChapAuthEnabled:              false,
+			ChapAuthenticationType:       string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited),
+			MutualChapAuthenticationType: string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited),
+		}
+	}
+	m := l[0].(map[string]interface{})
+	props := types.HostInternetScsiHbaAuthenticationProperties{
+		ChapAuthenticationType:       m["method"].(string),
+		ChapName:                     m["name"].(string),
+		ChapSecret:                   m["secret"].(string),
+		MutualChapAuthenticationType: m["mutual_method"].(string),
+		MutualChapName:               m["mutual_name"].(string),
+		MutualChapSecret:             m["mutual_secret"].(string),
+	}
+	props.ChapAuthEnabled = props.ChapAuthenticationType != string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited)
+	if target {
+		props.ChapInherited = types.NewBool(false)
+		props.MutualChapInherited = types.NewBool(false)
+	}
+	return props
+}
+
+// flattenHostInternetScsiChap returns the chap block for the supplied
+// authentication properties. The host does not return the secrets, so they
+// are kept from the current state. No block is returned when CHAP is
+// inherited or prohibited.
+func flattenHostInternetScsiChap(d *schema.ResourceData, props *types.HostInternetScsiHbaAuthenticationProperties) []interface{} {
+	if props == nil {
+		return nil
+	}
+	inherited := props.ChapInherited != nil && *props.ChapInherited
+	method := props.ChapAuthenticationType
+	if method == "" && props.ChapAuthEnabled {
+		method = string(types.HostInternetScsiHbaChapAuthenticationTypeChapRequired)
+	}
+	if inherited || method == "" || method == string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited) {
+		return nil
+	}
+	mutualMethod := props.MutualChapAuthenticationType
+	if mutualMethod == "" {
+		mutualMethod = string(types.HostInternetScsiHbaChapAuthenticationTypeChapProhibited)
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"method":        method,
+			"name":          props.ChapName,
+			"secret":        d.Get("chap.0.secret").(string),
+			"mutual_method": mutualMethod,
+			"mutual_name":   props.MutualChapName,
+			"mutual_secret": d.Get("chap.0.mutual_secret").(string),
+		},
+	}
+}
+
+// hostStorageDeviceInfo returns the storage device information of the
+// supplied HostStorageSystem.
+func hostStorageDeviceInfo(client *govmomi.Client, ss *object.HostStorageSystem) (*types.HostStorageDeviceInfo, error) {
+	var mss mo.HostStorageSystem
+	pc := client.PropertyCollector()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, ss.Reference(), []string{"storageDeviceInfo"}, &mss); err != nil {
+		return nil, fmt.Errorf("error fetching host storage properties: %s", err)
+	}
+	if mss.StorageDeviceInfo == nil {
+		return nil, errors.New("host storage system returned no storage device information")
+	}
+	return mss.StorageDeviceInfo, nil
+}
+
+// hostSoftwareInternetScsiHba returns the software iSCSI adapter of the host,
+// or nil if software iSCSI is not enabled.
+func hostSoftwareInternetScsiHba(client *govmomi.Client, ss *object.HostStorageSystem) (*types.HostInternetScsiHba, error) {
+	info, err := hostStorageDeviceInfo(client, ss)
+	if err != nil {
+		return nil, err
+	}
+	if !info.SoftwareInternetScsiEnabled {
+		return nil, nil
+	}
+	for _, hba := range info.HostBusAdapter {
+		if iscsi, ok := hba.(*types.HostInternetScsiHba); ok && iscsi.IsSoftwareBased {
+			return iscsi, nil
+		}
+	}
+	return nil, nil
+}
+
+// hostInternetScsiHbaFromDevice returns the iSCSI adapter of the host with
+// the supplied device name, ie: vmhba65.
+func hostInternetScsiHbaFromDevice(client *govmomi.Client, ss *object.HostStorageSystem, device string) (*types.HostInternetScsiHba, error) {
+	info, err := hostStorageDeviceInfo(client, ss)
+	if err != nil {
+		return nil, err
+	}
+	for _, hba := range info.HostBusAdapter {
+		if iscsi, ok := hba.(*types.HostInternetScsiHba); ok && iscsi.Device == device {
+			return iscsi, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find iSCSI adapter %s", device)
+}
+
+// updateSoftwareInternetScsiEnabled enables or disables software iSCSI on the
+// host.
+func updateSoftwareInternetScsiEnabled(ss *object.HostStorageSystem, enabled bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err := methods.UpdateSoftwareInternetScsiEnabled(ctx, ss.Client(), &types.UpdateSoftwareInternetScsiEnabled{
+		This:    ss.Reference(),
+		Enabled: enabled,
+	})
+	return err
+}
+
+// updateHostInternetScsiAuthentication sets the CHAP settings of an iSCSI
+// adapter or, if targets is not nil, of the targets in it.
+func updateHostInternetScsiAuthentication(ss *object.HostStorageSystem, device string, props types.HostInternetScsiHbaAuthenticationProperties, targets *types.HostInternetScsiHbaTargetSet) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err := methods.UpdateInternetScsiAuthenticationProperties(ctx, ss.Client(), &types.UpdateInternetScsiAuthenticationProperties{
+		This:                     ss.Reference(),
+		IScsiHbaDevice:           device,
+		AuthenticationProperties: props,
+		TargetSet:                targets,
+	})
+	return err
+}
+
+// rescanHostStorage rescans all the adapters and VMFS volumes of the host, so
+// that LUNs presented by new targets can be used right away.
+func rescanHostStorage(ss *object.HostStorageSystem) error {
+	log.Printf("[DEBUG] Rescanning host storage adapters and VMFS volumes")
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := ss.RescanAllHba(ctx); err != nil {
+		return fmt.Errorf("error rescanning storage adapters: %s", err)
+	}
+	if err := ss.RescanVmfs(ctx); err != nil {
+		return fmt.Errorf("error rescanning VMFS volumes: %s", err)
+	}
+	return nil
+}
+
+// hostIscsiManagerFromHostSystemID returns the reference to the iSCSI manager
+// of the HostSystem with the specified managed object ID.
+func hostIscsiManagerFromHostSystemID(client *govmomi.Client, hsID string) (*types.ManagedObjectReference, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	var props mo.HostSystem
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := hs.Properties(ctx, hs.Reference(), []string{"configManager.iscsiManager"}, &props); err != nil {
+		return nil, err
+	}
+	if props.ConfigManager.IscsiManager == nil {
+		return nil, fmt.Errorf("host %s has no iSCSI manager", hsID)
+	}
+	return props.ConfigManager.IscsiManager, nil
+}
+
+// hostIscsiBoundVnics returns the VMkernel network adapters bound to an iSCSI
+// adapter.
+func hostIscsiBoundVnics(client *govmomi.Client, im types.ManagedObjectReference, device string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.QueryBoundVnics(ctx, client.Client, &types.QueryBoundVnics{
+		This:         im,
+		IScsiHbaName: device,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var vnics []string
+	for _, p := range res.Returnval {
+		vnics = append(vnics, p.VnicDevice)
+	}
+	return vnics, nil
+}
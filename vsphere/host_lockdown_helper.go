@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostDcuiAccessOptionKey is the advanced option that lists the users that
+// can log in to the Direct Console User Interface in normal lockdown mode.
+const hostDcuiAccessOptionKey = "DCUI.Access"
+
+// hostLockdownSystemUsers are the ESXi system accounts that are lockdown
+// exception users by default. ESXi services and solutions installed on the
+// host rely on them in lockdown mode, so they are kept as exception users when
+// the host has them, even if they are not in the configuration.
+var hostLockdownSystemUsers = []string{"da-user", "mux_user"}
+
+// hostDcuiAccessUsers returns the users in the DCUI.Access advanced option of
+// the HostSystem with the specified managed object ID.
+func hostDcuiAccessUsers(client *govmomi.Client, hsID string) ([]string, error) {
+	om, err := hostOptionManagerFromHostSystemID(client, hsID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading advanced option manager of host %s: %s", hsID, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	values, err := om.Query(ctx, hostDcuiAccessOptionKey)
+	if err != nil {
+		if viapi.IsInvalidNameError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error fetching %s of host %s: %s", hostDcuiAccessOptionKey, hsID, err)
+	}
+	for _, v := range values {
+		if ov := v.GetOptionValue(); ov.Key == hostDcuiAccessOptionKey {
+			return splitHostDcuiAccessUsers(hostOptionString(ov.Value)), nil
+		}
+	}
+	return nil, nil
+}
+
+// updateHostDcuiAccessUsers sets the DCUI.Access advanced option of the
+// HostSystem with the specified managed object ID.
+func updateHostDcuiAccessUsers(client *govmomi.Client, hsID string, users []string) error {
+	om, err := hostOptionManagerFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading advanced option manager of host %s: %s", hsID, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	opts := []types.BaseOptionValue{
+		&types.OptionValue{Key: hostDcuiAccessOptionKey, Value: strings.Join(users, ",")},
+	}
+	if err := om.Update(ctx, opts); err != nil {
+		return fmt.Errorf("error updating %s of host %s: %s", hostDcuiAccessOptionKey, hsID, err)
+	}
+	return nil
+}
+
+// splitHostDcuiAccessUsers splits the comma-separated value of the
+// DCUI.Access advanced option into users.
+func splitHostDcuiAccessUsers(s string) []string {
+	var users []string
+	for _, u := range strings.Split(s, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// hostLockdownUserUnion returns the sorted users that are in any of the
+// supplied lists, without duplicates.
+func hostLockdownUserUnion(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, list := range lists {
+		for _, u := range list {
+			if !seen[u] {
+				seen[u] = true
+				users = append(users, u)
+			}
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// hostLockdownUsersEqual returns true if the supplied lists hold the same
+// users, regardless of order and duplicates.
+func hostLockdownUsersEqual(a, b []string) bool {
+	x, y := hostLockdownUserUnion(a), hostLockdownUserUnion(b)
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hostLockdownSystemUserExceptions returns the system users in the supplied
+// lockdown exception users.
+func hostLockdownSystemUserExceptions(users []string) []string {
+	var system []string
+	for _, u := range users {
+		for _, su := range hostLockdownSystemUsers {
+			if u == su {
+				system = append(system, u)
+			}
+		}
+	}
+	return system
+}
+
+// hostLockdownUserExceptions returns the lockdown exception users of the host
+// as saved in the state: the system users are left out, unless they are
+// managed, so that they do not show a diff when they are not configured.
+func hostLockdownUserExceptions(users, managed []string) []string {
+	system := make(map[string]bool)
+	for _, u := range hostLockdownSystemUserExceptions(users) {
+		system[u] = true
+	}
+	for _, u := range managed {
+		delete(system, u)
+	}
+	var result []string
+	for _, u := range users {
+		if !system[u] {
+			result = append(result, u)
+		}
+	}
+	return result
+}
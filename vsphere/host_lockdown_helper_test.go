@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func TestSplitHostDcuiAccessUsers(t *testing.T) {
+	cases := map[string][]string{
+		"":                  nil,
+		"root":              {"root"},
+		"root, svc-backup,": {"root", "svc-backup"},
+	}
+	for in, expected := range cases {
+		if actual := splitHostDcuiAccessUsers(in); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %q to split into %v, got %v", in, expected, actual)
+		}
+	}
+}
+
+func TestHostLockdownUserUnion(t *testing.T) {
+	actual := hostLockdownUserUnion([]string{"svc-monitor", "root"}, []string{"svc-backup", "root"})
+	expected := []string{"root", "svc-backup", "svc-monitor"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if !hostLockdownUsersEqual([]string{"b", "a", "a"}, []string{"a", "b"}) {
+		t.Fatal("expected lists with the same users to be equal")
+	}
+	if hostLockdownUsersEqual([]string{"a"}, []string{"a", "b"}) {
+		t.Fatal("expected lists with different users not to be equal")
+	}
+}
+
+func TestHostLockdownUserExceptions(t *testing.T) {
+	users := []string{"da-user", "mux_user", "root", "svc-backup"}
+	if actual := hostLockdownSystemUserExceptions(users); !reflect.DeepEqual(actual, []string{"da-user", "mux_user"}) {
+		t.Fatalf("expected the system users, got %v", actual)
+	}
+	if actual := hostLockdownUserExceptions(users, nil); !reflect.DeepEqual(actual, []string{"root", "svc-backup"}) {
+		t.Fatalf("expected the system users to be left out, got %v", actual)
+	}
+	if actual := hostLockdownUserExceptions(users, []string{"mux_user"}); !reflect.DeepEqual(actual, []string{"mux_user", "root", "svc-backup"}) {
+		t.Fatalf("expected managed system users to be kept, got %v", actual)
+	}
+}
+
+func TestHostDcuiAccessUsers(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c, SessionManager: session.NewManager(c)}
+		finder := find.NewFinder(c)
+		dc, err := finder.DefaultDatacenter(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		finder.SetDatacenter(dc)
+		hosts, err := finder.HostSystemList(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hsID := hosts[0].Reference().Value
+		users, err := hostDcuiAccessUsers(client, hsID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 0 {
+			t.Fatalf("expected no users, got %v", users)
+		}
+		if err := updateHostDcuiAccessUsers(client, hsID, []string{"root", "admin"}); err != nil {
+			t.Fatal(err)
+		}
+		users, err = hostDcuiAccessUsers(client, hsID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"root", "admin"}; !reflect.DeepEqual(expected, users) {
+			t.Fatalf("expected %v, got %v", expected, users)
+		}
+	})
+}
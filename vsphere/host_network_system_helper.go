@@ -75,3 +75,19 @@ func hostPortGroupFromName(client *govmomi.Client, ns *object.HostNetworkSystem,
 
 	return nil, fmt.Errorf("could not find port group %s", name)
 }
+
+// hostDNSConfig returns the DNS configuration of the supplied
+// HostNetworkSystem.
+func hostDNSConfig(client *govmomi.Client, ns *object.HostNetworkSystem) (*types.HostDnsConfig, error) {
+	var mns mo.HostNetworkSystem
+	pc := client.PropertyCollector()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, ns.Reference(), []string{"dnsConfig"}, &mns); err != nil {
+		return nil, fmt.Errorf("error fetching host network properties: %s", err)
+	}
+	if mns.DnsConfig == nil {
+		return nil, fmt.Errorf("host network system %s has no DNS configuration", ns.Reference().Value)
+	}
+	return mns.DnsConfig.GetHostDnsConfig(), nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostOptionManagerFromHostSystemID locates the advanced option manager of
+// the HostSystem with the specified managed object ID.
+func hostOptionManagerFromHostSystemID(client *govmomi.Client, hsID string) (*object.OptionManager, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return hs.ConfigManager().OptionManager(ctx)
+}
+
+// hostOptionManagerProperties returns the definitions of the options that
+// the supplied OptionManager supports, and their current values, both keyed
+// by option key.
+func hostOptionManagerProperties(client *govmomi.Client, om *object.OptionManager) (map[string]types.OptionDef, map[string]interface{}, error) {
+	var mom mo.OptionManager
+	pc := client.PropertyCollector()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, om.Reference(), []string{"supportedOption", "setting"}, &mom); err != nil {
+		return nil, nil, fmt.Errorf("error fetching advanced options: %s", err)
+	}
+	defs := make(map[string]types.OptionDef)
+	for _, def := range mom.SupportedOption {
+		defs[def.Key] = def
+	}
+	values := make(map[string]interface{})
+	for _, v := range mom.Setting {
+		ov := v.GetOptionValue()
+		values[ov.Key] = ov.Value
+	}
+	return defs, values, nil
+}
+
+// hostOptionReadonly returns true if the option cannot be changed.
+func hostOptionReadonly(def types.OptionDef) bool {
+	if def.OptionType == nil {
+		return false
+	}
+	ro := def.OptionType.GetOptionType().ValueIsReadonly
+	return ro != nil && *ro
+}
+
+// hostOptionValue converts the string value of an option to the type in its
+// definition, as the option manager rejects values of any other type. An
+// error is returned if the value cannot be converted or is out of range.
+func hostOptionValue(def types.OptionDef, s string) (interface{}, error) {
+	switch t := def.OptionType.(type) {
+	case *types.IntOption:
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", def.Key, s)
+		}
+		if int32(v) < t.Min || int32(v) > t.Max {
+			return nil, fmt.Errorf("%s: %d is outside of the range %d to %d", def.Key, v, t.Min, t.Max)
+		}
+		return int32(v), nil
+	case *types.LongOption:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", def.Key, s)
+		}
+		if v < t.Min || v > t.Max {
+			return nil, fmt.Errorf("%s: %d is outside of the range %d to %d", def.Key, v, t.Min, t.Max)
+		}
+		return v, nil
+	case *types.FloatOption:
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", def.Key, s)
+		}
+		return float32(v), nil
+	case *types.BoolOption:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a boolean", def.Key, s)
+		}
+		return v, nil
+	case *types.ChoiceOption:
+		for _, c := range t.ChoiceInfo {
+			if c.GetElementDescription().Key == s {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %q is not one of the allowed choices", def.Key, s)
+	}
+	return s, nil
+}
+
+// hostOptionDefault returns the default value of an option, and false if the
+// definition has no default value.
+func hostOptionDefault(def types.OptionDef) (interface{}, bool) {
+	switch t := def.OptionType.(type) {
+	case *types.IntOption:
+		return t.DefaultValue, true
+	case *types.LongOption:
+		return t.DefaultValue, true
+	case *types.FloatOption:
+		return t.DefaultValue, true
+	case *types.BoolOption:
+		return t.DefaultValue, true
+	case *types.StringOption:
+		return t.DefaultValue, true
+	case *types.ChoiceOption:
+		if int(t.DefaultIndex) < len(t.ChoiceInfo) {
+			return t.ChoiceInfo[t.DefaultIndex].GetElementDescription().Key, true
+		}
+	}
+	return nil, false
+}
+
+// hostOptionString returns the string form of an option value, as saved in
+// the state.
+func hostOptionString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case float32:
+		return strconv.FormatFloat(float64(t), 'g', -1, 32)
+	}
+	return fmt.Sprint(v)
+}
+
+// hostOptionSettingString returns the string form of the current value of an
+// option, as saved in the state. If the configured value converts to the
+// current value, such as 1 and true for a boolean option, the configured
+// value is returned so that it does not show a diff.
+func hostOptionSettingString(def types.OptionDef, configured string, current interface{}) string {
+	if v, err := hostOptionValue(def, configured); err == nil && hostOptionString(v) == hostOptionString(current) {
+		return configured
+	}
+	return hostOptionString(current)
+}
+
+// hostOptionHasPrefix returns true if the option key starts with one of the
+// supplied prefixes.
+func hostOptionHasPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testHostOptionDef(key string, t types.BaseOptionType) types.OptionDef {
+	return types.OptionDef{
+		ElementDescription: types.ElementDescription{Key: key},
+		OptionType:         t,
+	}
+}
+
+func TestHostOptionValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		def      types.OptionDef
+		value    string
+		expected interface{}
+		err      bool
+	}{
+		{
+			name:     "int",
+			def:      testHostOptionDef("Net.TcpipHeapMax", &types.IntOption{Min: 32, Max: 1536, DefaultValue: 1024}),
+			value:    "1536",
+			expected: int32(1536),
+		},
+		{
+			name:  "int out of range",
+			def:   testHostOptionDef("Net.TcpipHeapMax", &types.IntOption{Min: 32, Max: 1536, DefaultValue: 1024}),
+			value: "2048",
+			err:   true,
+		},
+		{
+			name:     "long",
+			def:      testHostOptionDef("UserVars.SuppressShellWarning", &types.LongOption{Min: 0, Max: 1, DefaultValue: 0}),
+			value:    "1",
+			expected: int64(1),
+		},
+		{
+			name:  "long not a number",
+			def:   testHostOptionDef("UserVars.SuppressShellWarning", &types.LongOption{Min: 0, Max: 1, DefaultValue: 0}),
+			value: "yes",
+			err:   true,
+		},
+		{
+			name:     "bool",
+			def:      testHostOptionDef("Config.HostAgent.plugins.solo.enableMob", &types.BoolOption{DefaultValue: false}),
+			value:    "false",
+			expected: false,
+		},
+		{
+			name:  "bool not a boolean",
+			def:   testHostOptionDef("Config.HostAgent.plugins.solo.enableMob", &types.BoolOption{DefaultValue: false}),
+			value: "off",
+			err:   true,
+		},
+		{
+			name:     "string",
+			def:      testHostOptionDef("Syslog.global.logHost", &types.StringOption{}),
+			value:    "tcp://syslog.example.com:514",
+			expected: "tcp://syslog.example.com:514",
+		},
+		{
+			name: "choice",
+			def: testHostOptionDef("Config.HostAgent.log.level", &types.ChoiceOption{
+				ChoiceInfo: []types.BaseElementDescription{
+					&types.ElementDescription{Key: "info"},
+					&types.ElementDescription{Key: "verbose"},
+				},
+			}),
+			value:    "verbose",
+			expected: "verbose",
+		},
+		{
+			name: "invalid choice",
+			def: testHostOptionDef("Config.HostAgent.log.level", &types.ChoiceOption{
+				ChoiceInfo: []types.BaseElementDescription{
+					&types.ElementDescription{Key: "info"},
+				},
+			}),
+			value: "trivia",
+			err:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := hostOptionValue(tc.def, tc.value)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got %#v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHostOptionDefault(t *testing.T) {
+	choice := testHostOptionDef("Config.HostAgent.log.level", &types.ChoiceOption{
+		ChoiceInfo: []types.BaseElementDescription{
+			&types.ElementDescription{Key: "info"},
+			&types.ElementDescription{Key: "verbose"},
+		},
+		DefaultIndex: 1,
+	})
+	if v, ok := hostOptionDefault(choice); !ok || v != "verbose" {
+		t.Fatalf("expected verbose, got %#v", v)
+	}
+	long := testHostOptionDef("UserVars.SuppressShellWarning", &types.LongOption{Max: 1})
+	if v, ok := hostOptionDefault(long); !ok || hostOptionString(v) != "0" {
+		t.Fatalf("expected 0, got %#v", v)
+	}
+	if _, ok := hostOptionDefault(testHostOptionDef("Unknown", nil)); ok {
+		t.Fatal("expected no default for an option without a type")
+	}
+}
+
+func TestHostOptionSettingString(t *testing.T) {
+	boolOpt := testHostOptionDef("Config.HostAgent.plugins.solo.enableMob", &types.BoolOption{})
+	longOpt := testHostOptionDef("Net.TcpipHeapMax", &types.LongOption{Max: 1536})
+	cases := []struct {
+		name       string
+		def        types.OptionDef
+		configured string
+		current    interface{}
+		expected   string
+	}{
+		{"bool as number", boolOpt, "0", false, "0"},
+		{"bool as word", boolOpt, "true", true, "true"},
+		{"bool changed", boolOpt, "1", false, "false"},
+		{"long", longOpt, "1536", int64(1536), "1536"},
+		{"long changed", longOpt, "1536", int64(512), "512"},
+		{"invalid", longOpt, "max", int64(512), "512"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := hostOptionSettingString(tc.def, tc.configured, tc.current); actual != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHostOptionHasPrefix(t *testing.T) {
+	prefixes := []string{"Net.", "UserVars."}
+	if !hostOptionHasPrefix("UserVars.SuppressShellWarning", prefixes) {
+		t.Fatal("expected UserVars.SuppressShellWarning to match")
+	}
+	if hostOptionHasPrefix("Syslog.global.logHost", prefixes) {
+		t.Fatal("expected Syslog.global.logHost not to match")
+	}
+	if hostOptionHasPrefix("Net.TcpipHeapMax", nil) {
+		t.Fatal("expected no match without prefixes")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostPciPassthruSystemFromHostSystemID returns the reference to the PCI
+// passthrough system of the HostSystem with the specified managed object ID.
+func hostPciPassthruSystemFromHostSystemID(client *govmomi.Client, hsID string) (types.ManagedObjectReference, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+	var props mo.HostSystem
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := hs.Properties(ctx, hs.Reference(), []string{"configManager.pciPassthruSystem"}, &props); err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+	if props.ConfigManager.PciPassthruSystem == nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("host %s has no PCI passthrough system", hsID)
+	}
+	return *props.ConfigManager.PciPassthruSystem, nil
+}
+
+// hostPciPassthruInfoFromID returns the passthrough information of the PCI
+// device with the supplied ID, ie: 0000:3b:00.0, or nil if the device is not
+// found.
+func hostPciPassthruInfoFromID(client *govmomi.Client, ps types.ManagedObjectReference, id string) (*types.HostPciPassthruInfo, error) {
+	var mps mo.HostPciPassthruSystem
+	pc := property.DefaultCollector(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, ps, []string{"pciPassthruInfo"}, &mps); err != nil {
+		return nil, fmt.Errorf("error fetching PCI passthrough information: %s", err)
+	}
+	for _, bi := range mps.PciPassthruInfo {
+		if info := bi.GetHostPciPassthruInfo(); info.Id == id {
+			return info, nil
+		}
+	}
+	return nil, nil
+}
+
+// updateHostPciPassthruEnabled enables or disables passthrough for the PCI
+// device with the supplied ID. The change is only active once the host is
+// rebooted.
+func updateHostPciPassthruEnabled(client *govmomi.Client, ps types.ManagedObjectReference, id string, enabled bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	_, err := methods.UpdatePassthruConfig(ctx, client.Client, &types.UpdatePassthruConfig{
+		This: ps,
+		Config: []types.BaseHostPciPassthruConfig{
+			&types.HostPciPassthruConfig{
+				Id:              id,
+				PassthruEnabled: enabled,
+			},
+		},
+	})
+	return err
+}
+
+// hostPciPassthroughRebootLocks holds a lock for each host, by managed object
+// ID. Passthrough updates hold the lock for reading, and reboots hold it for
+// writing, so that a reboot waits for the updates in progress on the host,
+// and the resources updated in the same run share one reboot.
+var hostPciPassthroughRebootLocks = struct {
+	sync.Mutex
+	locks map[string]*sync.RWMutex
+}{locks: make(map[string]*sync.RWMutex)}
+
+// hostPciPassthroughRebootLock returns the reboot lock of the host with the
+// specified managed object ID.
+func hostPciPassthroughRebootLock(hsID string) *sync.RWMutex {
+	hostPciPassthroughRebootLocks.Lock()
+	defer hostPciPassthroughRebootLocks.Unlock()
+	l, ok := hostPciPassthroughRebootLocks.locks[hsID]
+	if !ok {
+		l = &sync.RWMutex{}
+		hostPciPassthroughRebootLocks.locks[hsID] = l
+	}
+	return l
+}
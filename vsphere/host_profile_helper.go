@@ -0,0 +1,283 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostProfileComplianceStatusOrder ranks compliance statuses, so that the
+// worst status of several results can be reported.
+var hostProfileComplianceStatusOrder = map[string]int{
+	string(types.ComplianceResultStatusCompliant):    0,
+	string(types.ComplianceResultStatusRunning):      1,
+	string(types.ComplianceResultStatusUnknown):      2,
+	string(types.ComplianceResultStatusNonCompliant): 3,
+}
+
+// schemaHostProfileComplianceFailure returns the schema of the compliance
+// failures reported for host profiles.
+func schemaHostProfileComplianceFailure() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "The compliance failures of the hosts.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"host_system_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The managed object ID of the host the failure was found on.",
+				},
+				"failure_type": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The type of the failure.",
+				},
+				"message": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The message of the failure.",
+				},
+				"expression_name": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The name of the compliance expression that failed.",
+				},
+			},
+		},
+	}
+}
+
+// hostProfileManagerReference returns the reference to the host profile
+// manager. Host profiles are only available on vCenter Server.
+func hostProfileManagerReference(client *govmomi.Client) (types.ManagedObjectReference, error) {
+	if client.ServiceContent.HostProfileManager == nil {
+		return types.ManagedObjectReference{}, errors.New("host profiles are not available on this connection")
+	}
+	return *client.ServiceContent.HostProfileManager, nil
+}
+
+// hostProfileProperties returns the properties of the host profile with the
+// supplied managed object ID.
+func hostProfileProperties(client *govmomi.Client, id string) (*mo.HostProfile, error) {
+	var props mo.HostProfile
+	pc := property.DefaultCollector(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	ref := types.ManagedObjectReference{Type: "HostProfile", Value: id}
+	if err := pc.RetrieveOne(ctx, ref, []string{"name", "config", "entity", "referenceHost", "complianceStatus"}, &props); err != nil {
+		return nil, err
+	}
+	return &props, nil
+}
+
+// hostProfileFromName returns the properties of the host profile with the
+// supplied name.
+func hostProfileFromName(client *govmomi.Client, name string) (*mo.HostProfile, error) {
+	pm, err := hostProfileManagerReference(client)
+	if err != nil {
+		return nil, err
+	}
+	var mpm mo.HostProfileManager
+	pc := property.DefaultCollector(client.Client)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := pc.RetrieveOne(ctx, pm, []string{"profile"}, &mpm); err != nil {
+		return nil, fmt.Errorf("error listing host profiles: %s", err)
+	}
+	if len(mpm.Profile) == 0 {
+		return nil, fmt.Errorf("host profile %q not found", name)
+	}
+	var profiles []mo.HostProfile
+	if err := pc.Retrieve(ctx, mpm.Profile, []string{"name", "config", "entity", "referenceHost"}, &profiles); err != nil {
+		return nil, fmt.Errorf("error fetching host profiles: %s", err)
+	}
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("host profile %q not found", name)
+}
+
+// hostProfileConfigInfo returns the name, annotation and enabled state of a
+// host profile.
+func hostProfileConfigInfo(props *mo.HostProfile) *types.ProfileConfigInfo {
+	if props.Config == nil {
+		return &types.ProfileConfigInfo{Name: props.Name}
+	}
+	return props.Config.GetProfileConfigInfo()
+}
+
+// checkHostProfileCompliance checks the compliance of the supplied entities,
+// hosts or clusters, against the supplied profile. If profile is nil, the
+// entities are checked against the profiles attached to them.
+func checkHostProfileCompliance(client *govmomi.Client, profile *types.ManagedObjectReference, entities []types.ManagedObjectReference) ([]types.ComplianceResult, error) {
+	if client.ServiceContent.ComplianceManager == nil {
+		return nil, errors.New("profile compliance checks are not available on this connection")
+	}
+	req := &types.CheckCompliance_Task{
+		This:   *client.ServiceContent.ComplianceManager,
+		Entity: entities,
+	}
+	if profile != nil {
+		req.Profile = []types.ManagedObjectReference{*profile}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.CheckCompliance_Task(ctx, client.Client, req)
+	if err != nil {
+		return nil, err
+	}
+	info, err := object.NewTask(client.Client, res.Returnval).WaitForResult(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if results, ok := info.Result.(types.ArrayOfComplianceResult); ok {
+		return results.ComplianceResult, nil
+	}
+	return nil, nil
+}
+
+// hostProfileComplianceStatus returns the worst status of the supplied
+// compliance results, and their failures in the format of the
+// compliance_failure attribute.
+func hostProfileComplianceStatus(results []types.ComplianceResult) (string, []interface{}) {
+	status := string(types.ComplianceResultStatusUnknown)
+	if len(results) > 0 {
+		status = string(types.ComplianceResultStatusCompliant)
+	}
+	var failures []interface{}
+	for _, r := range results {
+		if hostProfileComplianceStatusOrder[r.ComplianceStatus] > hostProfileComplianceStatusOrder[status] {
+			status = r.ComplianceStatus
+		}
+		var hsID string
+		if r.Entity != nil && r.Entity.Type == "HostSystem" {
+			hsID = r.Entity.Value
+		}
+		for _, f := range r.Failure {
+			failures = append(failures, map[string]interface{}{
+				"host_system_id":  hsID,
+				"failure_type":    f.FailureType,
+				"message":         f.Message.Message,
+				"expression_name": f.ExpressionName,
+			})
+		}
+	}
+	return status, failures
+}
+
+// nonCompliantHosts returns the hosts in the supplied compliance results that
+// are not compliant, sorted by managed object ID.
+func nonCompliantHosts(results []types.ComplianceResult) []types.ManagedObjectReference {
+	var hosts []types.ManagedObjectReference
+	for _, r := range results {
+		if r.Entity == nil || r.Entity.Type != "HostSystem" {
+			continue
+		}
+		if r.ComplianceStatus == string(types.ComplianceResultStatusNonCompliant) {
+			hosts = append(hosts, *r.Entity)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Value < hosts[j].Value })
+	return hosts
+}
+
+// remediateHostProfile applies a host profile to a host. The configuration of
+// the host is generated from the profile first, so that a profile that needs
+// customization input or cannot be applied fails before the host is put in
+// maintenance mode. The host is then put in maintenance mode, and is taken out
+// of it once the profile is applied or has failed to apply, unless it was
+// already in maintenance mode.
+func remediateHostProfile(client *govmomi.Client, profile types.ManagedObjectReference, host types.ManagedObjectReference, timeout time.Duration) error {
+	pm, err := hostProfileManagerReference(client)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Generating configuration of host %q from host profile %q", host.Value, profile.Value)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	exec, err := methods.ExecuteHostProfile(ctx, client.Client, &types.ExecuteHostProfile{
+		This: profile,
+		Host: host,
+	})
+	if err != nil {
+		return fmt.Errorf("error generating configuration of host %s from host profile: %s", host.Value, err)
+	}
+	result := exec.Returnval.GetProfileExecuteResult()
+	switch result.Status {
+	case string(types.ProfileExecuteResultStatusSuccess):
+	case string(types.ProfileExecuteResultStatusNeedInput):
+		var paths []string
+		for _, p := range result.RequireInput {
+			paths = append(paths, p.InputPath.ProfilePath)
+		}
+		return fmt.Errorf("host profile requires customization input for host %s:\n- %s", host.Value, strings.Join(paths, "\n- "))
+	default:
+		var problems []string
+		for _, e := range result.Error {
+			problems = append(problems, e.Message.Message)
+		}
+		return fmt.Errorf("error generating configuration of host %s from host profile:\n- %s", host.Value, strings.Join(problems, "\n- "))
+	}
+	if result.ConfigSpec == nil {
+		return fmt.Errorf("host profile returned no configuration for host %s", host.Value)
+	}
+
+	hs := object.NewHostSystem(client.Client, host)
+	maintenance, err := hostsystem.HostInMaintenance(hs)
+	if err != nil {
+		return fmt.Errorf("error checking maintenance mode of host %s: %s", host.Value, err)
+	}
+	if err := hostsystem.EnterMaintenanceMode(hs, timeout, true); err != nil {
+		return fmt.Errorf("error putting host %s in maintenance mode: %s", host.Value, err)
+	}
+	log.Printf("[DEBUG] Applying host profile %q to host %q", profile.Value, host.Value)
+	applyErr := applyHostProfileConfig(ctx, client, pm, host, *result.ConfigSpec)
+	if maintenance {
+		return applyErr
+	}
+	if err := hostsystem.ExitMaintenanceMode(hs, timeout); err != nil {
+		if applyErr != nil {
+			return fmt.Errorf("%s; error taking host %s out of maintenance mode: %s", applyErr, host.Value, err)
+		}
+		return fmt.Errorf("error taking host %s out of maintenance mode: %s", host.Value, err)
+	}
+	return applyErr
+}
+
+// applyHostProfileConfig applies a configuration generated from a host
+// profile to a host.
+func applyHostProfileConfig(ctx context.Context, client *govmomi.Client, pm, host types.ManagedObjectReference, spec types.HostConfigSpec) error {
+	res, err := methods.ApplyHostConfig_Task(ctx, client.Client, &types.ApplyHostConfig_Task{
+		This:       pm,
+		Host:       host,
+		ConfigSpec: spec,
+	})
+	if err != nil {
+		return fmt.Errorf("error applying host profile to host %s: %s", host.Value, err)
+	}
+	if err := object.NewTask(client.Client, res.Returnval).Wait(ctx); err != nil {
+		return fmt.Errorf("error applying host profile to host %s: %s", host.Value, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestHostProfileComplianceStatus(t *testing.T) {
+	host1 := &types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+	host2 := &types.ManagedObjectReference{Type: "HostSystem", Value: "host-2"}
+	cluster := &types.ManagedObjectReference{Type: "ClusterComputeResource", Value: "domain-c1"}
+
+	status, failures := hostProfileComplianceStatus(nil)
+	if status != "unknown" || len(failures) != 0 {
+		t.Fatalf("expected unknown status without results, got %s, %v", status, failures)
+	}
+
+	results := []types.ComplianceResult{
+		{Entity: cluster, ComplianceStatus: "compliant"},
+		{Entity: host2, ComplianceStatus: "nonCompliant", Failure: []types.ComplianceFailure{
+			{FailureType: "hostValue", Message: types.LocalizableMessage{Message: "NTP server mismatch"}, ExpressionName: "ntp"},
+		}},
+		{Entity: host1, ComplianceStatus: "unknown"},
+	}
+	status, failures = hostProfileComplianceStatus(results)
+	if status != "nonCompliant" {
+		t.Fatalf("expected nonCompliant, got %s", status)
+	}
+	expected := []interface{}{
+		map[string]interface{}{
+			"host_system_id":  "host-2",
+			"failure_type":    "hostValue",
+			"message":         "NTP server mismatch",
+			"expression_name": "ntp",
+		},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Fatalf("expected %v, got %v", expected, failures)
+	}
+
+	results = append(results, types.ComplianceResult{Entity: host1, ComplianceStatus: "nonCompliant"})
+	hosts := nonCompliantHosts(results)
+	if len(hosts) != 2 || hosts[0].Value != "host-1" || hosts[1].Value != "host-2" {
+		t.Fatalf("unexpected non-compliant hosts: %v", hosts)
+	}
+}
+
+func TestRemediateHostProfileFailureBeforeMaintenance(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		client := &govmomi.Client{Client: c, SessionManager: session.NewManager(c)}
+		finder := find.NewFinder(c)
+		dc, err := finder.DefaultDatacenter(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		finder.SetDatacenter(dc)
+		hosts, err := finder.HostSystemList(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs := hosts[0]
+
+		// The configuration of the host cannot be generated from a profile that
+		// does not exist, which must fail before the host enters maintenance
+		// mode.
+		profile := types.ManagedObjectReference{Type: "HostProfile", Value: "hostprofile-missing"}
+		if err := remediateHostProfile(client, profile, hs.Reference(), time.Minute); err == nil {
+			t.Fatal("expected an error for a missing host profile")
+		}
+		maintenance, err := hostsystem.HostInMaintenance(hs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if maintenance {
+			t.Fatal("expected the host not to be in maintenance mode")
+		}
+	})
+}
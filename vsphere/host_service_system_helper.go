@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostServicePolicyAllowedValues are the startup policies that can be set on
+// a host service.
+var hostServicePolicyAllowedValues = []string{
+	string(types.HostServicePolicyOn),
+	string(types.HostServicePolicyOff),
+	string(types.HostServicePolicyAutomatic),
+}
+
+// hostServiceSystemFromHostSystemID locates a HostServiceSystem from a
+// specified HostSystem managed object ID.
+func hostServiceSystemFromHostSystemID(client *govmomi.Client, hsID string) (*object.HostServiceSystem, error) {
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	return hs.ConfigManager().ServiceSystem(ctx)
+}
+
+// hostServiceFromKey locates a service on the supplied HostServiceSystem by
+// key, ie: ntpd or TSM-SSH.
+func hostServiceFromKey(ss *object.HostServiceSystem, key string) (*types.HostService, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	services, err := ss.Service(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching host services: %s", err)
+	}
+	for _, s := range services {
+		if s.Key == key {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find service %s", key)
+}
+
+// updateHostService sets the startup policy of a host service and starts or
+// stops it so that its running state matches running. If restart is true and
+// the service is already running, it is restarted to pick up configuration
+// changes.
+func updateHostService(ss *object.HostServiceSystem, key string, policy string, running bool, restart bool) error {
+	service, err := hostServiceFromKey(ss, key)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if service.Policy != policy {
+		log.Printf("[DEBUG] Setting the policy of host service %q to %q", key, policy)
+		if err := ss.UpdatePolicy(ctx, key, policy); err != nil {
+			return fmt.Errorf("error updating the policy of service %s: %s", key, err)
+		}
+	}
+	switch {
+	case running && !service.Running:
+		log.Printf("[DEBUG] Starting host service %q", key)
+		if err := ss.Start(ctx, key); err != nil {
+			return fmt.Errorf("error starting service %s: %s", key, err)
+		}
+	case running && restart:
+		log.Printf("[DEBUG] Restarting host service %q", key)
+		if err := ss.Restart(ctx, key); err != nil {
+			return fmt.Errorf("error restarting service %s: %s", key, err)
+		}
+	case !running && service.Running:
+		log.Printf("[DEBUG] Stopping host service %q", key)
+		if err := ss.Stop(ctx, key); err != nil {
+			return fmt.Errorf("error stopping service %s: %s", key, err)
+		}
+	}
+	return nil
+}
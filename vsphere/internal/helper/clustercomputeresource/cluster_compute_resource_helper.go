@@ -287,3 +287,64 @@ func moveHostOutOf(cluster *object.ClusterComputeResource, host *object.HostSyst
 	log.Printf("[DEBUG] Host %q moved out of cluster %q successfully", host.Name(), cluster.Name())
 	return nil
 }
+
+// DrsEnabled returns true if DRS is enabled on the cluster.
+func DrsEnabled(cluster *object.ClusterComputeResource) (bool, error) {
+	props, err := Properties(cluster)
+	if err != nil {
+		return false, err
+	}
+	cfg, ok := props.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok || cfg.DrsConfig.Enabled == nil {
+		return false, nil
+	}
+	return *cfg.DrsConfig.Enabled, nil
+}
+
+// PlaceVM asks DRS for a placement recommendation for the virtual machine
+// described by spec. The target host, and the datastore if one was
+// recommended, of the highest rated recommendation are returned. DRS faults,
+// such as insufficient capacity, are returned as an error.
+func PlaceVM(cluster *object.ClusterComputeResource, spec types.PlacementSpec) (*types.ManagedObjectReference, *types.ManagedObjectReference, error) {
+	log.Printf("[DEBUG] Requesting DRS placement recommendation from cluster %q", cluster.InventoryPath)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	res, err := cluster.PlaceVm(ctx, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.DrsFault != nil {
+		var msgs []string
+		for _, f := range res.DrsFault.FaultsByVm {
+			for _, lf := range f.GetClusterDrsFaultsFaultsByVm().Fault {
+				msgs = append(msgs, lf.LocalizedMessage)
+			}
+		}
+		if len(msgs) == 0 {
+			msgs = append(msgs, res.DrsFault.Reason)
+		}
+		return nil, nil, fmt.Errorf("DRS could not place the virtual machine in cluster %q: %s", cluster.InventoryPath, strings.Join(msgs, "; "))
+	}
+
+	var best *types.ClusterRecommendation
+	for i := range res.Recommendations {
+		if best == nil || res.Recommendations[i].Rating > best.Rating {
+			best = &res.Recommendations[i]
+		}
+	}
+	if best != nil {
+		for _, a := range best.Action {
+			pa, ok := a.(*types.PlacementAction)
+			if !ok || pa.TargetHost == nil {
+				continue
+			}
+			var ds *types.ManagedObjectReference
+			if pa.RelocateSpec != nil {
+				ds = pa.RelocateSpec.Datastore
+			}
+			log.Printf("[DEBUG] DRS recommended host %q (rating %d)", pa.TargetHost.Value, best.Rating)
+			return pa.TargetHost, ds, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("DRS returned no placement recommendation for the virtual machine in cluster %q", cluster.InventoryPath)
+}
@@ -4,14 +4,12 @@
 package contentlibrary
 
 import (
-	"archive/tar"
 	"context"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"net/url"
-	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -160,8 +158,15 @@ func IsContentLibraryItem(c *rest.Client, id string) bool {
 	return item != nil
 }
 
-// CreateLibraryItem creates an item in a Content Library.
-func CreateLibraryItem(c *rest.Client, l *library.Library, name string, desc string, t string, file string, moid string) (*string, error) {
+// CreateLibraryItem creates an item in a Content Library. The files of an
+// OVF or OVA are uploaded concurrently and retried as controlled by opts, and
+// verified against the manifest of the OVF, if any. The signature of an OVF or
+// OVA is validated according to sigOpts before the item is created, and the
+// signer is returned. The file is read from src, if set, or otherwise from the
+// local path or URL in file, and allowUnverifiedSSL controls the verification
+// of the certificate of a remote file.
+func CreateLibraryItem(c *rest.Client, l *library.Library, name string, desc string, t string, file string, src ovfdeploy.Source, moid string, allowUnverifiedSSL bool,
+	sigOpts ovfdeploy.SignatureOptions, opts ovfdeploy.UploadOptions) (*string, *ovfdeploy.SignerInfo, error) {
 	log.Printf("[DEBUG] contentlibrary.CreateLibraryItem: Creating content library item %s.", name)
 	clm := library.NewManager(c)
 	ctx := context.TODO()
@@ -175,58 +180,101 @@ func CreateLibraryItem(c *rest.Client, l *library.Library, name string, desc str
 		ContentLibraryManager: clm,
 		RestClient:            c,
 		LibraryID:             l.ID,
+		UploadOptions:         opts,
 	}
 	if moid != "" {
-		return uploadSession.cloneTemplate(moid, name, t)
+		id, err := uploadSession.cloneTemplate(moid, name, t)
+		return id, nil, err
+	}
+
+	// A remote file is pulled by vCenter Server, unless it is read by the
+	// provider to validate its signature.
+	validate := sigOpts.Policy != "" && sigOpts.Policy != ovfdeploy.SignaturePolicyIgnore
+	isRemote := src == nil && strings.HasPrefix(file, "http")
+	if src == nil {
+		src = ovfdeploy.NewSource(file, !isRemote, allowUnverifiedSSL)
+	}
+	isOva := strings.HasSuffix(src.Name(), ".ova")
+	isIso := strings.HasSuffix(src.Name(), ".iso")
+
+	// The descriptor and manifest are read and validated once, and the files
+	// that are uploaded are verified against this manifest.
+	var vt *ovfdeploy.ValidatedTemplate
+	if !isIso {
+		var cleanup func()
+		var err error
+		src, cleanup, err = ovfdeploy.PrepareSource(src, isOva)
+		if err != nil {
+			return nil, nil, provider.Error(name, "CreateLibraryItem", err)
+		}
+		defer cleanup()
+		vt, err = ovfdeploy.ValidateTemplate(sigOpts, src, isOva)
+		if err != nil {
+			return nil, nil, provider.Error(name, "CreateLibraryItem", fmt.Errorf("while validating %s: %s", src, err))
+		}
+		uploadSession.Manifest = vt.Manifest
 	}
 
 	id, err := clm.CreateLibraryItem(ctx, item)
 	if err != nil {
-		return nil, provider.Error(name, "CreateLibraryItem", err)
+		return nil, nil, provider.Error(name, "CreateLibraryItem", err)
 	}
 	session, err := clm.CreateLibraryItemUpdateSession(ctx, library.Session{LibraryItemID: id})
 	if err != nil {
-		return nil, provider.Error(name, "CreateLibraryItem", err)
+		return nil, nil, provider.Error(name, "CreateLibraryItem", err)
 	}
 	uploadSession.UploadSession = session
 	defer func() {
 		_ = clm.CompleteLibraryItemUpdateSession(ctx, session)
 	}()
+	done := make(chan struct{})
+	defer close(done)
+	go uploadSession.keepAlive(done)
 
-	isOva := false
-	isLocal := true
-	isIso := false
-
-	if strings.HasPrefix(file, "http") {
-		isLocal = false
-	}
-	if strings.HasSuffix(file, ".ova") {
-		isOva = true
-	}
-	if strings.HasSuffix(file, ".iso") {
-		isIso = true
+	switch {
+	case isRemote && (isIso || (!isOva && !validate)):
+		err = uploadSession.deployRemoteOvf(file)
+	case isIso:
+		err = uploadSession.deployIso(src)
+	case isOva:
+		err = uploadSession.deployOva(src, vt.Descriptor)
+	default:
+		// A validated remote OVF is uploaded by the provider rather than
+		// pulled by vCenter Server, so that its files are verified against the
+		// validated manifest.
+		err = uploadSession.deployOvf(src, vt.Descriptor)
 	}
-
-	ovfDescriptor, err := ovfdeploy.GetOvfDescriptor(file, isOva, isLocal, true)
 	if err != nil {
-		return nil, provider.Error(name, "CreateLibraryItem", err)
+		return &id, nil, err
 	}
 
-	switch {
-	case isLocal && isOva:
-		return &id, uploadSession.deployLocalOva(file, ovfDescriptor)
-	case isLocal && !isOva && !isIso:
-		return &id, uploadSession.deployLocalOvf(file, ovfDescriptor)
-	case isLocal && isIso:
-		return &id, uploadSession.deployLocalIso(file)
-	case !isLocal && isOva:
-		return &id, uploadSession.deployRemoteOva(file, ovfDescriptor)
-	case !isLocal && !isOva:
-		return &id, uploadSession.deployRemoteOvf(file)
+	log.Printf("[DEBUG] contentlibrary.CreateLibraryItem: Successfully created content library item %s.", name)
+	var signer *ovfdeploy.SignerInfo
+	if vt != nil {
+		signer = vt.Signer
 	}
+	return &id, signer, nil
+}
 
-	log.Printf("[DEBUG] contentlibrary.CreateLibraryItem: Successfully created content library item %s.", name)
-	return &id, nil
+// libraryUploadSessionKeepAliveInterval is the interval at which the update
+// session is kept alive while its files are uploaded.
+const libraryUploadSessionKeepAliveInterval = time.Minute
+
+// keepAlive keeps the update session alive until done is closed, so that it
+// does not expire while a failed upload waits to be retried.
+func (uploadSession *libraryUploadSession) keepAlive(done <-chan struct{}) {
+	tick := time.NewTicker(libraryUploadSessionKeepAliveInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+			if err := uploadSession.ContentLibraryManager.KeepAliveLibraryItemUpdateSession(context.TODO(), uploadSession.UploadSession); err != nil {
+				log.Printf("[DEBUG] contentlibrary.keepAlive: Error keeping update session %s alive: %s", uploadSession.UploadSession, err)
+			}
+		}
+	}
 }
 
 func (uploadSession *libraryUploadSession) deployRemoteOvf(file string) error {
@@ -238,57 +286,52 @@ func (uploadSession *libraryUploadSession) deployRemoteOvf(file string) error {
 	return uploadSession.ContentLibraryManager.WaitOnLibraryItemUpdateSession(ctx, uploadSession.UploadSession, time.Second*10, func() { log.Printf("Waiting...") })
 }
 
-func (uploadSession *libraryUploadSession) deployRemoteOva(file string, ovfDescriptor string) error {
+// deployOva uploads the descriptor and the disks of the OVA in src. Each disk
+// is read from the OVA on its own, skipping over the entries before it.
+func (uploadSession *libraryUploadSession) deployOva(src ovfdeploy.Source, ovfDescriptor string) error {
 	e, err := readEnvelope(ovfDescriptor)
 	if err != nil {
 		return fmt.Errorf("failed to parse ovf: %s", err)
 	}
-	name := strings.TrimSuffix(filepath.Base(file), "ova")
-	if err := uploadSession.uploadString(ovfDescriptor, name+"ovf"); err != nil {
+	name := strings.TrimSuffix(src.Name(), "ova")
+	// The descriptor is uploaded under the name of the OVA, which is not
+	// listed in the manifest, so it is not verified.
+	if err := uploadSession.uploadString(ovfDescriptor, name+"ovf", nil); err != nil {
 		return err
 	}
-	for _, disk := range e.References {
-		if err := uploadSession.uploadOvaDisksFromURL(file, disk.Href, int64(disk.Size)); err != nil {
+	return uploadSession.UploadOptions.Parallel(context.TODO(), len(e.References), func(ctx context.Context, i int) error {
+		disk := e.References[i]
+		v, err := uploadSession.Manifest.Verifier(disk.Href)
+		if err != nil {
 			return err
 		}
-	}
-	return nil
+		return uploadSession.upload(ctx, disk.Href, int64(disk.Size), v, func() (io.ReadCloser, error) {
+			return ovfdeploy.OpenFile(ctx, src, true, disk.Href)
+		})
+	})
 }
 
-func (uploadSession *libraryUploadSession) deployLocalOvf(file string, ovfDescriptor string) error {
+// deployOvf uploads the descriptor of the OVF in src and the files it
+// references.
+func (uploadSession *libraryUploadSession) deployOvf(src ovfdeploy.Source, ovfDescriptor string) error {
 	e, err := readEnvelope(ovfDescriptor)
 	if err != nil {
 		return fmt.Errorf("failed to parse ovf: %s", err)
 	}
-	if err := uploadSession.uploadLocalFile(file); err != nil {
-		return err
-	}
-	dir := filepath.Dir(file)
-	for i := range e.References {
-		if err := uploadSession.uploadLocalFile(dir + "/" + e.References[i].Href); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func (uploadSession *libraryUploadSession) deployLocalOva(file string, ovfDescriptor string) error {
-	e, err := readEnvelope(ovfDescriptor)
+	v, err := uploadSession.Manifest.Verifier(src.Name())
 	if err != nil {
-		return fmt.Errorf("failed to parse ovf: %s", err)
+		return err
 	}
-	name := strings.TrimSuffix(filepath.Base(file), "ova")
-	if err := uploadSession.uploadString(ovfDescriptor, name+"ovf"); err != nil {
+	if err := uploadSession.uploadString(ovfDescriptor, src.Name(), v); err != nil {
 		return err
 	}
-	return uploadSession.uploadOvaDisksFromLocal(file, e)
+	return uploadSession.UploadOptions.Parallel(context.TODO(), len(e.References), func(ctx context.Context, i int) error {
+		return uploadSession.uploadSourceFile(ctx, src, e.References[i].Href)
+	})
 }
 
-func (uploadSession *libraryUploadSession) deployLocalIso(file string) error {
-	if err := uploadSession.uploadLocalFile(file); err != nil {
-		return err
-	}
-	return nil
+func (uploadSession *libraryUploadSession) deployIso(src ovfdeploy.Source) error {
+	return uploadSession.uploadSourceFile(context.TODO(), src, "")
 }
 
 type libraryUploadSession struct {
@@ -296,6 +339,8 @@ type libraryUploadSession struct {
 	RestClient            *rest.Client
 	UploadSession         string
 	LibraryID             string
+	UploadOptions         ovfdeploy.UploadOptions
+	Manifest              ovfdeploy.Manifest
 }
 
 func (uploadSession libraryUploadSession) cloneTemplate(moid string, name string, templateType string) (*string, error) {
@@ -321,90 +366,37 @@ func (uploadSession libraryUploadSession) cloneTemplate(moid string, name string
 	return nil, fmt.Errorf("Unsupported template type. Only ovf can be used when cloning from vCenter")
 }
 
-func (uploadSession libraryUploadSession) uploadString(data string, name string) error {
-	stringReader := strings.NewReader(data)
-	openFile := io.Reader(stringReader)
+// uploadString uploads data as the named file, verified with v.
+func (uploadSession libraryUploadSession) uploadString(data string, name string, v *ovfdeploy.ChecksumVerifier) error {
 	size := int64(len([]byte(data)))
-	return uploadSession.upload(name, &openFile, size)
-}
-
-func (uploadSession libraryUploadSession) uploadLocalFile(file string) error {
-	openFile, size, err := openLocalFile(file)
-	if err != nil {
-		return err
-	}
-
-	return uploadSession.upload(filepath.Base(file), openFile, *size)
+	return uploadSession.upload(context.TODO(), name, size, v, func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(data)), nil
+	})
 }
 
-func openLocalFile(file string) (*io.Reader, *int64, error) {
-	openFile, err := os.Open(file)
-	if err != nil {
-		return nil, nil, err
-	}
-	statFile, err := openFile.Stat()
-	if err != nil {
-		return nil, nil, err
+// uploadSourceFile uploads the named file of the OVF in src, or the file in
+// src itself if name is empty, verified against the manifest.
+func (uploadSession libraryUploadSession) uploadSourceFile(ctx context.Context, src ovfdeploy.Source, name string) error {
+	fileName := path.Base(name)
+	if name == "" {
+		fileName = src.Name()
 	}
-	openFileReader := io.Reader(openFile)
-	size := statFile.Size()
-	return &openFileReader, &size, nil
-}
-
-func (uploadSession libraryUploadSession) uploadOvaDisksFromLocal(ovaFilePath string, envelope *ovf.Envelope) error {
-	ovaFile, _, err := openLocalFile(ovaFilePath)
+	f, err := src.Open(name)
 	if err != nil {
 		return err
 	}
-
-	for _, disk := range envelope.References {
-		size := disk.Size
-		fileName := disk.Href
-		if err = uploadSession.findAndUploadDiskFromOva(*ovaFile, fileName, int64(size)); err != nil {
-			return err
-		}
+	size, err := f.Seek(0, io.SeekEnd)
+	_ = f.Close()
+	if err != nil {
+		return fmt.Errorf("error determining the size of %s: %s", fileName, err)
 	}
-	return err
-}
-
-func (uploadSession libraryUploadSession) uploadOvaDisksFromURL(ovfFilePath string, diskName string, size int64) error {
-	resp, err := http.Get(ovfFilePath)
+	v, err := uploadSession.Manifest.Verifier(name)
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode == http.StatusOK {
-		err = uploadSession.findAndUploadDiskFromOva(resp.Body, diskName, size)
-		if err != nil {
-			return err
-		}
-	} else {
-		return fmt.Errorf("got status %d while getting the file from remote url %s ", resp.StatusCode, ovfFilePath)
-	}
-	return nil
-}
-
-func (uploadSession libraryUploadSession) findAndUploadDiskFromOva(ovaFile io.Reader, diskName string, size int64) error {
-	log.Printf("[DEBUG] findAndUploadDiskFromOva: Finding %s", diskName)
-	ovaReader := tar.NewReader(ovaFile)
-	for {
-		fileHdr, err := ovaReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		if fileHdr.Name == diskName {
-			log.Printf("[DEBUG] findAndUploadDiskFromOva: %s found", diskName)
-			ioOvaReader := io.Reader(ovaReader)
-			err = uploadSession.upload(diskName, &ioOvaReader, size)
-			if err != nil {
-				return fmt.Errorf("error while uploading the file %s %s", diskName, err)
-			}
-			return nil
-		}
-	}
-	return fmt.Errorf("disk %s not found inside ova", diskName)
+	return uploadSession.upload(ctx, fileName, size, v, func() (io.ReadCloser, error) {
+		return ovfdeploy.OpenFile(ctx, src, false, name)
+	})
 }
 
 func readEnvelope(data string) (*ovf.Envelope, error) {
@@ -416,13 +408,15 @@ func readEnvelope(data string) (*ovf.Envelope, error) {
 	return e, nil
 }
 
-func (uploadSession libraryUploadSession) upload(name string, file *io.Reader, size int64) error {
-	ctx := context.TODO()
-
+// upload adds a file to the update session and uploads its contents, as read
+// from the reader returned by open. The transfer is retried with a fresh
+// reader if it fails, and the contents are verified with v.
+func (uploadSession libraryUploadSession) upload(ctx context.Context, name string, size int64, v *ovfdeploy.ChecksumVerifier, open func() (io.ReadCloser, error)) error {
 	info := library.UpdateFile{
 		Name:       name,
 		SourceType: "PUSH",
 		Size:       size,
+		Checksum:   v.Checksum(),
 	}
 
 	update, err := uploadSession.ContentLibraryManager.AddLibraryItemFile(ctx, uploadSession.UploadSession, info)
@@ -436,7 +430,23 @@ func (uploadSession libraryUploadSession) upload(name string, file *io.Reader, s
 	if err != nil {
 		return err
 	}
-	return uploadSession.RestClient.Upload(ctx, *file, u, &p)
+	err = uploadSession.UploadOptions.Retry(ctx, name, func(int) error {
+		file, err := open()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		if err := uploadSession.RestClient.Upload(ctx, v.Reader(file), u, &p); err != nil {
+			return err
+		}
+		return v.Verify()
+	})
+	if err != nil {
+		return fmt.Errorf("error while uploading the file %s %s", name, err)
+	}
+	return nil
 }
 
 // DeleteLibraryItem deletes an item from a Content Library.
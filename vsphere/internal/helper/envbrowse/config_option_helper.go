@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package envbrowse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// scsiControllerDescriptorNames maps the SCSI bus types of the provider to the
+// controller names used in GuestOsDescriptor.SupportedDiskControllerList.
+var scsiControllerDescriptorNames = map[string]string{
+	"lsilogic":     "VirtualLsiLogicController",
+	"lsilogic-sas": "VirtualLsiLogicSASController",
+	"pvscsi":       "ParaVirtualSCSIController",
+	"buslogic":     "VirtualBusLogicController",
+}
+
+// VirtualMachineRequirements describes the guest OS and virtual hardware of a
+// virtual machine that need to be supported by the environment it is placed
+// in. Zero values are not validated.
+type VirtualMachineRequirements struct {
+	GuestID           string
+	NumCPUs           int
+	MemoryMB          int64
+	Firmware          string
+	SCSIType          string
+	Disks             int
+	SCSIControllers   int
+	SATAControllers   int
+	IDEControllers    int
+	NetworkInterfaces int
+}
+
+// Validate checks the requirements against the config option and config
+// target of an environment browser and returns an error listing every
+// requirement that is not supported.
+func (r *VirtualMachineRequirements) Validate(opt *types.VirtualMachineConfigOption, target *types.ConfigTarget) error {
+	var problems []string
+	fail := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	var guest *types.GuestOsDescriptor
+	for i := range opt.GuestOSDescriptor {
+		if opt.GuestOSDescriptor[i].Id == r.GuestID {
+			guest = &opt.GuestOSDescriptor[i]
+		}
+	}
+	if r.GuestID != "" && guest == nil {
+		fail("guest_id %q is not supported by %s", r.GuestID, opt.Description)
+	}
+
+	if r.NumCPUs > 0 {
+		if guest != nil && guest.SupportedMaxCPUs > 0 && r.NumCPUs > int(guest.SupportedMaxCPUs) {
+			fail("num_cpus %d exceeds the maximum of %d supported by guest_id %q", r.NumCPUs, guest.SupportedMaxCPUs, r.GuestID)
+		}
+		if target != nil {
+			maxCPUs := target.MaxCpusPerHost
+			if maxCPUs == 0 {
+				maxCPUs = target.NumCpus
+			}
+			if maxCPUs > 0 && r.NumCPUs > int(maxCPUs) {
+				fail("num_cpus %d exceeds the %d logical CPUs available on the host", r.NumCPUs, maxCPUs)
+			}
+		}
+	}
+
+	if r.MemoryMB > 0 {
+		if guest != nil {
+			if guest.SupportedMinMemMB > 0 && r.MemoryMB < int64(guest.SupportedMinMemMB) {
+				fail("memory %d MB is below the minimum of %d MB supported by guest_id %q", r.MemoryMB, guest.SupportedMinMemMB, r.GuestID)
+			}
+			if guest.SupportedMaxMemMB > 0 && r.MemoryMB > int64(guest.SupportedMaxMemMB) {
+				fail("memory %d MB exceeds the maximum of %d MB supported by guest_id %q", r.MemoryMB, guest.SupportedMaxMemMB, r.GuestID)
+			}
+		}
+		if mem := opt.HardwareOptions.MemoryMB; mem.Max > 0 && (r.MemoryMB < mem.Min || r.MemoryMB > mem.Max) {
+			fail("memory %d MB is outside of the range of %d to %d MB supported by %s", r.MemoryMB, mem.Min, mem.Max, opt.Description)
+		}
+		if target != nil && target.SupportedMaxMemMB > 0 && r.MemoryMB > int64(target.SupportedMaxMemMB) {
+			fail("memory %d MB exceeds the maximum of %d MB supported by the host", r.MemoryMB, target.SupportedMaxMemMB)
+		}
+	}
+
+	if guest != nil {
+		if r.Firmware != "" && len(guest.SupportedFirmware) > 0 && !containsString(guest.SupportedFirmware, r.Firmware) {
+			fail("firmware %q is not supported by guest_id %q, supported firmware: %s", r.Firmware, r.GuestID, strings.Join(guest.SupportedFirmware, ", "))
+		}
+		if name, ok := scsiControllerDescriptorNames[r.SCSIType]; ok && r.SCSIControllers > 0 && len(guest.SupportedDiskControllerList) > 0 && !containsString(guest.SupportedDiskControllerList, name) {
+			fail("scsi_type %q is not supported by guest_id %q", r.SCSIType, r.GuestID)
+		}
+		if guest.SupportedNumDisks > 0 && r.Disks > int(guest.SupportedNumDisks) {
+			fail("%d disks exceed the maximum of %d supported by guest_id %q", r.Disks, guest.SupportedNumDisks, r.GuestID)
+		}
+	}
+
+	if max := opt.HardwareOptions.NumIDEControllers.Max; max > 0 && r.IDEControllers > int(max) {
+		fail("ide_controller_count %d exceeds the maximum of %d supported by %s", r.IDEControllers, max, opt.Description)
+	}
+	for _, o := range opt.HardwareOptions.VirtualDeviceOption {
+		pci, ok := o.(*types.VirtualPCIControllerOption)
+		if !ok {
+			continue
+		}
+		scsiMax := pci.NumSCSIControllers.Max
+		switch {
+		case r.SCSIType == "pvscsi" && pci.NumParaVirtualSCSIControllers != nil:
+			scsiMax = pci.NumParaVirtualSCSIControllers.Max
+		case r.SCSIType == "lsilogic-sas" && pci.NumSasSCSIControllers != nil:
+			scsiMax = pci.NumSasSCSIControllers.Max
+		}
+		if scsiMax > 0 && r.SCSIControllers > int(scsiMax) {
+			fail("scsi_controller_count %d exceeds the maximum of %d supported by %s", r.SCSIControllers, scsiMax, opt.Description)
+		}
+		if pci.NumSATAControllers != nil && pci.NumSATAControllers.Max > 0 && r.SATAControllers > int(pci.NumSATAControllers.Max) {
+			fail("sata_controller_count %d exceeds the maximum of %d supported by %s", r.SATAControllers, pci.NumSATAControllers.Max, opt.Description)
+		}
+		if max := pci.NumEthernetCards.Max; max > 0 && r.NetworkInterfaces > int(max) {
+			fail("%d network interfaces exceed the maximum of %d supported by %s", r.NetworkInterfaces, max, opt.Description)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("virtual machine configuration is not supported by the target environment:\n- %s", strings.Join(problems, "\n- "))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package envbrowse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testConfigOption() *types.VirtualMachineConfigOption {
+	sata := types.IntOption{Max: 4}
+	pvscsi := types.IntOption{Max: 4}
+	return &types.VirtualMachineConfigOption{
+		Description: "Hardware version 19",
+		GuestOSDescriptor: []types.GuestOsDescriptor{
+			{
+				Id:                          "windows2019srvNext_64Guest",
+				SupportedMaxCPUs:            768,
+				SupportedMinMemMB:           2048,
+				SupportedMaxMemMB:           25165824,
+				SupportedNumDisks:           240,
+				SupportedFirmware:           []string{"efi"},
+				SupportedDiskControllerList: []string{"VirtualLsiLogicSASController", "ParaVirtualSCSIController"},
+			},
+		},
+		HardwareOptions: types.VirtualHardwareOption{
+			MemoryMB:          types.LongOption{Min: 4, Max: 25165824},
+			NumIDEControllers: types.IntOption{Max: 2},
+			VirtualDeviceOption: []types.BaseVirtualDeviceOption{
+				&types.VirtualPCIControllerOption{
+					NumSCSIControllers:            types.IntOption{Max: 4},
+					NumEthernetCards:              types.IntOption{Max: 10},
+					NumParaVirtualSCSIControllers: &pvscsi,
+					NumSATAControllers:            &sata,
+				},
+			},
+		},
+	}
+}
+
+func TestVirtualMachineRequirementsValidate(t *testing.T) {
+	opt := testConfigOption()
+	target := &types.ConfigTarget{NumCpus: 32, MaxCpusPerHost: 64}
+
+	valid := &VirtualMachineRequirements{
+		GuestID:           "windows2019srvNext_64Guest",
+		NumCPUs:           8,
+		MemoryMB:          8192,
+		Firmware:          "efi",
+		SCSIType:          "pvscsi",
+		Disks:             2,
+		SCSIControllers:   1,
+		IDEControllers:    2,
+		NetworkInterfaces: 1,
+	}
+	if err := valid.Validate(opt, target); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	invalid := &VirtualMachineRequirements{
+		GuestID:           "windows2019srvNext_64Guest",
+		NumCPUs:           128,
+		MemoryMB:          1024,
+		Firmware:          "bios",
+		SCSIType:          "lsilogic",
+		SCSIControllers:   1,
+		SATAControllers:   5,
+		NetworkInterfaces: 11,
+	}
+	err := invalid.Validate(opt, target)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, s := range []string{
+		"num_cpus 128 exceeds the 64 logical CPUs available on the host",
+		"memory 1024 MB is below the minimum of 2048 MB",
+		"firmware \"bios\" is not supported",
+		"scsi_type \"lsilogic\" is not supported",
+		"sata_controller_count 5 exceeds the maximum of 4",
+		"11 network interfaces exceed the maximum of 10",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected %q in %q", s, err)
+		}
+	}
+
+	unknown := &VirtualMachineRequirements{GuestID: "fooGuest"}
+	if err := unknown.Validate(opt, target); err == nil || !strings.Contains(err.Error(), "guest_id \"fooGuest\" is not supported by Hardware version 19") {
+		t.Fatalf("expected an unsupported guest_id error, got %v", err)
+	}
+}
@@ -151,3 +151,45 @@ func (b *EnvironmentBrowser) QueryConfigOptionDescriptor(ctx context.Context) ([
 	}
 	return res.Returnval, nil
 }
+
+// ConfigOption returns the virtual machine config option for the optionally
+// supplied hardware version key, guest ID and host. The guest OS descriptors in
+// the result are limited to the supplied guest ID, and are empty if the guest
+// ID is not supported.
+func (b *EnvironmentBrowser) ConfigOption(ctx context.Context, key string, guest string, host *types.ManagedObjectReference) (*types.VirtualMachineConfigOption, error) {
+	req := types.QueryConfigOptionEx{
+		This: b.Reference(),
+		Spec: &types.EnvironmentBrowserConfigOptionQuerySpec{
+			Key:  key,
+			Host: host,
+		},
+	}
+	if guest != "" {
+		req.Spec.GuestId = []string{guest}
+	}
+	res, err := methods.QueryConfigOptionEx(ctx, b.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+	if res.Returnval == nil {
+		return nil, errors.New("no config options were found for the supplied criteria")
+	}
+	return res.Returnval, nil
+}
+
+// ConfigTarget returns the config target, ie: the CPU, memory and devices
+// available to virtual machines, for the optionally supplied host.
+func (b *EnvironmentBrowser) ConfigTarget(ctx context.Context, host *types.ManagedObjectReference) (*types.ConfigTarget, error) {
+	req := types.QueryConfigTarget{
+		This: b.Reference(),
+		Host: host,
+	}
+	res, err := methods.QueryConfigTarget(ctx, b.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+	if res.Returnval == nil {
+		return nil, errors.New("no config target was found for the supplied criteria")
+	}
+	return res.Returnval, nil
+}
@@ -5,6 +5,10 @@ package guestoscustomizations
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
@@ -16,6 +20,7 @@ import (
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -33,6 +38,8 @@ const (
 	schemaPrefixVMClone = "clone.0.customize.0."
 
 	schemaPrefixGOSC = "spec.0."
+
+	encryptedPasswordSuffix = "_encrypted"
 )
 
 func netifKey(key string, n int, prefix string) string {
@@ -158,10 +165,18 @@ func SpecSchema(isVM bool) map[string]*schema.Schema {
 					Description: "Specifies how many times the VM should auto-logon the Administrator account when auto_logon is true.",
 				},
 				"admin_password": {
-					Type:        schema.TypeString,
-					Optional:    true,
-					Sensitive:   true,
-					Description: "The new administrator password for this virtual machine.",
+					Type:          schema.TypeString,
+					Optional:      true,
+					Sensitive:     true,
+					ConflictsWith: []string{prefix + "windows_options.0.admin_password_encrypted"},
+					Description:   "The new administrator password for this virtual machine.",
+				},
+				"admin_password_encrypted": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					Sensitive:     true,
+					ConflictsWith: []string{prefix + "windows_options.0.admin_password"},
+					Description:   "The new administrator password for this virtual machine, encrypted with the public key of the vCenter Server.",
 				},
 				"time_zone": {
 					Type:        schema.TypeInt,
@@ -182,16 +197,24 @@ func SpecSchema(isVM bool) map[string]*schema.Schema {
 					Type:          schema.TypeString,
 					Optional:      true,
 					Sensitive:     true,
-					ConflictsWith: []string{prefix + "windows_options.workgroup"},
+					ConflictsWith: []string{prefix + "windows_options.workgroup", prefix + "windows_options.0.domain_admin_password_encrypted"},
 					Description:   "The password of the domain administrator used to join this virtual machine to the domain.",
 					RequiredWith:  []string{prefix + "windows_options.join_domain"},
 				},
+				"domain_admin_password_encrypted": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					Sensitive:     true,
+					ConflictsWith: []string{prefix + "windows_options.workgroup", prefix + "windows_options.0.domain_admin_password"},
+					Description:   "The password of the domain administrator used to join this virtual machine to the domain, encrypted with the public key of the vCenter Server.",
+					RequiredWith:  []string{prefix + "windows_options.join_domain"},
+				},
 				"join_domain": {
 					Type:          schema.TypeString,
 					Optional:      true,
 					ConflictsWith: []string{prefix + "windows_options.workgroup"},
 					Description:   "The domain that the virtual machine should join.",
-					RequiredWith:  []string{prefix + "windows_options.domain_admin_user", prefix + "windows_options.domain_admin_password"},
+					RequiredWith:  []string{prefix + "windows_options.domain_admin_user"},
 				},
 				"workgroup": {
 					Type:          schema.TypeString,
@@ -300,6 +323,43 @@ func FromName(client *govmomi.Client, name string) (*types.CustomizationSpecItem
 	return csm.GetCustomizationSpec(ctx, name)
 }
 
+// EncryptionKey returns the public key certificate of the vCenter Server
+// that can be used to encrypt passwords for customization specifications.
+func EncryptionKey(client *govmomi.Client) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+
+	csm := object.NewCustomizationSpecManager(client.Client)
+	var props mo.CustomizationSpecManager
+	if err := csm.Properties(ctx, csm.Reference(), []string{"encryptionKey"}, &props); err != nil {
+		return nil, err
+	}
+	if len(props.EncryptionKey) == 0 {
+		return nil, errors.New("vCenter Server did not return a customization encryption key")
+	}
+	return props.EncryptionKey, nil
+}
+
+// EncryptPassword encrypts the supplied password with the RSA public key
+// contained in the DER encoded certificate key, as returned by
+// EncryptionKey. The result is base64 encoded and can be used as a
+// CustomizationPassword with PlainText set to false.
+func EncryptPassword(key []byte, password string) (string, error) {
+	cert, err := x509.ParseCertificate(key)
+	if err != nil {
+		return "", fmt.Errorf("error parsing customization encryption key: %s", err)
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported customization encryption key type %T", cert.PublicKey)
+	}
+	b, err := rsa.EncryptPKCS1v15(rand.Reader, pub, []byte(password))
+	if err != nil {
+		return "", fmt.Errorf("error encrypting password: %s", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
 func FlattenGuestOsCustomizationSpec(d *schema.ResourceData, specItem *types.CustomizationSpecItem) error {
 	d.Set("type", specItem.Info.Type)
 	d.Set("description", specItem.Info.Description)
@@ -412,6 +472,23 @@ func ValidateCustomizationSpec(d *schema.ResourceDiff, family string, isVM bool)
 	case family == string(types.VirtualMachineGuestOsFamilyWindowsGuest) && !windowsExists && !sysprepExists:
 		return errors.New("one of windows_options or windows_sysprep_text must exist in VM customization options for Windows operating systems")
 	}
+	return ValidateDomainAdminPassword(d, isVM)
+}
+
+// ValidateDomainAdminPassword checks that a domain administrator password,
+// either in plain text or encrypted, has been supplied when join_domain is
+// set.
+func ValidateDomainAdminPassword(d *schema.ResourceDiff, isVM bool) error {
+	prefix := getSchemaPrefix(isVM) + "windows_options.0."
+	if !structure.ValuesAvailable(prefix, []string{"join_domain", "domain_admin_password", "domain_admin_password_encrypted"}, d) {
+		return nil
+	}
+	if d.Get(prefix+"join_domain").(string) == "" {
+		return nil
+	}
+	if d.Get(prefix+"domain_admin_password").(string) == "" && d.Get(prefix+"domain_admin_password_encrypted").(string) == "" {
+		return errors.New("one of domain_admin_password or domain_admin_password_encrypted must be set when join_domain is set")
+	}
 	return nil
 }
 func flattenWindowsOptions(customizationPrep *types.CustomizationSysprep) ([]map[string]interface{}, error) {
@@ -422,12 +499,12 @@ func flattenWindowsOptions(customizationPrep *types.CustomizationSysprep) ([]map
 	winOptionsData["auto_logon"] = customizationPrep.GuiUnattended.AutoLogon
 	winOptionsData["auto_logon_count"] = customizationPrep.GuiUnattended.AutoLogonCount
 	if customizationPrep.GuiUnattended.Password != nil {
-		winOptionsData["admin_password"] = customizationPrep.GuiUnattended.Password.Value
+		winOptionsData[passwordKey("admin_password", customizationPrep.GuiUnattended.Password)] = customizationPrep.GuiUnattended.Password.Value
 	}
 	winOptionsData["time_zone"] = customizationPrep.GuiUnattended.TimeZone
 	winOptionsData["domain_admin_user"] = customizationPrep.Identification.DomainAdmin
 	if customizationPrep.Identification.DomainAdminPassword != nil {
-		winOptionsData["domain_admin_password"] = customizationPrep.Identification.DomainAdminPassword.Value
+		winOptionsData[passwordKey("domain_admin_password", customizationPrep.Identification.DomainAdminPassword)] = customizationPrep.Identification.DomainAdminPassword.Value
 	}
 	winOptionsData["join_domain"] = customizationPrep.Identification.JoinDomain
 	winOptionsData["workgroup"] = customizationPrep.Identification.JoinWorkgroup
@@ -585,12 +662,7 @@ func expandCustomizationGuiUnattended(d *schema.ResourceData, prefix string) typ
 		AutoLogon:      d.Get(prefix + "auto_logon").(bool),
 		AutoLogonCount: int32(d.Get(prefix + "auto_logon_count").(int)),
 	}
-	if v, ok := d.GetOk(prefix + "admin_password"); ok {
-		obj.Password = &types.CustomizationPassword{
-			Value:     v.(string),
-			PlainText: true,
-		}
-	}
+	obj.Password = expandCustomizationPassword(d, prefix+"admin_password")
 
 	return obj
 }
@@ -603,13 +675,37 @@ func expandCustomizationIdentification(d *schema.ResourceData, prefix string) ty
 		JoinDomain:    d.Get(prefix + "join_domain").(string),
 		DomainAdmin:   d.Get(prefix + "domain_admin_user").(string),
 	}
-	if v, ok := d.GetOk(prefix + "domain_admin_password"); ok {
-		obj.DomainAdminPassword = &types.CustomizationPassword{
+	obj.DomainAdminPassword = expandCustomizationPassword(d, prefix+"domain_admin_password")
+	return obj
+}
+
+// expandCustomizationPassword returns a CustomizationPassword for the
+// supplied key. A plain text value in key takes precedence, otherwise the
+// pre-encrypted value in the matching "_encrypted" key is used and sent to
+// vCenter Server as is.
+func expandCustomizationPassword(d *schema.ResourceData, key string) *types.CustomizationPassword {
+	if v, ok := d.GetOk(key); ok {
+		return &types.CustomizationPassword{
 			Value:     v.(string),
 			PlainText: true,
 		}
 	}
-	return obj
+	if v, ok := d.GetOk(key + encryptedPasswordSuffix); ok {
+		return &types.CustomizationPassword{
+			Value:     v.(string),
+			PlainText: false,
+		}
+	}
+	return nil
+}
+
+// passwordKey returns the schema key that a CustomizationPassword should be
+// flattened to, depending on whether or not the password is encrypted.
+func passwordKey(key string, password *types.CustomizationPassword) string {
+	if password.PlainText {
+		return key
+	}
+	return key + encryptedPasswordSuffix
 }
 
 // expandCustomizationUserData reads certain ResourceData keys and
@@ -14,6 +14,7 @@ import (
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -202,3 +203,51 @@ func GetConnectionState(host *object.HostSystem) (types.HostSystemConnectionStat
 
 	return hostProps.Runtime.ConnectionState, nil
 }
+
+// rebootPollInterval is the interval at which the connection state of a host
+// is checked while waiting for it to come back from a reboot.
+const rebootPollInterval = 10 * time.Second
+
+// Reboot reboots a host that is in maintenance mode, and waits for it to be
+// connected to vCenter Server again. It is only supported on vCenter, as the
+// connection to a standalone host is lost on reboot.
+func Reboot(host *object.HostSystem, timeout time.Duration) error {
+	if err := viapi.VimValidateVirtualCenter(host.Client()); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Host %q is rebooting", host.Name())
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	res, err := methods.RebootHost_Task(ctx, host.Client(), &types.RebootHost_Task{
+		This:  host.Reference(),
+		Force: false,
+	})
+	if err != nil {
+		return err
+	}
+	if err := object.NewTask(host.Client(), res.Returnval).Wait(ctx); err != nil {
+		return err
+	}
+
+	// The host first drops out of the connected state, then comes back once
+	// the reboot is complete.
+	for _, connected := range []bool{false, true} {
+		for {
+			state, err := GetConnectionState(host)
+			if err != nil {
+				return err
+			}
+			if (state == types.HostSystemConnectionStateConnected) == connected {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("timeout waiting for host(%s) to reboot", host.Reference())
+			case <-time.After(rebootPollInterval):
+			}
+		}
+	}
+	log.Printf("[DEBUG] Host %q is connected after reboot", host.Name())
+	return nil
+}
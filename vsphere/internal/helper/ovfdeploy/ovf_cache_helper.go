@@ -0,0 +1,472 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// downloadCacheCompleteMarker is created in a cache entry once all of its
+	// files have been downloaded.
+	downloadCacheCompleteMarker = ".complete"
+
+	// downloadCacheLockHeartbeat is the interval at which the holder of the
+	// lock of a cache entry refreshes it.
+	downloadCacheLockHeartbeat = 30 * time.Second
+
+	// downloadCacheLockStale is the age after which the lock of a cache entry
+	// is considered abandoned, ie: by a process that was killed.
+	downloadCacheLockStale = 4 * downloadCacheLockHeartbeat
+
+	// downloadCacheLockPoll is the interval at which a locked cache entry is
+	// checked while waiting for another download of it to finish.
+	downloadCacheLockPoll = time.Second
+
+	// downloadCacheEvictionGrace is the time after its last use during which
+	// an entry is not evicted, as another process may still be deploying from
+	// it.
+	downloadCacheEvictionGrace = time.Hour
+)
+
+// DownloadCache is a local directory that holds copies of remote OVF and OVA
+// templates, so that the same template is only downloaded once across
+// deployments. Entries are keyed by URL and by the ETag, or the Last-Modified
+// date and size, of the template, and are safe to use from concurrent
+// deployments, including from other processes sharing the directory.
+//
+// If MaxSize is set, the least recently used entries are evicted once a new
+// entry brings the size of the cache above it. Entries that were used by this
+// process, or by another one within the last hour, are kept, so the cache can
+// exceed MaxSize while they are in use.
+type DownloadCache struct {
+	Dir string
+
+	// The maximum size of the cache in bytes, or 0 for no limit.
+	MaxSize int64
+
+	mu    sync.Mutex
+	inUse map[string]bool
+}
+
+// NewDownloadCache returns a DownloadCache in dir, creating the directory if
+// it does not exist. maxSize is the size in bytes above which entries are
+// evicted, or 0 for no limit.
+func NewDownloadCache(dir string, maxSize int64) (*DownloadCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating ovf download cache directory %s: %s", dir, err)
+	}
+	return &DownloadCache{Dir: dir, MaxSize: maxSize, inUse: make(map[string]bool)}, nil
+}
+
+// LocalPath returns the path of a local copy of the OVF or OVA at fileURL,
+// downloading it into the cache first if needed. For an OVF, the files it
+// references and its manifest and certificate are downloaded alongside it. An
+// error is returned if the template cannot be cached, ie: if the server does
+// not return an ETag or Last-Modified header for it.
+func (c *DownloadCache) LocalPath(fileURL string, deployOva bool, allowUnverifiedSSL bool) (string, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "", fmt.Errorf("cannot determine the file name of %s", fileURL)
+	}
+	client := getClient(allowUnverifiedSSL)
+	validator, err := downloadCacheValidator(client, fileURL)
+	if err != nil {
+		return "", err
+	}
+	dir, err := c.entry(fileURL+"\n"+validator, func(dir string) error {
+		log.Printf("[DEBUG] Downloading %s to the ovf download cache at %s", fileURL, dir)
+		if err := downloadCacheFile(client, fileURL, filepath.Join(dir, name), validator, true); err != nil {
+			return err
+		}
+		if !deployOva {
+			return downloadCacheOvfFiles(client, fileURL, filepath.Join(dir, name))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// entry returns the directory of the cache entry for key, calling fill to
+// populate it first if it is not complete yet. The modification time of the
+// complete marker of an entry records its last use.
+func (c *DownloadCache) entry(key string, fill func(dir string) error) (string, error) {
+	sum := sha256.Sum256([]byte(key))
+	dir := filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+
+	unlock, err := lockDownloadCacheEntry(dir)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	c.markInUse(dir)
+
+	markerPath := filepath.Join(dir, downloadCacheCompleteMarker)
+	if _, err := os.Stat(markerPath); err == nil {
+		log.Printf("[DEBUG] Using ovf download cache entry %s", dir)
+		now := time.Now()
+		_ = os.Chtimes(markerPath, now, now)
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := fill(dir); err != nil {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			log.Printf("[WARN] Could not remove incomplete ovf download cache entry %s: %s", dir, rmErr)
+		}
+		return "", err
+	}
+	marker, err := os.Create(markerPath)
+	if err != nil {
+		return "", err
+	}
+	if err := marker.Close(); err != nil {
+		return "", err
+	}
+	c.evict()
+	return dir, nil
+}
+
+func (c *DownloadCache) markInUse(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inUse == nil {
+		c.inUse = make(map[string]bool)
+	}
+	c.inUse[dir] = true
+}
+
+func (c *DownloadCache) isInUse(dir string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inUse[dir]
+}
+
+// downloadCacheEntry is a complete entry of a DownloadCache.
+type downloadCacheEntry struct {
+	dir      string
+	size     int64
+	lastUsed time.Time
+}
+
+// evict removes the least recently used entries of the cache until its size
+// is at most MaxSize. Entries used by this process, entries used within
+// downloadCacheEvictionGrace and entries that are locked are skipped.
+func (c *DownloadCache) evict() {
+	if c.MaxSize <= 0 {
+		return
+	}
+	dirs, err := os.ReadDir(c.Dir)
+	if err != nil {
+		log.Printf("[WARN] Could not list the ovf download cache %s: %s", c.Dir, err)
+		return
+	}
+	var total int64
+	var entries []downloadCacheEntry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(c.Dir, d.Name())
+		size, err := downloadCacheEntrySize(dir)
+		if err != nil {
+			log.Printf("[WARN] Could not determine the size of ovf download cache entry %s: %s", dir, err)
+			continue
+		}
+		total += size
+		fi, err := os.Stat(filepath.Join(dir, downloadCacheCompleteMarker))
+		if err != nil {
+			// The entry is still being downloaded.
+			continue
+		}
+		entries = append(entries, downloadCacheEntry{dir: dir, size: size, lastUsed: fi.ModTime()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.Before(entries[j].lastUsed)
+	})
+	for _, e := range entries {
+		if total <= c.MaxSize {
+			return
+		}
+		if c.isInUse(e.dir) || time.Since(e.lastUsed) < downloadCacheEvictionGrace {
+			continue
+		}
+		if c.remove(e.dir) {
+			total -= e.size
+		}
+	}
+	if total > c.MaxSize {
+		log.Printf("[DEBUG] The ovf download cache %s holds %d bytes, above its maximum size of %d bytes, in entries that are in use", c.Dir, total, c.MaxSize)
+	}
+}
+
+// remove deletes the cache entry in dir, unless another process holds its
+// lock. The complete marker is removed first, so that a concurrent lookup of
+// the entry downloads it again rather than using a partial copy.
+func (c *DownloadCache) remove(dir string) bool {
+	lockPath := dir + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false
+	}
+	_ = f.Close()
+	defer func() {
+		_ = os.Remove(lockPath)
+	}()
+	log.Printf("[DEBUG] Evicting ovf download cache entry %s", dir)
+	_ = os.Remove(filepath.Join(dir, downloadCacheCompleteMarker))
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("[WARN] Could not remove ovf download cache entry %s: %s", dir, err)
+		return false
+	}
+	return true
+}
+
+// downloadCacheEntrySize returns the total size of the files in dir.
+func downloadCacheEntrySize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// downloadCacheValidator returns a string identifying the current version of
+// the file at fileURL, from the ETag or the Last-Modified and Content-Length
+// headers of the file.
+func downloadCacheValidator(client *http.Client, fileURL string) (string, error) {
+	resp, err := client.Head(fileURL)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("got status %d for HEAD request to %s", resp.StatusCode, fileURL)
+	}
+	return responseValidator(resp, fileURL)
+}
+
+func responseValidator(resp *http.Response, fileURL string) (string, error) {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return "etag:" + etag, nil
+	}
+	if modified := resp.Header.Get("Last-Modified"); modified != "" {
+		return fmt.Sprintf("modified:%s:%d", modified, resp.ContentLength), nil
+	}
+	return "", fmt.Errorf("%s cannot be cached: the server returned neither an ETag nor a Last-Modified header", fileURL)
+}
+
+// downloadCacheOvfFiles downloads the files referenced by the OVF descriptor at
+// localPath, and the manifest and certificate of the OVF if they exist, next to
+// the descriptor.
+func downloadCacheOvfFiles(client *http.Client, fileURL string, localPath string) error {
+	desc, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	e, err := ParseOvfDescriptor(string(desc))
+	if err != nil {
+		return err
+	}
+	base, err := url.Parse(fileURL)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(localPath)
+	for _, ref := range e.References {
+		href, err := relativeOvfReference(ref.Href)
+		if err != nil {
+			return fmt.Errorf("%s cannot be cached: %s", fileURL, err)
+		}
+		refURL, err := base.Parse(href)
+		if err != nil {
+			return err
+		}
+		if err := downloadCacheFile(client, refURL.String(), filepath.Join(dir, filepath.FromSlash(href)), "", true); err != nil {
+			return err
+		}
+	}
+	stem := strings.TrimSuffix(localPath, filepath.Ext(localPath))
+	for _, ext := range []string{".mf", ".cert"} {
+		extURL := strings.TrimSuffix(fileURL, path.Ext(fileURL)) + ext
+		if err := downloadCacheFile(client, extURL, stem+ext, "", false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadCacheFile downloads fileURL to localPath through a temporary file.
+// If validator is set, the download fails if the file changed since the
+// validator was retrieved. If required is false, a missing file is ignored.
+func downloadCacheFile(client *http.Client, fileURL string, localPath string, validator string, required bool) error {
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case !required && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden):
+		return nil
+	default:
+		return fmt.Errorf("got status %d while getting the file from remote url %s ", resp.StatusCode, fileURL)
+	}
+	if validator != "" {
+		if v, err := responseValidator(resp, fileURL); err == nil && v != validator {
+			return fmt.Errorf("%s changed while it was being downloaded", fileURL)
+		}
+	}
+
+	if err := writeCacheFile(resp.Body, localPath); err != nil {
+		return fmt.Errorf("error downloading %s: %s", fileURL, err)
+	}
+	return nil
+}
+
+// relativeOvfReference returns the cleaned href of a file reference of an OVF
+// descriptor, or an error if it does not point to a file next to or below the
+// descriptor.
+func relativeOvfReference(href string) (string, error) {
+	p := path.Clean(href)
+	if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") || strings.Contains(href, "://") {
+		return "", fmt.Errorf("file reference %s is not relative to the descriptor", href)
+	}
+	return p, nil
+}
+
+// writeCacheFile writes the content of r to localPath through a temporary
+// file, so that localPath only exists once it is complete.
+func writeCacheFile(r io.Reader, localPath string) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return err
+	}
+	partial := localPath + ".partial"
+	f, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(partial)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+	return os.Rename(partial, localPath)
+}
+
+// DescriptorCache holds the OVF descriptors that were read during the life
+// of the provider, so that the descriptor of a template is read once, ie: to
+// validate vapp.properties, rather than on every plan of every resource
+// deployed from it.
+type DescriptorCache struct {
+	mu          sync.Mutex
+	descriptors map[string]string
+}
+
+// NewDescriptorCache returns an empty DescriptorCache.
+func NewDescriptorCache() *DescriptorCache {
+	return &DescriptorCache{descriptors: make(map[string]string)}
+}
+
+// Get returns the OVF descriptor of the template in src, reading it only if
+// it is not cached. Failed reads are not cached.
+func (c *DescriptorCache) Get(src Source, deployOva bool) (string, error) {
+	key := fmt.Sprintf("%s\n%t", src, deployOva)
+	c.mu.Lock()
+	desc, ok := c.descriptors[key]
+	c.mu.Unlock()
+	if ok {
+		log.Printf("[DEBUG] Using the cached ovf descriptor of %s", src)
+		return desc, nil
+	}
+	desc, err := GetSourceDescriptor(src, deployOva)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.descriptors[key] = desc
+	c.mu.Unlock()
+	return desc, nil
+}
+
+// lockDownloadCacheEntry acquires the lock of the cache entry in dir, waiting
+// for any other holder of the lock to release it. The lock is a file next to
+// the entry, which the holder refreshes while it holds the lock, so that
+// the lock of a process that died can be detected and broken.
+func lockDownloadCacheEntry(dir string) (func(), error) {
+	lockPath := dir + ".lock"
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error locking ovf download cache entry %s: %s", dir, err)
+		}
+		if fi, err := os.Stat(lockPath); err == nil && time.Since(fi.ModTime()) > downloadCacheLockStale {
+			log.Printf("[WARN] Breaking stale ovf download cache lock %s", lockPath)
+			_ = os.Remove(lockPath)
+			continue
+		}
+		time.Sleep(downloadCacheLockPoll)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tick := time.NewTicker(downloadCacheLockHeartbeat)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				now := time.Now()
+				_ = os.Chtimes(lockPath, now, now)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		_ = os.Remove(lockPath)
+	}, nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testCacheDescriptor = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:href="disks/disk1.vmdk" ovf:id="file1" ovf:size="4"/>
+  </References>
+</Envelope>
+`
+
+func TestDownloadCacheLocalPath(t *testing.T) {
+	files := map[string]string{
+		"/ovf/test.ovf":             testCacheDescriptor,
+		"/ovf/disks/disk1.vmdk":     "disk",
+		"/ovf/test.mf":              "SHA256(test.ovf)= 00\n",
+		"/ova/appliance.ova":        "ova",
+		"/ova-no-validator/app.ova": "ova",
+	}
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	var gets sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path != "/ova-no-validator/app.ova" {
+			w.Header().Set("ETag", etag.Load().(string))
+		}
+		if r.Method == http.MethodGet {
+			n, _ := gets.LoadOrStore(r.URL.Path, new(int32))
+			atomic.AddInt32(n.(*int32), 1)
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer srv.Close()
+	getCount := func(p string) int32 {
+		n, ok := gets.Load(p)
+		if !ok {
+			return 0
+		}
+		return atomic.LoadInt32(n.(*int32))
+	}
+
+	c, err := NewDownloadCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent deployments of the same OVF download it once.
+	var wg sync.WaitGroup
+	paths := make([]string, 5)
+	errs := make([]error, 5)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = c.LocalPath(srv.URL+"/ovf/test.ovf", false, false)
+		}(i)
+	}
+	wg.Wait()
+	for i := range paths {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if paths[i] != paths[0] {
+			t.Fatalf("expected the same path, got %s and %s", paths[0], paths[i])
+		}
+	}
+	if n := getCount("/ovf/test.ovf"); n != 1 {
+		t.Fatalf("expected the descriptor to be downloaded once, got %d", n)
+	}
+	dir := filepath.Dir(paths[0])
+	for _, name := range []string{"test.ovf", "test.mf", filepath.Join("disks", "disk1.vmdk")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s in the cache: %s", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test.cert")); !os.IsNotExist(err) {
+		t.Fatalf("expected no certificate in the cache, got %v", err)
+	}
+
+	// A new version of the template is downloaded again.
+	p1, err := c.LocalPath(srv.URL+"/ova/appliance.ova", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag.Store(`"v2"`)
+	p2, err := c.LocalPath(srv.URL+"/ova/appliance.ova", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1 == p2 || getCount("/ova/appliance.ova") != 2 {
+		t.Fatalf("expected a new download for a new ETag, got %s and %s", p1, p2)
+	}
+
+	// Templates without a validator are not cached.
+	if _, err := c.LocalPath(srv.URL+"/ova-no-validator/app.ova", true, false); err == nil {
+		t.Fatal("expected an error for a template without ETag or Last-Modified")
+	}
+}
+
+func TestDownloadCacheEvict(t *testing.T) {
+	dir := t.TempDir()
+	// Entries created by another process are only protected by their age.
+	other, err := NewDownloadCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill := func(size int) func(string) error {
+		return func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, "disk.vmdk"), make([]byte, size), 0o644)
+		}
+	}
+	age := func(entry string, d time.Duration) {
+		at := time.Now().Add(-d)
+		if err := os.Chtimes(filepath.Join(entry, downloadCacheCompleteMarker), at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldest, err := other.entry("oldest", fill(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	age(oldest, 3*time.Hour)
+	older, err := other.entry("older", fill(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	age(older, 2*time.Hour)
+	recent, err := other.entry("recent", fill(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewDownloadCache(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A failed download leaves no entry behind.
+	if _, err := c.entry("failed", func(string) error { return os.ErrNotExist }); err == nil {
+		t.Fatal("expected an error")
+	}
+	latest, err := c.entry("latest", fill(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for entry, expected := range map[string]bool{oldest: false, older: false, recent: true, latest: true} {
+		if _, err := os.Stat(entry); (err == nil) != expected {
+			t.Fatalf("expected %s to exist: %t, got %v", filepath.Base(entry), expected, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+}
+
+func TestRelativeOvfReference(t *testing.T) {
+	cases := map[string]bool{
+		"disk1.vmdk":          true,
+		"disks/../disk1.vmdk": true,
+		"../disk1.vmdk":       false,
+		"/tmp/disk1.vmdk":     false,
+		"http://x/disk1.vmdk": false,
+	}
+	for href, ok := range cases {
+		if _, err := relativeOvfReference(href); ok != (err == nil) {
+			t.Errorf("%s: expected valid %t, got %v", href, ok, err)
+		}
+	}
+}
+
+func TestDescriptorCacheGet(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/test.ovf" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testCacheDescriptor))
+	}))
+	defer srv.Close()
+
+	c := NewDescriptorCache()
+	for i := 0; i < 2; i++ {
+		desc, err := c.Get(NewSource(srv.URL+"/test.ovf", false, false), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc != testCacheDescriptor {
+			t.Fatalf("unexpected descriptor %q", desc)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected the descriptor to be read once, got %d requests", n)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(NewSource(srv.URL+"/missing.ovf", false, false), false); err == nil {
+			t.Fatal("expected an error for a missing descriptor")
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("expected failed reads not to be cached, got %d requests", n)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// VirtualSystemCollection is the subset of an OVF VirtualSystemCollection
+// that is required to deploy it as a vApp. The govmomi OVF envelope does not
+// model collections, so this is parsed separately from the descriptor.
+type VirtualSystemCollection struct {
+	ID            string               `xml:"id,attr"`
+	Name          string               `xml:"Name"`
+	VirtualSystem []VirtualSystemEntry `xml:"VirtualSystem"`
+}
+
+// VirtualSystemEntry is a VirtualSystem that is a member of a
+// VirtualSystemCollection.
+type VirtualSystemEntry struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"Name"`
+}
+
+type collectionEnvelope struct {
+	VirtualSystemCollection *VirtualSystemCollection `xml:"VirtualSystemCollection"`
+}
+
+// GetVirtualSystemCollection parses the top-level VirtualSystemCollection from
+// the supplied OVF descriptor. nil is returned if the descriptor describes a
+// single VirtualSystem.
+func GetVirtualSystemCollection(ovfDescriptor string) (*VirtualSystemCollection, error) {
+	var e collectionEnvelope
+	if err := xml.NewDecoder(strings.NewReader(ovfDescriptor)).Decode(&e); err != nil {
+		return nil, fmt.Errorf("error parsing ovf descriptor: %s", err)
+	}
+	return e.VirtualSystemCollection, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/datastore"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/folder"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+)
+
+// DatastoreSource is an OVF or OVA template, or any other file, stored on a
+// datastore and read through the datastore HTTP file access of the connected
+// vCenter Server or host. It implements Source.
+type DatastoreSource struct {
+	Datastore *object.Datastore
+	Path      string
+}
+
+// NewDatastoreSource returns the DatastoreSource for the file at filePath,
+// relative to the root of the datastore with ID dsID.
+func NewDatastoreSource(client *govmomi.Client, dsID string, filePath string) (*DatastoreSource, error) {
+	ds, err := datastore.FromID(client, dsID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find datastore with ID %q: %s", dsID, err)
+	}
+	// The datastore HTTP file access addresses datastores by datacenter path
+	// and name.
+	dcPath, err := folder.RootPathParticleDatastore.SplitDatacenter(ds.InventoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine the datacenter of datastore %q: %s", ds.Name(), err)
+	}
+	ds.DatacenterPath = dcPath
+
+	var dp object.DatastorePath
+	if dp.FromString(filePath) {
+		filePath = dp.Path
+	}
+	return &DatastoreSource{
+		Datastore: ds,
+		Path:      strings.TrimPrefix(filePath, "/"),
+	}, nil
+}
+
+// String returns the datastore path of the file, ie: "[datastore1] ovf/vm.ova".
+func (s *DatastoreSource) String() string {
+	return s.Datastore.Path(s.Path)
+}
+
+// IsOva returns true if the file is an OVA.
+func (s *DatastoreSource) IsOva() bool {
+	return strings.HasSuffix(s.Path, ".ova")
+}
+
+// Open returns the file at name, relative to the directory of the template,
+// or the template itself if name is empty. The file is read with range
+// requests, so the entries of an OVA can be skipped without reading them and
+// a failed read is resumed from the offset it reached.
+func (s *DatastoreSource) Open(name string) (io.ReadSeekCloser, error) {
+	p := s.Path
+	if name != "" {
+		p = path.Join(path.Dir(s.Path), name)
+	}
+	f, err := s.Datastore.Open(context.Background(), p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", s.Datastore.Path(p), err)
+	}
+	return newResumableFile(s.Datastore.Path(p), f), nil
+}
+
+// Name returns the file name of the template.
+func (s *DatastoreSource) Name() string {
+	return path.Base(s.Path)
+}
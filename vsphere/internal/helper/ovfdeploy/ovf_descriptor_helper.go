@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/ovf"
+)
+
+// capacityAllocationUnitsRe matches the programmatic units used in the
+// capacityAllocationUnits attribute of an OVF disk, ie: "byte * 2^30".
+var capacityAllocationUnitsRe = regexp.MustCompile(`^byte\s*(?:\*\s*(\d+)\s*\^\s*(\d+))?$`)
+
+// ParseOvfDescriptor parses the supplied OVF descriptor into an envelope.
+func ParseOvfDescriptor(ovfDescriptor string) (*ovf.Envelope, error) {
+	e, err := ovf.Unmarshal(strings.NewReader(ovfDescriptor))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ovf descriptor: %s", err)
+	}
+	return e, nil
+}
+
+// ProductSections returns all of the product sections in the envelope, both at
+// the envelope level and in the VirtualSystem.
+func ProductSections(e *ovf.Envelope) []ovf.ProductSection {
+	var sections []ovf.ProductSection
+	if e.Product != nil {
+		sections = append(sections, *e.Product)
+	}
+	if e.VirtualSystem != nil {
+		sections = append(sections, e.VirtualSystem.Product...)
+	}
+	return sections
+}
+
+// EulaSections returns all of the EULA sections in the envelope, both at the
+// envelope level and in the VirtualSystem.
+func EulaSections(e *ovf.Envelope) []ovf.EulaSection {
+	var sections []ovf.EulaSection
+	if e.Eula != nil {
+		sections = append(sections, *e.Eula)
+	}
+	if e.VirtualSystem != nil {
+		sections = append(sections, e.VirtualSystem.Eula...)
+	}
+	return sections
+}
+
+// DiskCapacityBytes returns the capacity of the supplied OVF disk in bytes,
+// taking into account its capacityAllocationUnits.
+func DiskCapacityBytes(disk ovf.VirtualDiskDesc) (int64, error) {
+	capacity, err := strconv.ParseInt(disk.Capacity, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid capacity %q for disk %s", disk.Capacity, disk.DiskID)
+	}
+	if disk.CapacityAllocationUnits == nil {
+		return capacity, nil
+	}
+	units := strings.TrimSpace(*disk.CapacityAllocationUnits)
+	m := capacityAllocationUnitsRe.FindStringSubmatch(units)
+	if m == nil {
+		return 0, fmt.Errorf("unsupported capacity allocation units %q for disk %s", units, disk.DiskID)
+	}
+	if m[1] == "" {
+		return capacity, nil
+	}
+	base, _ := strconv.Atoi(m[1])
+	exp, _ := strconv.Atoi(m[2])
+	return capacity * int64(math.Pow(float64(base), float64(exp))), nil
+}
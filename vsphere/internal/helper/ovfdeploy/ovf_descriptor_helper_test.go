@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/ovf"
+)
+
+func TestDiskCapacityBytes(t *testing.T) {
+	units := func(s string) *string { return &s }
+	cases := []struct {
+		name      string
+		disk      ovf.VirtualDiskDesc
+		expected  int64
+		expectErr bool
+	}{
+		{
+			name:     "no units",
+			disk:     ovf.VirtualDiskDesc{DiskID: "vmdisk1", Capacity: "1073741824"},
+			expected: 1073741824,
+		},
+		{
+			name:     "bytes",
+			disk:     ovf.VirtualDiskDesc{DiskID: "vmdisk1", Capacity: "1024", CapacityAllocationUnits: units("byte")},
+			expected: 1024,
+		},
+		{
+			name:     "gibibytes",
+			disk:     ovf.VirtualDiskDesc{DiskID: "vmdisk1", Capacity: "20", CapacityAllocationUnits: units("byte * 2^30")},
+			expected: 20 * 1024 * 1024 * 1024,
+		},
+		{
+			name:     "megabytes without spaces",
+			disk:     ovf.VirtualDiskDesc{DiskID: "vmdisk1", Capacity: "5", CapacityAllocationUnits: units("byte*10^6")},
+			expected: 5000000,
+		},
+		{
+			name:      "unsupported units",
+			disk:      ovf.VirtualDiskDesc{DiskID: "vmdisk1", Capacity: "5", CapacityAllocationUnits: units("bit")},
+			expectErr: true,
+		},
+		{
+			name:      "non-numeric capacity",
+			disk:      ovf.VirtualDiskDesc{DiskID: "vmdisk1", Capacity: "${disk.size}"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := DiskCapacityBytes(tc.disk)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bad: %s", err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
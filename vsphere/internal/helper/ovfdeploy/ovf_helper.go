@@ -4,7 +4,6 @@
 package ovfdeploy
 
 import (
-	"archive/tar"
 	"context"
 	"crypto/tls"
 	"errors"
@@ -12,7 +11,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -24,6 +22,7 @@ import (
 
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/network"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/nfc"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/ovf"
 	"github.com/vmware/govmomi/vim25/soap"
@@ -49,83 +48,167 @@ func (pr *ProgressReader) Read(p []byte) (n int, err error) {
 	return
 }
 
+// DeployOvfAndGetResult imports the supplied import spec and uploads its
+// files from src. The reference to the created entity, a VirtualMachine or a
+// VirtualApp, is returned.
+//
+// The files are uploaded concurrently and the upload of each file is retried
+// on its own, as controlled by opts, while the lease is kept alive. Reads from
+// a remote source that fail are resumed from the offset they reached, while a
+// failed upload to the host is retried from the start of the file, as the
+// lease does not accept partial content. Every file is checked against
+// manifest, which is the manifest the descriptor was validated with, if the
+// OVF has one: a file that is not listed in it or whose checksum does not
+// match the bytes uploaded fails the deployment before the lease is completed.
 func DeployOvfAndGetResult(client *govmomi.Client, ovfCreateImportSpecResult *types.OvfCreateImportSpecResult, resourcePoolObj *object.ResourcePool,
-	folder *object.Folder, host *object.HostSystem, filePath string, deployOva bool, fromLocal bool, allowUnverifiedSSL bool) error {
+	folder *object.Folder, host *object.HostSystem, src Source, deployOva bool, manifest Manifest, opts UploadOptions) (*types.ManagedObjectReference, error) {
+	ctx := context.Background()
 
-	var currBytesRead int64
-	var totalBytes int64
+	src, cleanup, err := PrepareSource(src, deployOva)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	if manifest == nil {
+		log.Printf("[DEBUG] No manifest found for %s, skipping checksum verification", src)
+	}
 
-	nfcLease, err := resourcePoolObj.ImportVApp(context.Background(), ovfCreateImportSpecResult.ImportSpec, folder, host)
+	nfcLease, err := resourcePoolObj.ImportVApp(ctx, ovfCreateImportSpecResult.ImportSpec, folder, host)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	leaseInfo, err := nfcLease.Wait(context.Background(), ovfCreateImportSpecResult.FileItem)
+	leaseInfo, err := nfcLease.Wait(ctx, ovfCreateImportSpecResult.FileItem)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	u := nfcLease.StartUpdater(context.Background(), leaseInfo)
+	// The updater renews the lease every few seconds, which keeps it alive
+	// while a failed upload waits to be retried.
+	u := nfcLease.StartUpdater(ctx, leaseInfo)
 	defer u.Done()
 
+	var uploads []ovfFileUpload
+	var totalBytes int64
 	for _, ovfFileItem := range ovfCreateImportSpecResult.FileItem {
-		totalBytes += ovfFileItem.Size
+		for _, deviceObj := range leaseInfo.DeviceUrl {
+			if ovfFileItem.DeviceId != deviceObj.ImportKey {
+				continue
+			}
+			uploads = append(uploads, ovfFileUpload{item: ovfFileItem, device: deviceObj})
+			totalBytes += ovfFileItem.Size
+		}
 	}
 	log.Printf("Total size of files to upload is %v bytes", totalBytes)
 
-	statusChannel := make(chan bool)
-	// Create a go routine to update progress regularly
-	go func() {
-		for {
-			select {
-			case <-statusChannel:
-				break
-			default:
-				if totalBytes == 0 {
-					_ = nfcLease.Progress(context.Background(), 100)
-					return
-				}
-				log.Printf("Uploaded %v of %v Bytes", getTotalBytesRead(&currBytesRead), totalBytes)
-				progress := (getTotalBytesRead(&currBytesRead) / totalBytes) * 100
-				_ = nfcLease.Progress(context.Background(), int32(progress))
-				time.Sleep(10 * time.Second)
-			}
+	uploader := &ovfUploader{
+		client:    client,
+		source:    src,
+		deployOva: deployOva,
+		manifest:  manifest,
+	}
+	bytesRead := make([]int64, len(uploads))
+	done := make(chan struct{})
+	go reportLeaseProgress(nfcLease, bytesRead, totalBytes, done)
+
+	err = opts.Parallel(ctx, len(uploads), func(ctx context.Context, i int) error {
+		item, device := uploads[i].item, uploads[i].device
+		err := opts.Retry(ctx, item.Path, func(int) error {
+			// Progress of a failed attempt is discarded, as the file is
+			// uploaded to the host again from the start.
+			atomic.StoreInt64(&bytesRead[i], 0)
+			return uploader.uploadFileItem(ctx, item, device, &bytesRead[i])
+		})
+		if err != nil {
+			return fmt.Errorf("error while uploading the disk %s %s", item.Path, err)
 		}
+		log.Printf("[DEBUG] Completed uploading the file %s", item.Path)
+		return nil
+	})
+	close(done)
+	if err != nil {
+		fault := &types.LocalizedMethodFault{LocalizedMessage: err.Error()}
+		if abortErr := nfcLease.Abort(ctx, fault); abortErr != nil {
+			log.Printf("[WARN] Error while aborting the lease: %s", abortErr)
+		}
+		return nil, err
+	}
+
+	err = nfcLease.Progress(ctx, 100)
+	if err != nil {
+		return nil, err
+	}
+	if err = nfcLease.Complete(ctx); err != nil {
+		return nil, err
+	}
+	return &leaseInfo.Entity, nil
+}
+
+// ovfFileUpload is a file of an OVF together with the lease URL it is
+// uploaded to.
+type ovfFileUpload struct {
+	item   types.OvfFileItem
+	device types.HttpNfcLeaseDeviceUrl
+}
+
+// ovfUploader uploads the files of an OVF or OVA to the URLs of a lease.
+type ovfUploader struct {
+	client    *govmomi.Client
+	source    Source
+	deployOva bool
+	manifest  Manifest
+}
+
+// uploadFileItem uploads a single file from its source and verifies its
+// checksum against the manifest.
+func (u *ovfUploader) uploadFileItem(ctx context.Context, ovfFileItem types.OvfFileItem, deviceObj types.HttpNfcLeaseDeviceUrl, currBytesRead *int64) error {
+	v, err := u.manifest.Verifier(ovfFileItem.Path)
+	if err != nil {
+		return err
+	}
+	f, err := OpenFile(ctx, u.source, u.deployOva, ovfFileItem.Path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
 	}()
+	err = upload(ctx, u.client, ovfFileItem, f, deviceObj.Url, ovfFileItem.Size, currBytesRead, v)
+	if err != nil {
+		return fmt.Errorf("error while uploading the file %s %s", ovfFileItem.Path, err)
+	}
+	return v.Verify()
+}
 
-	for _, ovfFileItem := range ovfCreateImportSpecResult.FileItem {
-		for _, deviceObj := range leaseInfo.DeviceUrl {
-			if ovfFileItem.DeviceId != deviceObj.ImportKey {
-				continue
+// reportLeaseProgress regularly reports the overall upload progress to the
+// lease until done is closed.
+func reportLeaseProgress(nfcLease *nfc.Lease, bytesRead []int64, totalBytes int64, done <-chan struct{}) {
+	tick := time.NewTicker(10 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+			var read int64
+			for i := range bytesRead {
+				read += getTotalBytesRead(&bytesRead[i])
 			}
-			if !deployOva {
-				if fromLocal {
-					err = uploadDisksFromLocal(client, filePath, ovfFileItem, deviceObj, &currBytesRead)
-				} else {
-					err = uploadDisksFromURL(client, filePath, ovfFileItem, deviceObj, &currBytesRead, allowUnverifiedSSL)
-				}
-			} else {
-				if fromLocal {
-					err = uploadOvaDisksFromLocal(client, filePath, ovfFileItem, deviceObj, &currBytesRead)
-				} else {
-					err = uploadOvaDisksFromURL(client, filePath, ovfFileItem, deviceObj, &currBytesRead, allowUnverifiedSSL)
-				}
+			log.Printf("Uploaded %v of %v Bytes", read, totalBytes)
+			if totalBytes == 0 {
+				continue
 			}
-			if err != nil {
-				return fmt.Errorf("error while uploading the disk %s %s", ovfFileItem.Path, err)
+			progress := read * 100 / totalBytes
+			if progress > 99 {
+				progress = 99
 			}
-			log.Print(" DEBUG : Completed uploading the vmdk file", ovfFileItem.Path)
+			_ = nfcLease.Progress(context.Background(), int32(progress))
 		}
 	}
-	statusChannel <- true
-	err = nfcLease.Progress(context.Background(), 100)
-	if err != nil {
-		return err
-	}
-	return nfcLease.Complete(context.Background())
 }
 
-func upload(ctx context.Context, client *govmomi.Client, item types.OvfFileItem, f io.Reader, rawUrl string, size int64, totalBytesRead *int64) error {
+func upload(ctx context.Context, client *govmomi.Client, item types.OvfFileItem, f io.Reader, rawUrl string, size int64, totalBytesRead *int64, v *ChecksumVerifier) error {
 	u, err := client.Client.ParseURL(rawUrl)
 	if err != nil {
 		return err
@@ -147,7 +230,7 @@ func upload(ctx context.Context, client *govmomi.Client, item types.OvfFileItem,
 		param.Type = "application/x-vnd.vmware-streamVmdk"
 	}
 
-	pr := &ProgressReader{f, func(r int64) {
+	pr := &ProgressReader{v.Reader(f), func(r int64) {
 		incrementTotalBytesRead(totalBytesRead, r)
 	}}
 	f = pr
@@ -186,185 +269,24 @@ func upload(ctx context.Context, client *govmomi.Client, item types.OvfFileItem,
 	return err
 }
 
-func uploadDisksFromLocal(client *govmomi.Client, filePath string, ovfFileItem types.OvfFileItem, deviceObj types.HttpNfcLeaseDeviceUrl, currBytesRead *int64) error {
-	absoluteFilePath := ""
-	if strings.Contains(filePath, string(os.PathSeparator)) {
-		absoluteFilePath = string(filePath[0 : strings.LastIndex(filePath, string(os.PathSeparator))+1])
-	}
-	vmdkFilePath := absoluteFilePath + ovfFileItem.Path
-	log.Print(" [DEBUG] Absolute vmdk path: " + vmdkFilePath)
-	file, err := os.Open(vmdkFilePath)
-	if err != nil {
-		return err
-	}
-	err = upload(context.Background(), client, ovfFileItem, file, deviceObj.Url, ovfFileItem.Size, currBytesRead)
-	if err != nil {
-		return fmt.Errorf("error while uploading the file %s %s", vmdkFilePath, err)
-	}
-	err = file.Close()
-	if err != nil {
-		log.Printf("error while closing the file %s", vmdkFilePath)
-	}
-	return nil
-}
-
-func uploadDisksFromURL(client *govmomi.Client, filePath string, ovfFileItem types.OvfFileItem, deviceObj types.HttpNfcLeaseDeviceUrl, currBytesRead *int64,
-	allowUnverifiedSSL bool) error {
-	absoluteFilePath := ""
-	if strings.Contains(filePath, "/") {
-		absoluteFilePath = string(filePath[0 : strings.LastIndex(filePath, "/")+1])
-	}
-	vmdkFilePath := absoluteFilePath + ovfFileItem.Path
-	httpClient := getClient(allowUnverifiedSSL)
-	resp, err := httpClient.Get(vmdkFilePath)
-	log.Print(" [DEBUG] Absolute vmdk path: " + vmdkFilePath)
-	if err != nil {
-		return err
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	err = upload(context.Background(), client, ovfFileItem, resp.Body, deviceObj.Url, ovfFileItem.Size, currBytesRead)
-	return err
-}
-
-func uploadOvaDisksFromLocal(client *govmomi.Client, filePath string, ovfFileItem types.OvfFileItem, deviceObj types.HttpNfcLeaseDeviceUrl, currBytesRead *int64) error {
-	diskName := ovfFileItem.Path
-	ovaFile, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer func(ovaFile *os.File) {
-		_ = ovaFile.Close()
-	}(ovaFile)
-
-	err = findAndUploadDiskFromOva(client, ovaFile, diskName, ovfFileItem, deviceObj, currBytesRead)
-	return err
-}
-
-func uploadOvaDisksFromURL(client *govmomi.Client, filePath string, ovfFileItem types.OvfFileItem, deviceObj types.HttpNfcLeaseDeviceUrl, currBytesRead *int64,
-	allowUnverifiedSSL bool) error {
-	diskName := ovfFileItem.Path
-	httpClient := getClient(allowUnverifiedSSL)
-	resp, err := httpClient.Get(filePath)
-	if err != nil {
-		return err
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	if resp.StatusCode == http.StatusOK {
-		err = findAndUploadDiskFromOva(client, resp.Body, diskName, ovfFileItem, deviceObj, currBytesRead)
-		if err != nil {
-			return err
-		}
-	} else {
-		return fmt.Errorf("got status %d while getting the file from remote url %s ", resp.StatusCode, filePath)
-	}
-	return nil
-}
-
 func GetOvfDescriptor(filePath string, deployOva bool, fromLocal bool, allowUnverifiedSSL bool) (string, error) {
-	ovfDescriptor := ""
-	if !deployOva {
-		if fromLocal {
-			fileBuffer, err := os.ReadFile(filePath)
-			if err != nil {
-				return "", err
-			}
-			ovfDescriptor = string(fileBuffer)
-		} else {
-			client := getClient(allowUnverifiedSSL)
-			resp, err := client.Get(filePath)
-			if err != nil {
-				return "", err
-			}
-			defer func(Body io.ReadCloser) {
-				_ = Body.Close()
-			}(resp.Body)
-
-			if resp.StatusCode == http.StatusOK {
-				bodyBytes, err := io.ReadAll(resp.Body)
-				if err != nil {
-					return "", err
-				}
-				ovfDescriptor = string(bodyBytes)
-			}
-		}
-	} else {
-		if fromLocal {
-			ovaFile, err := os.Open(filePath)
-			if err != nil {
-				return "", err
-			}
-			defer func(ovaFile *os.File) {
-				_ = ovaFile.Close()
-			}(ovaFile)
-			ovfDescriptor, err = getOvfDescriptorFromOva(ovaFile)
-			if err != nil {
-				return "", err
-			}
-		} else {
-			client := getClient(allowUnverifiedSSL)
-			resp, err := client.Get(filePath)
-			if err != nil {
-				return "", err
-			}
-			defer func(Body io.ReadCloser) {
-				_ = Body.Close()
-			}(resp.Body)
-
-			if resp.StatusCode == http.StatusOK {
-				ovfDescriptor, err = getOvfDescriptorFromOva(resp.Body)
-				if err != nil {
-					return "", err
-				}
-			} else {
-				return "", fmt.Errorf("got status %d while getting the file from remote url %s ", resp.StatusCode, filePath)
-			}
-		}
-	}
-	return ovfDescriptor, nil
+	return GetSourceDescriptor(NewSource(filePath, fromLocal, allowUnverifiedSSL), deployOva)
 }
 
-func getOvfDescriptorFromOva(ovaFile io.Reader) (string, error) {
-	ovaReader := tar.NewReader(ovaFile)
-	for {
-		fileHdr, err := ovaReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return "", err
-		}
-		if strings.HasSuffix(fileHdr.Name, ".ovf") {
-			content, _ := io.ReadAll(ovaReader)
-			ovfDescriptor := string(content)
-			return ovfDescriptor, nil
-		}
+// GetSourceDescriptor reads the OVF descriptor of the template in src.
+func GetSourceDescriptor(src Source, deployOva bool) (string, error) {
+	files, err := getOvfFiles(src, deployOva, ".ovf")
+	if err != nil {
+		return "", err
 	}
-	return "", fmt.Errorf("ovf file not found inside the ova")
-}
-
-func findAndUploadDiskFromOva(client *govmomi.Client, ovaFile io.Reader, diskName string, ovfFileItem types.OvfFileItem, deviceObj types.HttpNfcLeaseDeviceUrl, currBytesRead *int64) error {
-	ovaReader := tar.NewReader(ovaFile)
-	for {
-		fileHdr, err := ovaReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		if fileHdr.Name == diskName {
-			err = upload(context.Background(), client, ovfFileItem, ovaReader, deviceObj.Url, ovfFileItem.Size, currBytesRead)
-			if err != nil {
-				return fmt.Errorf("error while uploading the file %s %s", diskName, err)
-			}
-			return nil
+	descriptor, ok := files[".ovf"]
+	if !ok {
+		if deployOva {
+			return "", fmt.Errorf("ovf file not found inside the ova")
 		}
+		return "", fmt.Errorf("%s not found", src)
 	}
-	return fmt.Errorf("disk %s not found inside ova", diskName)
+	return string(descriptor.content), nil
 }
 
 func GetNetworkMapping(client *govmomi.Client, m map[string]interface{}) ([]types.OvfNetworkMapping, error) {
@@ -423,23 +345,39 @@ type OvfHelper struct {
 	IPAllocationPolicy string
 	IPProtocol         string
 	NetworkMapping     []types.OvfNetworkMapping
+	OvfDescriptor      string
 	ResourcePool       *object.ResourcePool
+	SignatureOptions   SignatureOptions
+	Signer             *SignerInfo
+	UploadOptions      UploadOptions
+
+	// source reads the template from FilePath or from a datastore.
+	source Source
+
+	// manifest is the manifest read with the descriptor by GetImportSpec,
+	// against which the files are verified as they are deployed.
+	manifest Manifest
 }
 
 type OvfHelperParams struct {
-	AllowUnverifiedSSL bool
-	DatastoreID        string
-	DeploymentOption   string
-	DiskProvisioning   string
-	FilePath           string
-	Folder             string
-	HostID             string
-	IPAllocationPolicy string
-	IPProtocol         string
-	Name               string
-	NetworkMappings    map[string]interface{}
-	OvfURL             string
-	PoolID             string
+	AllowUnverifiedSSL  bool
+	DatastoreID         string
+	DeploymentOption    string
+	DiskProvisioning    string
+	DownloadCache       *DownloadCache
+	FilePath            string
+	Folder              string
+	HostID              string
+	IPAllocationPolicy  string
+	IPProtocol          string
+	Name                string
+	NetworkMappings     map[string]interface{}
+	OvfURL              string
+	PoolID              string
+	SignatureOptions    SignatureOptions
+	SourceDatastoreID   string
+	SourceDatastorePath string
+	UploadOptions       UploadOptions
 }
 
 func NewOvfHelper(client *govmomi.Client, o *OvfHelperParams) (*OvfHelper, error) {
@@ -450,6 +388,8 @@ func NewOvfHelper(client *govmomi.Client, o *OvfHelperParams) (*OvfHelper, error
 		IPAllocationPolicy: o.IPAllocationPolicy,
 		IPProtocol:         o.IPProtocol,
 		Name:               o.Name,
+		SignatureOptions:   o.SignatureOptions,
+		UploadOptions:      o.UploadOptions,
 	}
 
 	ovfParams.DeployOva = false
@@ -466,6 +406,16 @@ func NewOvfHelper(client *govmomi.Client, o *OvfHelperParams) (*OvfHelper, error
 		ovfParams.DeployOva = true
 	}
 
+	if !ovfParams.IsLocal && o.DownloadCache != nil {
+		localPath, err := o.DownloadCache.LocalPath(ovfParams.FilePath, ovfParams.DeployOva, o.AllowUnverifiedSSL)
+		if err != nil {
+			log.Printf("[WARN] Not using the ovf download cache for %s: %s", ovfParams.FilePath, err)
+		} else {
+			ovfParams.FilePath = localPath
+			ovfParams.IsLocal = true
+		}
+	}
+
 	// Resource pool
 	poolID := o.PoolID
 	poolObj, err := resourcepool.FromID(client, poolID)
@@ -510,6 +460,22 @@ func NewOvfHelper(client *govmomi.Client, o *OvfHelperParams) (*OvfHelper, error
 	}
 	ovfParams.NetworkMapping = networkMapping
 
+	if (o.SourceDatastoreID == "") != (o.SourceDatastorePath == "") {
+		return nil, fmt.Errorf("both the source datastore ID and the path to the ovf/ova file on it are required")
+	}
+	if o.SourceDatastoreID != "" {
+		src, err := NewDatastoreSource(client, o.SourceDatastoreID, o.SourceDatastorePath)
+		if err != nil {
+			return nil, err
+		}
+		ovfParams.source = src
+		ovfParams.FilePath = src.String()
+		ovfParams.IsLocal = false
+		ovfParams.DeployOva = src.IsOva()
+	} else {
+		ovfParams.source = NewSource(ovfParams.FilePath, ovfParams.IsLocal, ovfParams.AllowUnverifiedSSL)
+	}
+
 	return ovfParams, nil
 }
 
@@ -524,14 +490,19 @@ func (o *OvfHelper) GetImportSpec(client *govmomi.Client) (*types.OvfCreateImpor
 		DiskProvisioning:   o.DiskProvisioning,
 	}
 
-	ovfDescriptor, err := GetOvfDescriptor(o.FilePath, o.DeployOva, o.IsLocal, o.AllowUnverifiedSSL)
+	// The descriptor and manifest are read once, and the deployment uses
+	// them as they were validated.
+	vt, err := ValidateTemplate(o.SignatureOptions, o.source, o.DeployOva)
 	if err != nil {
 		return nil, fmt.Errorf("error while reading the ovf file %s, %s ", o.FilePath, err)
 	}
-
+	ovfDescriptor := vt.Descriptor
 	if ovfDescriptor == "" {
 		return nil, fmt.Errorf("the given ovf file %s is empty", o.FilePath)
 	}
+	o.OvfDescriptor = ovfDescriptor
+	o.Signer = vt.Signer
+	o.manifest = vt.Manifest
 
 	ovfManager := ovf.NewManager(client.Client)
 	deploymentOption := o.DeploymentOption
@@ -559,6 +530,22 @@ func (o *OvfHelper) GetImportSpec(client *govmomi.Client) (*types.OvfCreateImpor
 }
 
 func (o *OvfHelper) DeployOvf(client *govmomi.Client, spec *types.OvfCreateImportSpecResult) error {
-	return DeployOvfAndGetResult(client, spec, o.ResourcePool, o.Folder, o.HostSystem,
-		o.FilePath, o.DeployOva, o.IsLocal, o.AllowUnverifiedSSL)
+	_, err := DeployOvfAndGetResult(client, spec, o.ResourcePool, o.Folder, o.HostSystem,
+		o.source, o.DeployOva, o.manifest, o.UploadOptions)
+	return err
+}
+
+// DeployVApp deploys an OVF describing a VirtualSystemCollection as a vApp
+// and returns the created vApp. The import spec must be a
+// VirtualAppImportSpec, as returned by GetImportSpec for such an OVF.
+func (o *OvfHelper) DeployVApp(client *govmomi.Client, spec *types.OvfCreateImportSpecResult) (*object.VirtualApp, error) {
+	if _, ok := spec.ImportSpec.(*types.VirtualAppImportSpec); !ok {
+		return nil, fmt.Errorf("ovf %s does not describe a VirtualSystemCollection", o.FilePath)
+	}
+	ref, err := DeployOvfAndGetResult(client, spec, o.ResourcePool, o.Folder, o.HostSystem,
+		o.source, o.DeployOva, o.manifest, o.UploadOptions)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewVirtualApp(client.Client, *ref), nil
 }
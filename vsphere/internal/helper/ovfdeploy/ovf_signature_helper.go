@@ -0,0 +1,396 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	// SignaturePolicyIgnore skips the validation of the signature of an OVF.
+	SignaturePolicyIgnore = "ignore"
+
+	// SignaturePolicyWarn validates the signature of an OVF and logs a warning
+	// if the OVF is unsigned or the validation fails.
+	SignaturePolicyWarn = "warn"
+
+	// SignaturePolicyRequire fails the deployment if the OVF is unsigned or
+	// its signature cannot be validated.
+	SignaturePolicyRequire = "require"
+)
+
+// SignaturePolicies is the list of valid OVF signature policies.
+var SignaturePolicies = []string{
+	SignaturePolicyIgnore,
+	SignaturePolicyWarn,
+	SignaturePolicyRequire,
+}
+
+// SignatureOptions controls the validation of the signature of an OVF.
+type SignatureOptions struct {
+	// One of the SignaturePolicy constants. An empty policy is treated as
+	// SignaturePolicyIgnore.
+	Policy string
+
+	// PEM encoded CA certificates the signing certificate must chain to. The
+	// system roots are used if empty.
+	TrustedCABundle string
+}
+
+// Signature is the signature of an OVF manifest, as read from the
+// certificate (.cert) file of the OVF.
+type Signature struct {
+	// The hash algorithm, ie: SHA256.
+	Algorithm string
+
+	// The name of the signed manifest file.
+	ManifestName string
+
+	// The signature of the digest of the manifest.
+	Value []byte
+
+	// The signing certificate, followed by its intermediates.
+	Certificates []*x509.Certificate
+}
+
+// SignerInfo describes the certificate that signed an OVF.
+type SignerInfo struct {
+	Subject      string
+	Issuer       string
+	SerialNumber string
+	Thumbprint   string
+	NotBefore    time.Time
+	NotAfter     time.Time
+
+	// Whether the signature and certificate chain were validated.
+	Verified bool
+}
+
+// ParseSignature parses the contents of an OVF certificate file, which holds
+// the signature of the manifest followed by the PEM encoded signing
+// certificate and its chain.
+func ParseSignature(data []byte) (*Signature, error) {
+	s := &Signature{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "-----BEGIN") {
+			break
+		}
+		parts := strings.SplitN(line, ")=", 2)
+		name := strings.SplitN(parts[0], "(", 2)
+		if len(parts) != 2 || len(name) != 2 {
+			return nil, fmt.Errorf("invalid signature line %q in ovf certificate", line)
+		}
+		value, err := hex.DecodeString(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature in ovf certificate: %s", err)
+		}
+		s.Algorithm = strings.ToUpper(strings.TrimSpace(name[0]))
+		s.ManifestName = name[1]
+		s.Value = value
+		break
+	}
+	if s.Value == nil {
+		return nil, fmt.Errorf("no signature found in ovf certificate")
+	}
+
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate in ovf certificate: %s", err)
+		}
+		s.Certificates = append(s.Certificates, cert)
+	}
+	if len(s.Certificates) == 0 {
+		return nil, fmt.Errorf("no signing certificate found in ovf certificate")
+	}
+	return s, nil
+}
+
+// Signer returns the details of the signing certificate.
+func (s *Signature) Signer() *SignerInfo {
+	cert := s.Certificates[0]
+	return &SignerInfo{
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		SerialNumber: cert.SerialNumber.Text(16),
+		Thumbprint:   thumbprintSHA256(cert),
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+	}
+}
+
+// Verify checks the signature of the supplied manifest contents and verifies
+// the signing certificate against roots. The system roots are used if roots
+// is nil.
+func (s *Signature) Verify(manifest []byte, roots *x509.CertPool) error {
+	var h crypto.Hash
+	switch s.Algorithm {
+	case "SHA1":
+		h = crypto.SHA1
+	case "SHA256":
+		h = crypto.SHA256
+	case "SHA512":
+		h = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", s.Algorithm)
+	}
+	hasher := h.New()
+	hasher.Write(manifest)
+	digest := hasher.Sum(nil)
+
+	cert := s.Certificates[0]
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, h, digest, s.Value); err != nil {
+			return fmt.Errorf("invalid manifest signature: %s", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, s.Value) {
+			return fmt.Errorf("invalid manifest signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T in signing certificate", key)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range s.Certificates[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("error verifying signing certificate %q: %s", cert.Subject, err)
+	}
+	return nil
+}
+
+// ValidatedTemplate is the descriptor and manifest of an OVF or OVA, read once
+// to validate its signature. Deploying this descriptor and verifying the other
+// files against this manifest as they are uploaded ensures that what is
+// deployed is what was validated, even if the template changes in between.
+type ValidatedTemplate struct {
+	Descriptor string
+	Manifest   Manifest
+	Signer     *SignerInfo
+}
+
+// ValidateTemplate reads the descriptor and manifest of the template in src
+// and validates its signature according to the policy in opts. The signature
+// of the manifest, the chain of the signing certificate, which must allow code
+// signing, and the checksum of the descriptor in the manifest are validated.
+// The signer is returned if the OVF is signed, even if the validation failed
+// under SignaturePolicyWarn.
+func ValidateTemplate(opts SignatureOptions, src Source, deployOva bool) (*ValidatedTemplate, error) {
+	validate := opts.Policy != "" && opts.Policy != SignaturePolicyIgnore
+	exts := []string{".ovf", ".mf"}
+	if validate {
+		exts = append(exts, ".cert")
+	}
+	files, err := getOvfFiles(src, deployOva, exts...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ovf files: %s", err)
+	}
+	ovfDesc, ok := files[".ovf"]
+	if !ok {
+		return nil, fmt.Errorf("ovf descriptor not found in %s", src)
+	}
+	t := &ValidatedTemplate{Descriptor: string(ovfDesc.content)}
+	if mf, ok := files[".mf"]; ok {
+		t.Manifest, err = ReadManifest(bytes.NewReader(mf.content))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !validate {
+		return t, nil
+	}
+
+	t.Signer, err = validateSignature(opts, src, files, t.Manifest)
+	if err != nil {
+		if opts.Policy == SignaturePolicyRequire {
+			return nil, err
+		}
+		log.Printf("[WARN] Signature validation of %s failed: %s", src, err)
+		return t, nil
+	}
+	t.Signer.Verified = true
+	log.Printf("[DEBUG] Validated signature of %s, signed by %q", src, t.Signer.Subject)
+	return t, nil
+}
+
+func validateSignature(opts SignatureOptions, src Source, files map[string]ovfFile, manifest Manifest) (*SignerInfo, error) {
+	certFile, ok := files[".cert"]
+	if !ok {
+		return nil, fmt.Errorf("%s is not signed: no certificate (.cert) file found", src)
+	}
+	mf, ok := files[".mf"]
+	if !ok {
+		return nil, fmt.Errorf("%s is not signed: no manifest (.mf) file found", src)
+	}
+	sig, err := ParseSignature(certFile.content)
+	if err != nil {
+		return nil, err
+	}
+	signer := sig.Signer()
+	if sig.ManifestName != mf.name {
+		return signer, fmt.Errorf("certificate signs %s, but the manifest is %s", sig.ManifestName, mf.name)
+	}
+
+	var roots *x509.CertPool
+	if opts.TrustedCABundle != "" {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(opts.TrustedCABundle)) {
+			return signer, fmt.Errorf("no valid certificates found in trusted CA bundle")
+		}
+	}
+	if err := sig.Verify(mf.content, roots); err != nil {
+		return signer, err
+	}
+
+	// The signature only covers the manifest, so the descriptor must be
+	// covered by the manifest. The other files are verified against the
+	// manifest as they are uploaded.
+	ovfDesc := files[".ovf"]
+	v, err := manifest.Verifier(ovfDesc.name)
+	if err != nil {
+		return signer, err
+	}
+	if _, err := io.Copy(io.Discard, v.Reader(bytes.NewReader(ovfDesc.content))); err != nil {
+		return signer, err
+	}
+	if err := v.Verify(); err != nil {
+		return signer, err
+	}
+	return signer, nil
+}
+
+func thumbprintSHA256(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hexSum := make([]string, len(sum))
+	for i, b := range sum {
+		hexSum[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexSum, ":")
+}
+
+// SignatureSchema returns the schema for the options that control the
+// validation of the signature of an OVF. All options are ForceNew.
+func SignatureSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"ovf_signature_policy": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Whether to validate the signature of the OVF/OVA. One of ignore, warn or require. Defaults to ignore.",
+			ValidateFunc: validation.StringInSlice(SignaturePolicies, false),
+			ForceNew:     true,
+		},
+		"ovf_trusted_ca_bundle": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "PEM encoded CA certificates the signing certificate of the OVF/OVA must chain to. The system roots are used if not set.",
+			ForceNew:    true,
+		},
+	}
+}
+
+// SignerSchema returns the schema for the computed details of the certificate
+// that signed an OVF.
+func SignerSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "The certificate that signed the OVF/OVA, if the signature was validated.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"subject": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The subject of the signing certificate.",
+				},
+				"issuer": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The issuer of the signing certificate.",
+				},
+				"serial_number": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The serial number of the signing certificate, in hexadecimal.",
+				},
+				"thumbprint": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The SHA-256 thumbprint of the signing certificate.",
+				},
+				"not_before": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The start of the validity period of the signing certificate, in RFC3339 format.",
+				},
+				"not_after": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The end of the validity period of the signing certificate, in RFC3339 format.",
+				},
+				"verified": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether the signature and certificate chain were validated.",
+				},
+			},
+		},
+	}
+}
+
+// FlattenSigner flattens the signer for SignerSchema. An empty list is
+// returned for a nil signer.
+func FlattenSigner(s *SignerInfo) []interface{} {
+	if s == nil {
+		return []interface{}{}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"subject":       s.Subject,
+			"issuer":        s.Issuer,
+			"serial_number": s.SerialNumber,
+			"thumbprint":    s.Thumbprint,
+			"not_before":    s.NotBefore.Format(time.RFC3339),
+			"not_after":     s.NotAfter.Format(time.RFC3339),
+			"verified":      s.Verified,
+		},
+	}
+}
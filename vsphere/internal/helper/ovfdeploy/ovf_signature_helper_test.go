@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testSigner struct {
+	caPEM   string
+	certPEM string
+	key     *rsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T, eku x509.ExtKeyUsage) *testSigner {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test Vendor"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{eku},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{
+		caPEM:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		key:     key,
+	}
+}
+
+// writeTestOvf writes a signed OVF to dir and returns the path of the
+// descriptor.
+func (s *testSigner) writeTestOvf(t *testing.T, dir string, descriptor string) string {
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("test.ovf", descriptor)
+
+	sum := sha256.Sum256([]byte("<Envelope/>"))
+	mf := fmt.Sprintf("SHA256(test.ovf)= %s\n", hex.EncodeToString(sum[:]))
+	write("test.mf", mf)
+
+	digest := sha256.Sum256([]byte(mf))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("test.cert", fmt.Sprintf("SHA256(test.mf)= %s\n%s", hex.EncodeToString(sig), s.certPEM))
+	return filepath.Join(dir, "test.ovf")
+}
+
+func TestValidateTemplate(t *testing.T) {
+	signer := newTestSigner(t, x509.ExtKeyUsageCodeSigning)
+	other := newTestSigner(t, x509.ExtKeyUsageCodeSigning)
+	serverAuth := newTestSigner(t, x509.ExtKeyUsageServerAuth)
+
+	signed := signer.writeTestOvf(t, t.TempDir(), "<Envelope/>")
+	notCodeSigning := serverAuth.writeTestOvf(t, t.TempDir(), "<Envelope/>")
+	tampered := signer.writeTestOvf(t, t.TempDir(), "<Envelope>tampered</Envelope>")
+	unsignedDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(unsignedDir, "test.ovf"), []byte("<Envelope/>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := filepath.Join(unsignedDir, "test.ovf")
+
+	cases := []struct {
+		name         string
+		opts         SignatureOptions
+		file         string
+		expectErr    bool
+		expectSigner bool
+		expectValid  bool
+	}{
+		{
+			name: "ignore",
+			opts: SignatureOptions{Policy: SignaturePolicyIgnore},
+			file: unsigned,
+		},
+		{
+			name:         "require trusted",
+			opts:         SignatureOptions{Policy: SignaturePolicyRequire, TrustedCABundle: signer.caPEM},
+			file:         signed,
+			expectSigner: true,
+			expectValid:  true,
+		},
+		{
+			name:      "require untrusted",
+			opts:      SignatureOptions{Policy: SignaturePolicyRequire, TrustedCABundle: other.caPEM},
+			file:      signed,
+			expectErr: true,
+		},
+		{
+			name:      "require certificate without code signing",
+			opts:      SignatureOptions{Policy: SignaturePolicyRequire, TrustedCABundle: serverAuth.caPEM},
+			file:      notCodeSigning,
+			expectErr: true,
+		},
+		{
+			name:      "require tampered descriptor",
+			opts:      SignatureOptions{Policy: SignaturePolicyRequire, TrustedCABundle: signer.caPEM},
+			file:      tampered,
+			expectErr: true,
+		},
+		{
+			name:      "require unsigned",
+			opts:      SignatureOptions{Policy: SignaturePolicyRequire},
+			file:      unsigned,
+			expectErr: true,
+		},
+		{
+			name:         "warn untrusted",
+			opts:         SignatureOptions{Policy: SignaturePolicyWarn, TrustedCABundle: other.caPEM},
+			file:         signed,
+			expectSigner: true,
+		},
+		{
+			name: "warn unsigned",
+			opts: SignatureOptions{Policy: SignaturePolicyWarn},
+			file: unsigned,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vt, err := ValidateTemplate(tc.opts, NewSource(tc.file, true, false), false)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			s := vt.Signer
+			if tc.expectValid {
+				if vt.Descriptor != "<Envelope/>" {
+					t.Fatalf("expected the validated descriptor, got %q", vt.Descriptor)
+				}
+				if _, ok := vt.Manifest["test.ovf"]; !ok {
+					t.Fatalf("expected the validated manifest, got %v", vt.Manifest)
+				}
+			}
+			if tc.expectSigner != (s != nil) {
+				t.Fatalf("expected signer %t, got %v", tc.expectSigner, s)
+			}
+			if s == nil {
+				return
+			}
+			if s.Subject != "CN=Test Vendor" {
+				t.Fatalf("unexpected subject %q", s.Subject)
+			}
+			if s.Verified != tc.expectValid {
+				t.Fatalf("expected verified %t, got %t", tc.expectValid, s.Verified)
+			}
+		})
+	}
+}
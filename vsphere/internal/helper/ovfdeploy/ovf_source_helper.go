@@ -0,0 +1,370 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Source reads the files of an OVF or OVA template. The files support
+// seeking, so that the entries of an OVA can be skipped without reading them
+// and an interrupted read can be resumed from the offset it reached.
+type Source interface {
+	// Open returns the file at name, relative to the directory of the OVF
+	// descriptor, or the descriptor or OVA itself if name is empty. Opening a
+	// file that does not exist fails with an error matching os.ErrNotExist,
+	// at the latest on the first read.
+	Open(name string) (io.ReadSeekCloser, error)
+
+	// Name returns the file name of the OVF descriptor or OVA.
+	Name() string
+
+	// String returns the location of the template, for messages.
+	String() string
+}
+
+// NewSource returns the Source for the OVF descriptor or OVA at filePath,
+// which is a local path or a remote URL.
+func NewSource(filePath string, fromLocal bool, allowUnverifiedSSL bool) Source {
+	if fromLocal {
+		return localSource(filePath)
+	}
+	return &urlSource{url: filePath, client: getClient(allowUnverifiedSSL)}
+}
+
+// PrepareSource returns a Source from which the files of the template in src
+// can be read individually. A remote OVA served without support for range
+// requests can only be read from its start, so it is downloaded once into a
+// temporary directory, which the returned function removes.
+func PrepareSource(src Source, ova bool) (Source, func(), error) {
+	us, ok := src.(*urlSource)
+	if !ok || !ova || us.supportsRanges() {
+		return src, func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "terraform-provider-vsphere-ovf-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("[WARN] Could not remove %s: %s", dir, err)
+		}
+	}
+	localPath := filepath.Join(dir, us.Name())
+	log.Printf("[DEBUG] %s does not support range requests, downloading it to %s", us, localPath)
+	f, err := us.Open("")
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if err := writeCacheFile(f, localPath); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("error downloading %s: %s", us, err)
+	}
+	return localSource(localPath), cleanup, nil
+}
+
+// OpenFile returns the named file of the template in src. If ova is set, the
+// file is read from the OVA, skipping over the entries that precede it. A read
+// that is resumed after a failure stops waiting when ctx is canceled.
+func OpenFile(ctx context.Context, src Source, ova bool, name string) (io.ReadCloser, error) {
+	if !ova {
+		f, err := src.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return withContext(ctx, f), nil
+	}
+	f, err := src.Open("")
+	if err != nil {
+		return nil, err
+	}
+	f = withContext(ctx, f)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if hdr.Name == name {
+			return ovaEntryReader{Reader: tr, Closer: f}, nil
+		}
+	}
+	_ = f.Close()
+	return nil, fmt.Errorf("%s not found inside ova %s", name, src)
+}
+
+// ovaEntryReader reads a single entry of an OVA and closes the OVA when it is
+// closed.
+type ovaEntryReader struct {
+	io.Reader
+	io.Closer
+}
+
+// fileSize returns the size of f and rewinds it.
+func fileSize(f io.Seeker) (int64, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// localSource is a template on the local filesystem.
+type localSource string
+
+func (s localSource) Open(name string) (io.ReadSeekCloser, error) {
+	p := string(s)
+	if name != "" {
+		p = filepath.Join(filepath.Dir(p), filepath.FromSlash(name))
+	}
+	return os.Open(p)
+}
+
+func (s localSource) Name() string {
+	return filepath.Base(string(s))
+}
+
+func (s localSource) String() string {
+	return string(s)
+}
+
+// urlSource is a template at a remote URL.
+type urlSource struct {
+	url    string
+	client *http.Client
+}
+
+func (s *urlSource) Open(name string) (io.ReadSeekCloser, error) {
+	u := s.url
+	if name != "" {
+		u = u[:strings.LastIndex(u, "/")+1] + name
+	}
+	return newResumableFile(u, &httpFile{client: s.client, url: u, length: -1}), nil
+}
+
+func (s *urlSource) Name() string {
+	return path.Base(s.url)
+}
+
+func (s *urlSource) String() string {
+	return s.url
+}
+
+// supportsRanges returns true if the server of the template answers range
+// requests for it.
+func (s *urlSource) supportsRanges() bool {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusPartialContent
+}
+
+// errFileChanged is returned when a remote file changes while it is read.
+// Resuming the read does not help, so it is not retried.
+var errFileChanged = errors.New("the file changed while it was being read")
+
+// httpFile reads a remote file from any offset, with range requests. The
+// connection is opened on the first read after a seek or a close, so a closed
+// httpFile can be read again from the offset it reached.
+type httpFile struct {
+	client *http.Client
+	url    string
+
+	body       io.ReadCloser
+	bodyOffset int64
+	offset     int64
+	length     int64
+
+	// The ETag or Last-Modified date of the first response, which subsequent
+	// requests must match so that the file does not change while it is read.
+	validator string
+}
+
+func (f *httpFile) Read(p []byte) (int, error) {
+	if f.body != nil && f.bodyOffset != f.offset {
+		_ = f.Close()
+	}
+	if f.body == nil {
+		if f.length >= 0 && f.offset >= f.length {
+			return 0, io.EOF
+		}
+		if err := f.get(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	f.bodyOffset += int64(n)
+	return n, err
+}
+
+func (f *httpFile) get() error {
+	req, err := http.NewRequest(http.MethodGet, f.url, nil)
+	if err != nil {
+		return err
+	}
+	if f.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", f.offset))
+		if f.validator != "" {
+			req.Header.Set("If-Range", f.validator)
+		}
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if f.offset > 0 {
+			_ = resp.Body.Close()
+			return fmt.Errorf("%s cannot be read from offset %d: the server does not support range requests, or %w", f.url, f.offset, errFileChanged)
+		}
+		f.length = resp.ContentLength
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		_ = resp.Body.Close()
+		f.length = f.offset
+		return io.EOF
+	case http.StatusNotFound, http.StatusForbidden:
+		_ = resp.Body.Close()
+		return fmt.Errorf("%s: %w", f.url, os.ErrNotExist)
+	default:
+		_ = resp.Body.Close()
+		return fmt.Errorf("got status %d while getting the file from remote url %s ", resp.StatusCode, f.url)
+	}
+
+	validator := resp.Header.Get("ETag")
+	if validator == "" {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if f.validator == "" {
+		f.validator = validator
+	} else if validator != "" && validator != f.validator {
+		_ = resp.Body.Close()
+		return fmt.Errorf("%s: %w", f.url, errFileChanged)
+	}
+	f.body = resp.Body
+	f.bodyOffset = f.offset
+	return nil
+}
+
+func (f *httpFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		if f.length < 0 {
+			resp, err := f.client.Head(f.url)
+			if err != nil {
+				return 0, err
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || resp.ContentLength < 0 {
+				return 0, fmt.Errorf("cannot determine the size of %s", f.url)
+			}
+			f.length = resp.ContentLength
+		}
+		offset += f.length
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *httpFile) Close() error {
+	if f.body == nil {
+		return nil
+	}
+	err := f.body.Close()
+	f.body = nil
+	return err
+}
+
+// resumableFile resumes a read of a remote file that failed, ie: because the
+// connection dropped, from the offset it reached instead of failing the
+// transfer. The file must reopen its connection at the current offset on the
+// first read after it is closed.
+type resumableFile struct {
+	io.ReadSeekCloser
+	ctx     context.Context
+	name    string
+	retries int
+	backoff time.Duration
+}
+
+func newResumableFile(name string, f io.ReadSeekCloser) *resumableFile {
+	return &resumableFile{
+		ReadSeekCloser: f,
+		ctx:            context.Background(),
+		name:           name,
+		retries:        DefaultUploadRetries,
+		backoff:        defaultUploadRetryBackoff,
+	}
+}
+
+func (f *resumableFile) Read(p []byte) (int, error) {
+	backoff := f.backoff
+	for attempt := 0; ; attempt++ {
+		n, err := f.ReadSeekCloser.Read(p)
+		if err == nil || err == io.EOF || errors.Is(err, os.ErrNotExist) || errors.Is(err, errFileChanged) || attempt >= f.retries {
+			return n, err
+		}
+		log.Printf("[DEBUG] Reading %s failed, resuming (%d/%d): %s", f.name, attempt+1, f.retries, err)
+		_ = f.ReadSeekCloser.Close()
+		if n > 0 {
+			return n, nil
+		}
+		select {
+		case <-time.After(backoff):
+		case <-f.ctx.Done():
+			return 0, err
+		}
+		backoff *= 2
+		if backoff > maxUploadRetryBackoff {
+			backoff = maxUploadRetryBackoff
+		}
+	}
+}
+
+// withContext binds the resumed reads of f to ctx, if f is a resumableFile.
+func withContext(ctx context.Context, f io.ReadSeekCloser) io.ReadSeekCloser {
+	if rf, ok := f.(*resumableFile); ok {
+		rf.ctx = ctx
+	}
+	return f
+}
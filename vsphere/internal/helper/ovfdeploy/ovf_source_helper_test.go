@@ -0,0 +1,259 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testOva returns an OVA with a large first disk, followed by a small one.
+func testOva(t *testing.T) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{
+		{"test.ovf", []byte(testCacheDescriptor)},
+		{"disk1.vmdk", bytes.Repeat([]byte("a"), 1<<20)},
+		{"disk2.vmdk", []byte("disk2")},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHttpFileResume(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	var requests int32
+	var resumedFrom atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Drop the connection half way through the first response.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write([]byte(content[:len(content)/2]))
+			return
+		}
+		resumedFrom.Store(r.Header.Get("Range"))
+		http.ServeContent(w, r, "disk.vmdk", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	f := newResumableFile("disk.vmdk", &httpFile{client: srv.Client(), url: srv.URL + "/disk.vmdk", length: -1})
+	f.backoff = time.Millisecond
+	defer func() {
+		_ = f.Close()
+	}()
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content {
+		t.Fatalf("expected %d bytes of content, got %d", len(content), len(got))
+	}
+	if r, _ := resumedFrom.Load().(string); r == "" || r == "bytes=0-" {
+		t.Fatalf("expected the read to resume from the offset it reached, got range %q", r)
+	}
+}
+
+func TestHttpFileChanged(t *testing.T) {
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag.Load().(string))
+		http.ServeContent(w, r, "disk.vmdk", time.Time{}, strings.NewReader("0123456789"))
+	}))
+	defer srv.Close()
+
+	f := &httpFile{client: srv.Client(), url: srv.URL + "/disk.vmdk", length: -1}
+	if _, err := f.Read(make([]byte, 4)); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	etag.Store(`"v2"`)
+	if _, err := f.Read(make([]byte, 4)); !errors.Is(err, errFileChanged) {
+		t.Fatalf("expected an error reading a file that changed, got %v", err)
+	}
+}
+
+// testFailingFile fails every read.
+type testFailingFile struct {
+	io.ReadSeekCloser
+	err   error
+	reads int
+}
+
+func (f *testFailingFile) Read([]byte) (int, error) {
+	f.reads++
+	return 0, f.err
+}
+
+func (f *testFailingFile) Close() error {
+	return nil
+}
+
+func TestResumableFileNotResumed(t *testing.T) {
+	f := &testFailingFile{err: fmt.Errorf("disk.vmdk: %w", errFileChanged)}
+	rf := newResumableFile("disk.vmdk", f)
+	rf.backoff = time.Millisecond
+	if _, err := rf.Read(make([]byte, 4)); !errors.Is(err, errFileChanged) || f.reads != 1 {
+		t.Fatalf("expected a changed file not to be resumed, got %d reads and %v", f.reads, err)
+	}
+
+	f = &testFailingFile{err: errors.New("connection reset")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rf = withContext(ctx, newResumableFile("disk.vmdk", f)).(*resumableFile)
+	rf.backoff = time.Hour
+	if _, err := rf.Read(make([]byte, 4)); err == nil || f.reads != 1 {
+		t.Fatalf("expected the read to stop when the context is canceled, got %d reads and %v", f.reads, err)
+	}
+}
+
+func TestHttpFileNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	src := NewSource(srv.URL+"/test.ovf", false, false)
+	f, err := src.Open("test.mf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := io.ReadAll(f); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestOpenFileOva(t *testing.T) {
+	ova := testOva(t)
+	var skipped int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err == nil && start > 1<<20 {
+			atomic.StoreInt32(&skipped, 1)
+		}
+		http.ServeContent(w, r, "test.ova", time.Time{}, bytes.NewReader(ova))
+	}))
+	defer srv.Close()
+
+	src := NewSource(srv.URL+"/test.ova", false, false)
+	f, err := OpenFile(context.Background(), src, true, "disk2.vmdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "disk2" {
+		t.Fatalf("expected disk2, got %q", got)
+	}
+	if atomic.LoadInt32(&skipped) == 0 {
+		t.Fatal("expected disk1.vmdk to be skipped with a range request")
+	}
+
+	if _, err := OpenFile(context.Background(), src, true, "disk3.vmdk"); err == nil {
+		t.Fatal("expected an error for a file that is not in the ova")
+	}
+}
+
+func TestPrepareSource(t *testing.T) {
+	ova := testOva(t)
+	var gets int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&gets, 1)
+		if r.URL.Path == "/ranges/test.ova" {
+			http.ServeContent(w, r, "test.ova", time.Time{}, bytes.NewReader(ova))
+			return
+		}
+		_, _ = w.Write(ova)
+	}))
+	defer srv.Close()
+
+	src := NewSource(srv.URL+"/ranges/test.ova", false, false)
+	prepared, cleanup, err := PrepareSource(src, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if prepared != src {
+		t.Fatal("expected a server with range support to be read directly")
+	}
+
+	atomic.StoreInt32(&gets, 0)
+	prepared, cleanup, err = PrepareSource(NewSource(srv.URL+"/no-ranges/test.ova", false, false), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if _, ok := prepared.(localSource); !ok {
+		t.Fatalf("expected a local copy, got %s", prepared)
+	}
+	for _, name := range []string{"disk1.vmdk", "disk2.vmdk"} {
+		f, err := OpenFile(context.Background(), prepared, true, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+	}
+	if n := atomic.LoadInt32(&gets); n != 2 {
+		t.Fatalf("expected the ova to be downloaded once after the range probe, got %d requests", n)
+	}
+}
+
+func TestGetSourceManifestOva(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{"test.ovf": testCacheDescriptor, "test.mf": testManifest} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	p := t.TempDir() + "/test.ova"
+	if err := os.WriteFile(p, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := GetSourceManifest(NewSource(p, true, false), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["disk1.vmdk"]; !ok {
+		t.Fatalf("expected disk1.vmdk in the manifest, got %v", m)
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+const (
+	// DefaultUploadParallelism is the default number of files of an OVF or OVA
+	// that are uploaded concurrently.
+	DefaultUploadParallelism = 4
+
+	// DefaultUploadRetries is the default number of times the upload of a
+	// single file is retried before the deployment fails.
+	DefaultUploadRetries = 3
+
+	// defaultUploadRetryBackoff is the delay before the first retry of a
+	// failed upload. The delay doubles with every subsequent retry.
+	defaultUploadRetryBackoff = 5 * time.Second
+
+	// maxUploadRetryBackoff caps the delay between retries of a failed upload.
+	maxUploadRetryBackoff = 2 * time.Minute
+)
+
+// UploadOptions controls how the files of an OVF or OVA are uploaded.
+type UploadOptions struct {
+	// The number of files that are uploaded concurrently.
+	Parallelism int
+
+	// The number of times the upload of a single file is retried.
+	Retries int
+
+	// The delay before the first retry. Defaults to 5 seconds.
+	RetryBackoff time.Duration
+}
+
+// DefaultUploadOptions returns the default upload options.
+func DefaultUploadOptions() UploadOptions {
+	return UploadOptions{
+		Parallelism:  DefaultUploadParallelism,
+		Retries:      DefaultUploadRetries,
+		RetryBackoff: defaultUploadRetryBackoff,
+	}
+}
+
+func (o UploadOptions) normalize() UploadOptions {
+	if o.Parallelism < 1 {
+		o.Parallelism = 1
+	}
+	if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = defaultUploadRetryBackoff
+	}
+	return o
+}
+
+// Parallel calls fn for every index in [0, n), running at most Parallelism
+// calls concurrently. The context passed to fn is canceled as soon as one of
+// the calls fails, and the first error is returned.
+func (o UploadOptions) Parallel(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	o = o.normalize()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	sem := make(chan struct{}, o.Parallelism)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// PermanentError is an error that Retry does not retry, such as a file that
+// does not match its checksum in the manifest.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Retry calls fn until it succeeds, the retries are exhausted or the context
+// is canceled, with an exponential backoff between attempts. An error that
+// wraps a PermanentError is returned without retrying. name is only used for
+// logging.
+func (o UploadOptions) Retry(ctx context.Context, name string, fn func(attempt int) error) error {
+	o = o.normalize()
+	backoff := o.RetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		var permanent *PermanentError
+		if errors.As(err, &permanent) {
+			return err
+		}
+		if attempt >= o.Retries {
+			break
+		}
+		log.Printf("[DEBUG] Upload of %s failed, retrying in %s (%d/%d): %s", name, backoff, attempt+1, o.Retries, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		if backoff > maxUploadRetryBackoff {
+			backoff = maxUploadRetryBackoff
+		}
+	}
+	if o.Retries > 0 {
+		return fmt.Errorf("giving up after %d attempts: %s", o.Retries+1, err)
+	}
+	return err
+}
+
+// Manifest maps the names of the files of an OVF to their checksums, as read
+// from the OVF manifest (.mf) file.
+type Manifest map[string]*library.Checksum
+
+// ReadManifest parses the contents of an OVF manifest file.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	m, err := library.ReadManifest(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ovf manifest: %s", err)
+	}
+	return m, nil
+}
+
+// GetManifest reads the manifest of the OVF or OVA at filePath. For an OVF,
+// the manifest is expected next to the descriptor with an .mf extension. nil
+// is returned if there is no manifest.
+func GetManifest(filePath string, deployOva bool, fromLocal bool, allowUnverifiedSSL bool) (Manifest, error) {
+	return GetSourceManifest(NewSource(filePath, fromLocal, allowUnverifiedSSL), deployOva)
+}
+
+// GetSourceManifest reads the manifest of the template in src, or returns nil
+// if it has none.
+func GetSourceManifest(src Source, deployOva bool) (Manifest, error) {
+	files, err := getOvfFiles(src, deployOva, ".mf")
+	if err != nil {
+		return nil, err
+	}
+	mf, ok := files[".mf"]
+	if !ok {
+		return nil, nil
+	}
+	return ReadManifest(bytes.NewReader(mf.content))
+}
+
+// ovfFile is a file of an OVF or OVA, named as it is referenced in the
+// manifest.
+type ovfFile struct {
+	name    string
+	content []byte
+}
+
+// getOvfFiles reads the files of the OVF or OVA in src with the supplied
+// extensions, keyed by extension. For an OVA, the first file in the archive
+// with each extension is returned. For an OVF, the files are expected next to
+// the descriptor with the same base name, and the ".ovf" extension returns the
+// descriptor itself. Files that do not exist are omitted.
+func getOvfFiles(src Source, deployOva bool, exts ...string) (map[string]ovfFile, error) {
+	files := make(map[string]ovfFile)
+	if deployOva {
+		f, err := src.Open("")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		ovaReader := tar.NewReader(f)
+		for len(files) < len(exts) {
+			fileHdr, err := ovaReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			ext := path.Ext(fileHdr.Name)
+			if _, ok := files[ext]; ok || !containsString(exts, ext) {
+				continue
+			}
+			content, err := io.ReadAll(ovaReader)
+			if err != nil {
+				return nil, err
+			}
+			files[ext] = ovfFile{name: path.Base(fileHdr.Name), content: content}
+		}
+		return files, nil
+	}
+
+	base := src.Name()
+	for _, ext := range exts {
+		name := strings.TrimSuffix(base, path.Ext(base)) + ext
+		content, err := readOvfFile(src, name)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		files[ext] = ovfFile{name: name, content: content}
+	}
+	return files, nil
+}
+
+// readOvfFile reads the named file of the OVF in src. nil is returned if the
+// file does not exist.
+func readOvfFile(src Source, name string) ([]byte, error) {
+	f, err := src.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	content, err := io.ReadAll(f)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return content, err
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier returns a ChecksumVerifier for the named file. nil, which
+// verifies nothing, is returned if there is no manifest. A file that is not
+// listed in the manifest cannot be verified and is an error.
+func (m Manifest) Verifier(name string) (*ChecksumVerifier, error) {
+	if m == nil {
+		return nil, nil
+	}
+	sum, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("file %s is not listed in the ovf manifest", name)
+	}
+	var h hash.Hash
+	switch strings.ToUpper(sum.Algorithm) {
+	case "SHA1":
+		h = sha1.New()
+	case "SHA256":
+		h = sha256.New()
+	case "SHA512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q for file %s in ovf manifest", sum.Algorithm, name)
+	}
+	return &ChecksumVerifier{name: name, expected: sum, hash: h}, nil
+}
+
+// ChecksumVerifier computes the checksum of a file as it is read and compares
+// it to the checksum in the manifest. A nil ChecksumVerifier verifies nothing.
+type ChecksumVerifier struct {
+	name     string
+	expected *library.Checksum
+	hash     hash.Hash
+}
+
+// Reader resets the verifier and returns a reader that hashes everything read
+// from r.
+func (v *ChecksumVerifier) Reader(r io.Reader) io.Reader {
+	if v == nil {
+		return r
+	}
+	v.hash.Reset()
+	return io.TeeReader(r, v.hash)
+}
+
+// Checksum returns the checksum from the manifest, for services that verify
+// the checksum on their side.
+func (v *ChecksumVerifier) Checksum() *library.Checksum {
+	if v == nil {
+		return nil
+	}
+	return v.expected
+}
+
+// Verify compares the checksum of the data read so far to the manifest. A
+// mismatch is reported as a PermanentError, as reading the same file again
+// does not change its checksum.
+func (v *ChecksumVerifier) Verify() error {
+	if v == nil {
+		return nil
+	}
+	actual := hex.EncodeToString(v.hash.Sum(nil))
+	if !strings.EqualFold(actual, v.expected.Checksum) {
+		return &PermanentError{Err: fmt.Errorf("%s checksum mismatch for %s: expected %s, got %s", v.expected.Algorithm, v.name, v.expected.Checksum, actual)}
+	}
+	log.Printf("[DEBUG] Verified %s checksum of %s", v.expected.Algorithm, v.name)
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfdeploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testManifest = `SHA256(disk1.vmdk)= 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824
+SHA1(disk2.vmdk)= AAF4C61DDCC5E8A2DABEDE0F3B482CD9AEA9434D
+MD5(disk3.vmdk)= 5d41402abc4b2a76b9719d911017c592
+`
+
+func TestManifestVerifier(t *testing.T) {
+	m, err := ReadManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name      string
+		file      string
+		data      string
+		expectErr bool
+	}{
+		{name: "sha256", file: "disk1.vmdk", data: "hello"},
+		{name: "sha1 upper case", file: "disk2.vmdk", data: "hello"},
+		{name: "mismatch", file: "disk1.vmdk", data: "goodbye", expectErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := m.Verifier(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.Copy(io.Discard, v.Reader(strings.NewReader(tc.data))); err != nil {
+				t.Fatal(err)
+			}
+			err = v.Verify()
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+
+	if _, err := m.Verifier("disk3.vmdk"); err == nil {
+		t.Fatal("expected error for unsupported algorithm")
+	}
+	if _, err := m.Verifier("disk4.vmdk"); err == nil {
+		t.Fatal("expected error for a file that is not in the manifest")
+	}
+	if v, err := Manifest(nil).Verifier("disk4.vmdk"); v != nil || err != nil {
+		t.Fatalf("expected no verification without a manifest, got %v, %v", v, err)
+	}
+}
+
+func TestUploadOptionsRetry(t *testing.T) {
+	opts := UploadOptions{Retries: 2, RetryBackoff: time.Millisecond}
+
+	attempts := 0
+	err := opts.Retry(context.Background(), "disk1.vmdk", func(int) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("expected success after 3 attempts, got %d attempts and %v", attempts, err)
+	}
+
+	attempts = 0
+	err = opts.Retry(context.Background(), "disk1.vmdk", func(int) error {
+		attempts++
+		return errors.New("permanent")
+	})
+	if err == nil || attempts != 3 {
+		t.Fatalf("expected failure after 3 attempts, got %d attempts and %v", attempts, err)
+	}
+
+	attempts = 0
+	err = opts.Retry(context.Background(), "disk1.vmdk", func(int) error {
+		attempts++
+		return fmt.Errorf("error while uploading: %w", &PermanentError{Err: errors.New("checksum mismatch")})
+	})
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected failure without retries, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestUploadOptionsParallel(t *testing.T) {
+	opts := UploadOptions{Parallelism: 2}
+
+	var running, maxRunning, calls int32
+	err := opts.Parallel(context.Background(), 6, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 6 {
+		t.Fatalf("expected 6 calls, got %d", calls)
+	}
+	if maxRunning > 2 {
+		t.Fatalf("expected at most 2 concurrent calls, got %d", maxRunning)
+	}
+
+	err = opts.Parallel(context.Background(), 6, func(ctx context.Context, i int) error {
+		if i == 1 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Fatalf("expected error from failed call, got %v", err)
+	}
+}
@@ -96,6 +96,14 @@ func BoolNilFalse(v *bool) bool {
 	return *v
 }
 
+// StringNilEmpty returns an empty string for a nil string pointer
+func StringNilEmpty(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
 // BoolPtr makes a *bool out of the value passed in through v.
 //
 // vSphere uses nil values in bools to omit values in the SOAP XML request, and
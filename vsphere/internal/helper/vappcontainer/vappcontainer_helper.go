@@ -6,11 +6,13 @@ package vappcontainer
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/provider"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -113,3 +115,59 @@ func HasChildren(vc *object.VirtualApp) (bool, error) {
 	}
 	return false, nil
 }
+
+// PowerOn powers on a VirtualApp, starting its entities in the configured
+// start order. The timeout bounds the whole start sequence, including the
+// start delays of the entities, and is never shorter than the default API
+// timeout.
+func PowerOn(vc *object.VirtualApp, timeout time.Duration) error {
+	log.Printf("[DEBUG] Powering on vApp container %q", vc.InventoryPath)
+	if timeout < provider.DefaultAPITimeout {
+		timeout = provider.DefaultAPITimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	task, err := vc.PowerOn(ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// PowerOff powers off a VirtualApp, stopping its entities in the configured
+// stop order. Nothing is done if the vApp is already powered off.
+func PowerOff(vc *object.VirtualApp) error {
+	props, err := Properties(vc)
+	if err != nil {
+		return err
+	}
+	if props.Summary != nil {
+		if summary, ok := props.Summary.(*types.VirtualAppSummary); ok && summary.VAppState == types.VirtualAppVAppStateStopped {
+			return nil
+		}
+	}
+	log.Printf("[DEBUG] Powering off vApp container %q", vc.InventoryPath)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	task, err := vc.PowerOff(ctx, true)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// VirtualMachines returns the name and configuration of the virtual machines
+// that are direct children of the supplied VirtualApp.
+func VirtualMachines(client *govmomi.Client, props *mo.VirtualApp) ([]mo.VirtualMachine, error) {
+	if len(props.Vm) < 1 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	var vms []mo.VirtualMachine
+	pc := property.DefaultCollector(client.Client)
+	if err := pc.Retrieve(ctx, props.Vm, []string{"name", "config.uuid"}, &vms); err != nil {
+		return nil, err
+	}
+	return vms, nil
+}
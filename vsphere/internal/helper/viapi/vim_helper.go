@@ -83,6 +83,16 @@ func IsAnyNotFoundError(err error) bool {
 	return false
 }
 
+// IsInvalidNameError checks an error to see if it's of the InvalidName type.
+func IsInvalidNameError(err error) bool {
+	if f, ok := vimSoapFault(err); ok {
+		if _, ok := f.(types.InvalidName); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // IsResourceInUseError checks an error to see if it's of the
 // ResourceInUse type.
 func IsResourceInUseError(err error) bool {
@@ -0,0 +1,379 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualmachine
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The kinds of vApp properties, as used in the type of a VAppPropertyInfo.
+const (
+	VAppPropertyKindString     = "string"
+	VAppPropertyKindPassword   = "password"
+	VAppPropertyKindInt        = "int"
+	VAppPropertyKindReal       = "real"
+	VAppPropertyKindBoolean    = "boolean"
+	VAppPropertyKindIP         = "ip"
+	VAppPropertyKindExpression = "expression"
+)
+
+var (
+	// vAppPropertyTypeRe matches the type of a VAppPropertyInfo with an
+	// optional range, ie: "int(1..100)" or "string(..64)".
+	vAppPropertyTypeRe = regexp.MustCompile(`^(\w+)(?:\((-?[0-9.]*)\.\.(-?[0-9.]*)\))?$`)
+
+	// vAppPropertyEnumRe matches the type of a VAppPropertyInfo that is an
+	// enumeration, ie: `string["small", "large"]`.
+	vAppPropertyEnumRe = regexp.MustCompile(`^string\[(.*)\]$`)
+
+	ovfQualifierMinLenRe   = regexp.MustCompile(`MinLen\(\s*(\d+)\s*\)`)
+	ovfQualifierMaxLenRe   = regexp.MustCompile(`MaxLen\(\s*(\d+)\s*\)`)
+	ovfQualifierMinValueRe = regexp.MustCompile(`MinValue\(\s*(-?[0-9.]+)\s*\)`)
+	ovfQualifierMaxValueRe = regexp.MustCompile(`MaxValue\(\s*(-?[0-9.]+)\s*\)`)
+	ovfQualifierValueMapRe = regexp.MustCompile(`ValueMap\{([^}]*)\}`)
+)
+
+// ovfIntRanges are the ranges of the integer types of OVF properties. 64-bit
+// types are only bounded on the side that fits in a float64 exactly.
+var ovfIntRanges = map[string][2]*float64{
+	"uint8":  {float64Ptr(0), float64Ptr(math.MaxUint8)},
+	"sint8":  {float64Ptr(math.MinInt8), float64Ptr(math.MaxInt8)},
+	"uint16": {float64Ptr(0), float64Ptr(math.MaxUint16)},
+	"sint16": {float64Ptr(math.MinInt16), float64Ptr(math.MaxInt16)},
+	"uint32": {float64Ptr(0), float64Ptr(math.MaxUint32)},
+	"sint32": {float64Ptr(math.MinInt32), float64Ptr(math.MaxInt32)},
+	"uint64": {float64Ptr(0), nil},
+	"sint64": {nil, nil},
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+// VAppPropertyQualifiedID returns the qualified ID of a vApp property in the
+// form class.key.instance, as used in the OVF environment. The class and the
+// instance are omitted if the product section of the property has none.
+func VAppPropertyQualifiedID(classID, id, instanceID string) string {
+	parts := []string{id}
+	if classID != "" {
+		parts = append([]string{classID}, parts...)
+	}
+	if instanceID != "" {
+		parts = append(parts, instanceID)
+	}
+	return strings.Join(parts, ".")
+}
+
+// VAppPropertyDefinition describes the values that a vApp property accepts,
+// as defined either by the VAppConfig of a virtual machine or by the product
+// section of an OVF descriptor.
+type VAppPropertyDefinition struct {
+	// The ID of the property, as used in vapp.properties.
+	ID string
+
+	// The ID of the property qualified with the class and instance of its
+	// product section, which is also accepted in vapp.properties. This is the
+	// same as ID if the product section has no class or instance.
+	QualifiedID string
+
+	// One of the VAppPropertyKind constants. Values of other kinds are not
+	// validated.
+	Kind string
+
+	// The range of the property. For numbers, this is the range of the value,
+	// and for strings and passwords the range of its length.
+	Min *float64
+	Max *float64
+
+	// The values the property is restricted to, if any.
+	Enum []string
+
+	Password         bool
+	UserConfigurable bool
+	DefaultValue     string
+
+	// The current value of the property on a virtual machine, if any. A valid
+	// current value satisfies a required property that is not set.
+	Value string
+}
+
+// VAppPropertyDefinitionFromInfo returns the definition of a property in the
+// VAppConfig of a virtual machine. A property of a type that is not
+// recognized is treated as a string without restrictions, so that its value
+// is not validated.
+func VAppPropertyDefinitionFromInfo(p types.VAppPropertyInfo) *VAppPropertyDefinition {
+	def := &VAppPropertyDefinition{
+		ID:               p.Id,
+		QualifiedID:      VAppPropertyQualifiedID(p.ClassId, p.Id, p.InstanceId),
+		UserConfigurable: p.UserConfigurable != nil && *p.UserConfigurable,
+		DefaultValue:     p.DefaultValue,
+		Value:            p.Value,
+	}
+	t := strings.TrimSpace(p.Type)
+	switch {
+	case strings.HasPrefix(t, "ip:") || t == VAppPropertyKindIP:
+		// The network name after the colon is informative only.
+		def.Kind = VAppPropertyKindIP
+	case vAppPropertyEnumRe.MatchString(t):
+		def.Kind = VAppPropertyKindString
+		def.Enum = parseVAppPropertyEnum(vAppPropertyEnumRe.FindStringSubmatch(t)[1])
+	default:
+		m := vAppPropertyTypeRe.FindStringSubmatch(t)
+		var minErr, maxErr error
+		if m != nil {
+			def.Min, minErr = parseVAppPropertyBound(m[2])
+			def.Max, maxErr = parseVAppPropertyBound(m[3])
+		}
+		if m == nil || minErr != nil || maxErr != nil || !isVAppPropertyKind(m[1]) {
+			log.Printf("[DEBUG] Unsupported type %q for vApp property %s, not validating its value", p.Type, p.Id)
+			def.Kind = VAppPropertyKindString
+			def.Min, def.Max = nil, nil
+			return def
+		}
+		def.Kind = m[1]
+	}
+	def.Password = def.Kind == VAppPropertyKindPassword
+	return def
+}
+
+// isVAppPropertyKind returns true if kind is one of the VAppPropertyKind
+// constants.
+func isVAppPropertyKind(kind string) bool {
+	switch kind {
+	case VAppPropertyKindString, VAppPropertyKindPassword, VAppPropertyKindInt, VAppPropertyKindReal,
+		VAppPropertyKindBoolean, VAppPropertyKindIP, VAppPropertyKindExpression:
+		return true
+	}
+	return false
+}
+
+// VAppPropertyDefinitionFromOvf returns the definition of a property in the
+// supplied product section of an OVF descriptor, taking into account its
+// qualifiers.
+func VAppPropertyDefinitionFromOvf(section ovf.ProductSection, p ovf.Property) (*VAppPropertyDefinition, error) {
+	var classID, instanceID string
+	if section.Class != nil {
+		classID = *section.Class
+	}
+	if section.Instance != nil {
+		instanceID = *section.Instance
+	}
+	def := &VAppPropertyDefinition{
+		ID:               p.Key,
+		QualifiedID:      VAppPropertyQualifiedID(classID, p.Key, instanceID),
+		UserConfigurable: p.UserConfigurable != nil && *p.UserConfigurable,
+		Password:         p.Password != nil && *p.Password,
+	}
+	if p.Default != nil {
+		def.DefaultValue = *p.Default
+	}
+	r, isInt := ovfIntRanges[p.Type]
+	switch {
+	case isInt:
+		def.Kind = VAppPropertyKindInt
+		def.Min, def.Max = r[0], r[1]
+	case p.Type == "string" && def.Password:
+		def.Kind = VAppPropertyKindPassword
+	case p.Type == "string":
+		def.Kind = VAppPropertyKindString
+	case p.Type == "boolean":
+		def.Kind = VAppPropertyKindBoolean
+	case p.Type == "real32" || p.Type == "real64":
+		def.Kind = VAppPropertyKindReal
+	default:
+		log.Printf("[DEBUG] Unsupported type %q for ovf property %s, not validating its value", p.Type, p.Key)
+		def.Kind = VAppPropertyKindString
+		return def, nil
+	}
+	if p.Qualifiers == nil {
+		return def, nil
+	}
+
+	q := *p.Qualifiers
+	parse := func(re *regexp.Regexp) (*float64, error) {
+		m := re.FindStringSubmatch(q)
+		if m == nil {
+			return nil, nil
+		}
+		return parseVAppPropertyBound(m[1])
+	}
+	var err error
+	var minQ, maxQ *float64
+	switch def.Kind {
+	case VAppPropertyKindString, VAppPropertyKindPassword:
+		if minQ, err = parse(ovfQualifierMinLenRe); err == nil {
+			maxQ, err = parse(ovfQualifierMaxLenRe)
+		}
+	default:
+		if minQ, err = parse(ovfQualifierMinValueRe); err == nil {
+			maxQ, err = parse(ovfQualifierMaxValueRe)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid qualifiers %q for ovf property %s: %s", q, p.Key, err)
+	}
+	if minQ != nil {
+		def.Min = minQ
+	}
+	if maxQ != nil {
+		def.Max = maxQ
+	}
+	if m := ovfQualifierValueMapRe.FindStringSubmatch(q); m != nil {
+		def.Enum = parseVAppPropertyEnum(m[1])
+	}
+	return def, nil
+}
+
+func parseVAppPropertyBound(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// parseVAppPropertyEnum parses a comma-separated list of optionally quoted
+// values.
+func parseVAppPropertyEnum(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		v = strings.TrimSuffix(strings.TrimPrefix(v, `"`), `"`)
+		values = append(values, v)
+	}
+	return values
+}
+
+// Validate checks the supplied value against the definition.
+func (def *VAppPropertyDefinition) Validate(value string) error {
+	if len(def.Enum) > 0 {
+		for _, v := range def.Enum {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %q", def.Enum)
+	}
+
+	switch def.Kind {
+	case VAppPropertyKindString, VAppPropertyKindPassword:
+		return def.validateRange(float64(utf8.RuneCountInString(value)), "length")
+	case VAppPropertyKindInt:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		return def.validateRange(float64(v), "value")
+	case VAppPropertyKindReal:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		return def.validateRange(v, "value")
+	case VAppPropertyKindBoolean:
+		if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+			return fmt.Errorf("must be True or False")
+		}
+	case VAppPropertyKindIP:
+		// An empty address is commonly used to select DHCP.
+		if value != "" && net.ParseIP(value) == nil {
+			return fmt.Errorf("must be an IP address")
+		}
+	}
+	return nil
+}
+
+func (def *VAppPropertyDefinition) validateRange(v float64, what string) error {
+	switch {
+	case def.Min != nil && def.Max != nil && (v < *def.Min || v > *def.Max):
+		return fmt.Errorf("%s must be between %v and %v", what, *def.Min, *def.Max)
+	case def.Min != nil && v < *def.Min:
+		return fmt.Errorf("%s must be at least %v", what, *def.Min)
+	case def.Max != nil && v > *def.Max:
+		return fmt.Errorf("%s must be at most %v", what, *def.Max)
+	}
+	return nil
+}
+
+// ValidateVAppProperties validates the supplied vApp property values against
+// the definitions. A property is set either by its qualified ID or, if no
+// other property shares it, by its ID. Unknown or ambiguous properties,
+// properties that are set more than once, properties that are not user
+// configurable unless enableHidden is set, invalid values, and user
+// configurable properties that are not set, have no default or valid current
+// value and do not accept an empty value are reported. Values in unknown are not validated. All
+// problems are reported in a single error.
+func ValidateVAppProperties(defs []*VAppPropertyDefinition, values map[string]string, unknown map[string]bool, enableHidden bool) error {
+	byID := make(map[string][]*VAppPropertyDefinition)
+	byQualifiedID := make(map[string]*VAppPropertyDefinition)
+	for _, def := range defs {
+		byID[def.ID] = append(byID[def.ID], def)
+		byQualifiedID[def.QualifiedID] = def
+	}
+
+	var problems []string
+	set := make(map[*VAppPropertyDefinition]string)
+	for id, value := range values {
+		def, ok := byQualifiedID[id]
+		if !ok && len(byID[id]) == 1 {
+			def, ok = byID[id][0], true
+		}
+		switch {
+		case !ok && len(byID[id]) > 1:
+			var ids []string
+			for _, d := range byID[id] {
+				ids = append(ids, d.QualifiedID)
+			}
+			sort.Strings(ids)
+			problems = append(problems, fmt.Sprintf("%s: property is defined in more than one product section, use one of %q", id, ids))
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: unknown property", id))
+		case set[def] != "":
+			problems = append(problems, fmt.Sprintf("%s: property is set more than once", def.QualifiedID))
+		case !def.UserConfigurable && !enableHidden:
+			problems = append(problems, fmt.Sprintf("%s: property is not user configurable", id))
+		case unknown[id]:
+			set[def] = id
+		default:
+			set[def] = id
+			if err := def.Validate(value); err != nil {
+				if def.Password {
+					problems = append(problems, fmt.Sprintf("%s: invalid password: %s", id, err))
+				} else {
+					problems = append(problems, fmt.Sprintf("%s: invalid value %q: %s", id, value, err))
+				}
+			}
+		}
+	}
+	for _, def := range defs {
+		if _, ok := set[def]; ok || !def.UserConfigurable || def.DefaultValue != "" {
+			continue
+		}
+		if def.Value != "" && def.Validate(def.Value) == nil {
+			continue
+		}
+		if def.Validate("") != nil {
+			problems = append(problems, fmt.Sprintf("%s: property is required and has no default value", def.QualifiedID))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("invalid vApp properties:\n- %s", strings.Join(problems, "\n- "))
+}
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualmachine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVAppPropertyDefinitionValidate(t *testing.T) {
+	cases := []struct {
+		name      string
+		typ       string
+		value     string
+		expectErr bool
+	}{
+		{name: "int in range", typ: "int(1..100)", value: "50"},
+		{name: "int out of range", typ: "int(1..100)", value: "101", expectErr: true},
+		{name: "int not a number", typ: "int", value: "ten", expectErr: true},
+		{name: "real", typ: "real", value: "1.5"},
+		{name: "boolean", typ: "boolean", value: "True"},
+		{name: "boolean invalid", typ: "boolean", value: "yes", expectErr: true},
+		{name: "string length", typ: "string(..4)", value: "abcde", expectErr: true},
+		{name: "enum", typ: `string["small", "large"]`, value: "large"},
+		{name: "enum invalid", typ: `string["small", "large"]`, value: "medium", expectErr: true},
+		{name: "ip", typ: "ip:VM Network", value: "10.0.0.1"},
+		{name: "ip empty", typ: "ip", value: ""},
+		{name: "ip invalid", typ: "ip", value: "10.0.0", expectErr: true},
+		{name: "expression", typ: "expression", value: "anything"},
+		{name: "unknown type", typ: "blob(1..2)", value: "anything"},
+		{name: "unparsable type", typ: "string{1..2}", value: "anything"},
+		{name: "invalid range", typ: "int(1.2.3..4)", value: "ten"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			def := VAppPropertyDefinitionFromInfo(types.VAppPropertyInfo{Id: "p", Type: tc.typ})
+			if err := def.Validate(tc.value); tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestVAppPropertyDefinitionFromOvf(t *testing.T) {
+	str := func(s string) *string { return &s }
+	def, err := VAppPropertyDefinitionFromOvf(ovf.ProductSection{}, ovf.Property{Key: "p", Type: "uint8", Qualifiers: str("MinValue(10) MaxValue(20)")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Validate("15") != nil || def.Validate("21") == nil || def.Validate("9") == nil {
+		t.Fatalf("unexpected range %v..%v", *def.Min, *def.Max)
+	}
+	def, err = VAppPropertyDefinitionFromOvf(ovf.ProductSection{}, ovf.Property{Key: "p", Type: "string", Qualifiers: str("ValueMap{\"a\",\"b\"}")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Validate("a") != nil || def.Validate("c") == nil {
+		t.Fatalf("unexpected enum %q", def.Enum)
+	}
+	def, err = VAppPropertyDefinitionFromOvf(ovf.ProductSection{}, ovf.Property{Key: "p", Type: "blob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Kind != VAppPropertyKindString || def.Validate("anything") != nil {
+		t.Fatalf("expected an unsupported type to be treated as a string, got %q", def.Kind)
+	}
+}
+
+func TestValidateVAppProperties(t *testing.T) {
+	defs := []*VAppPropertyDefinition{
+		{ID: "hostname", QualifiedID: "hostname", Kind: VAppPropertyKindString, Min: float64Ptr(1), UserConfigurable: true},
+		{ID: "size", QualifiedID: "size", Kind: VAppPropertyKindInt, Max: float64Ptr(8), UserConfigurable: true, DefaultValue: "2"},
+		{ID: "secret", QualifiedID: "secret", Kind: VAppPropertyKindPassword, Min: float64Ptr(8), Password: true, UserConfigurable: true, DefaultValue: "changeme"},
+		{ID: "hidden", QualifiedID: "hidden", Kind: VAppPropertyKindString},
+	}
+	err := ValidateVAppProperties(defs, map[string]string{
+		"size":    "16",
+		"secret":  "short",
+		"hidden":  "x",
+		"unknown": "x",
+	}, nil, false)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, s := range []string{
+		"hidden: property is not user configurable",
+		"hostname: property is required and has no default value",
+		"secret: invalid password",
+		"size: invalid value \"16\"",
+		"unknown: unknown property",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected %q in %q", s, err)
+		}
+	}
+	if strings.Contains(err.Error(), "short") {
+		t.Errorf("expected the password not to be reported in %q", err)
+	}
+
+	err = ValidateVAppProperties(defs, map[string]string{
+		"hostname": "computed",
+		"hidden":   "x",
+	}, map[string]bool{"hostname": true}, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+}
+
+func TestValidateVAppPropertiesCurrentValue(t *testing.T) {
+	userConfigurable := true
+	info := func(id, value string) *VAppPropertyDefinition {
+		return VAppPropertyDefinitionFromInfo(types.VAppPropertyInfo{Id: id, Type: "int", Value: value, UserConfigurable: &userConfigurable})
+	}
+	defs := []*VAppPropertyDefinition{
+		info("cpus", "2"),
+		info("disks", ""),
+		info("nics", " "),
+		info("port", ""),
+	}
+	// Only port changes in an update of the virtual machine.
+	err := ValidateVAppProperties(defs, map[string]string{"port": "8080"}, nil, false)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for id, expected := range map[string]bool{"cpus": false, "disks": true, "nics": true, "port": false} {
+		if reported := strings.Contains(err.Error(), id+": property is required"); reported != expected {
+			t.Errorf("expected %s to be reported as required: %t, got %q", id, expected, err)
+		}
+	}
+}
+
+func TestValidateVAppPropertiesQualified(t *testing.T) {
+	str := func(s string) *string { return &s }
+	userConfigurable := true
+	var defs []*VAppPropertyDefinition
+	for _, section := range []ovf.ProductSection{
+		{Class: str("vami"), Instance: str("VM_1")},
+		{Class: str("vami"), Instance: str("VM_2")},
+		{},
+	} {
+		for _, key := range []string{"ip0", "hostname"} {
+			if key == "hostname" && section.Class != nil {
+				continue
+			}
+			def, err := VAppPropertyDefinitionFromOvf(section, ovf.Property{Key: key, Type: "string", UserConfigurable: &userConfigurable})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defs = append(defs, def)
+		}
+	}
+	if defs[0].QualifiedID != "vami.ip0.VM_1" {
+		t.Fatalf("expected qualified ID vami.ip0.VM_1, got %s", defs[0].QualifiedID)
+	}
+
+	err := ValidateVAppProperties(defs, map[string]string{
+		"vami.ip0.VM_1": "10.0.0.1",
+		"vami.ip0.VM_2": "10.0.0.2",
+		"ip0":           "10.0.0.3",
+		"hostname":      "a",
+	}, nil, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	err = ValidateVAppProperties(defs[:2], map[string]string{"ip0": "10.0.0.1"}, nil, false)
+	if err == nil || !strings.Contains(err.Error(), `ip0: property is defined in more than one product section, use one of ["vami.ip0.VM_1" "vami.ip0.VM_2"]`) {
+		t.Fatalf("expected an ambiguous property error, got %v", err)
+	}
+
+	err = ValidateVAppProperties(defs[:1], map[string]string{"ip0": "10.0.0.1", "vami.ip0.VM_1": "10.0.0.1"}, nil, false)
+	if err == nil || !strings.Contains(err.Error(), "vami.ip0.VM_1: property is set more than once") {
+		t.Fatalf("expected a duplicate property error, got %v", err)
+	}
+}
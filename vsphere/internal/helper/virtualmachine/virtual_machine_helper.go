@@ -790,6 +790,100 @@ func ShutdownGuest(client *govmomi.Client, vm *object.VirtualMachine, timeout in
 	return nil
 }
 
+// UpgradeTools upgrades VMware Tools in the guest of a powered on virtual
+// machine, passing installerOptions to the installer, and then waits for
+// VMware Tools to be running again. If VMware Tools does not come back in the
+// period specified by timeout (in minutes), an error is returned.
+//
+// The minimum value for timeout is 1 minute - setting to a 0 or negative value
+// is not allowed and will just reset the timeout to the minimum.
+func UpgradeTools(client *govmomi.Client, vm *object.VirtualMachine, installerOptions string, timeout int) error {
+	vprops, err := Properties(vm)
+	if err != nil {
+		return err
+	}
+	if vprops.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		return fmt.Errorf("virtual machine %q must be powered on to upgrade VMware Tools", vm.InventoryPath)
+	}
+	if vprops.Guest == nil || vprops.Guest.ToolsVersionStatus == string(types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled) {
+		return fmt.Errorf("VMware Tools is not installed on virtual machine %q", vm.InventoryPath)
+	}
+	switch vprops.Guest.ToolsVersionStatus {
+	case string(types.VirtualMachineToolsVersionStatusGuestToolsCurrent):
+		log.Printf("[DEBUG] VMware Tools on virtual machine %q is current, skipping upgrade", vm.InventoryPath)
+		return nil
+	case string(types.VirtualMachineToolsVersionStatusGuestToolsUnmanaged):
+		return fmt.Errorf("VMware Tools on virtual machine %q is managed by the guest operating system and cannot be upgraded through vSphere", vm.InventoryPath)
+	}
+
+	if timeout < 1 {
+		timeout = 1
+	}
+	log.Printf("[DEBUG] Upgrading VMware Tools on virtual machine %q (timeout = %dm)", vm.InventoryPath, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*time.Duration(timeout))
+	defer cancel()
+	task, err := vm.UpgradeTools(ctx, installerOptions)
+	if err != nil {
+		return err
+	}
+	if err := task.Wait(ctx); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return errors.New("timeout waiting for VMware Tools upgrade to complete")
+		}
+		return err
+	}
+
+	// The upgrade restarts VMware Tools, wait for it to report as running again.
+	state := &toolsUpgradeState{oldStatus: vprops.Guest.ToolsVersionStatus2}
+	p := client.PropertyCollector()
+	err = property.Wait(ctx, p, vm.Reference(), []string{"guest.toolsVersionStatus2", "guest.toolsRunningStatus"}, state.update)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return errors.New("timeout waiting for VMware Tools to be running after upgrade")
+		}
+		return err
+	}
+
+	log.Printf("[DEBUG] VMware Tools upgrade on virtual machine %q complete", vm.InventoryPath)
+	return nil
+}
+
+// toolsUpgradeState tracks the VMware Tools status of a virtual machine after
+// an upgrade. Tools may still report as running with the old version when the
+// upgrade task completes, so the upgrade is complete once Tools is running and
+// either its version status changed from the one before the upgrade, or it
+// stopped and started again.
+type toolsUpgradeState struct {
+	oldStatus string
+	status    string
+	running   string
+	stopped   bool
+}
+
+// update applies the changes of guest.toolsVersionStatus2 and
+// guest.toolsRunningStatus, and returns true once the upgrade is complete.
+func (s *toolsUpgradeState) update(pc []types.PropertyChange) bool {
+	for _, c := range pc {
+		if c.Op != types.PropertyChangeOpAssign {
+			continue
+		}
+		v, _ := c.Val.(string)
+		switch c.Name {
+		case "guest.toolsVersionStatus2":
+			s.status = v
+		case "guest.toolsRunningStatus":
+			s.running = v
+			if v != string(types.VirtualMachineToolsRunningStatusGuestToolsRunning) {
+				s.stopped = true
+			}
+		}
+	}
+	if s.running != string(types.VirtualMachineToolsRunningStatusGuestToolsRunning) {
+		return false
+	}
+	return s.stopped || (s.oldStatus != "" && s.status != "" && s.status != s.oldStatus)
+}
+
 // GracefulPowerOff is a meta-operation that handles powering down of virtual
 // machines. A graceful shutdown is attempted first if possible (VMware Tools
 // is installed, and the guest state is not suspended), and then, if allowed, a
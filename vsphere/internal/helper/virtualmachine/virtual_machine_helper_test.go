@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualmachine
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestToolsUpgradeStateUpdate(t *testing.T) {
+	running := string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)
+	notRunning := string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning)
+	needUpgrade := string(types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade)
+	current := string(types.VirtualMachineToolsVersionStatusGuestToolsCurrent)
+	change := func(name, val string) types.PropertyChange {
+		return types.PropertyChange{Name: name, Op: types.PropertyChangeOpAssign, Val: val}
+	}
+
+	cases := []struct {
+		name     string
+		updates  [][]types.PropertyChange
+		expected []bool
+	}{
+		{
+			name: "still running the old version",
+			updates: [][]types.PropertyChange{
+				{change("guest.toolsVersionStatus2", needUpgrade), change("guest.toolsRunningStatus", running)},
+			},
+			expected: []bool{false},
+		},
+		{
+			name: "version status changed",
+			updates: [][]types.PropertyChange{
+				{change("guest.toolsVersionStatus2", needUpgrade), change("guest.toolsRunningStatus", running)},
+				{change("guest.toolsVersionStatus2", current)},
+			},
+			expected: []bool{false, true},
+		},
+		{
+			name: "restarted",
+			updates: [][]types.PropertyChange{
+				{change("guest.toolsVersionStatus2", needUpgrade), change("guest.toolsRunningStatus", running)},
+				{change("guest.toolsRunningStatus", notRunning)},
+				{change("guest.toolsRunningStatus", running)},
+			},
+			expected: []bool{false, false, true},
+		},
+		{
+			name: "version status changed while not running",
+			updates: [][]types.PropertyChange{
+				{change("guest.toolsVersionStatus2", current), change("guest.toolsRunningStatus", notRunning)},
+				{change("guest.toolsRunningStatus", running)},
+			},
+			expected: []bool{false, true},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &toolsUpgradeState{oldStatus: needUpgrade}
+			for i, pc := range tc.updates {
+				if actual := s.update(pc); actual != tc.expected[i] {
+					t.Fatalf("update %d: expected %t, got %t", i, tc.expected[i], actual)
+				}
+			}
+		})
+	}
+}
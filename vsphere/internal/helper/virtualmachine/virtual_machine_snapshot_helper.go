@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package virtualmachine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/provider"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// SnapshotTree returns the root snapshots of the virtual machine, or nil if it
+// has no snapshots.
+func SnapshotTree(vm *object.VirtualMachine) ([]types.VirtualMachineSnapshotTree, error) {
+	log.Printf("[DEBUG] Fetching snapshots for VM %q", vm.InventoryPath)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	var props mo.VirtualMachine
+	if err := vm.Properties(ctx, vm.Reference(), []string{"snapshot"}, &props); err != nil {
+		return nil, err
+	}
+	if props.Snapshot == nil {
+		return nil, nil
+	}
+	return props.Snapshot.RootSnapshotList, nil
+}
+
+// FindSnapshots walks the snapshot tree and returns all snapshots for which
+// match returns true, parents before their children.
+func FindSnapshots(tree []types.VirtualMachineSnapshotTree, match func(types.VirtualMachineSnapshotTree) bool) []types.VirtualMachineSnapshotTree {
+	var found []types.VirtualMachineSnapshotTree
+	for _, s := range tree {
+		if match(s) {
+			found = append(found, s)
+		}
+		found = append(found, FindSnapshots(s.ChildSnapshotList, match)...)
+	}
+	return found
+}
+
+// SnapshotFromID returns the snapshot of the virtual machine with the supplied
+// managed object ID, or nil if the snapshot does not exist.
+func SnapshotFromID(vm *object.VirtualMachine, id string) (*types.VirtualMachineSnapshotTree, error) {
+	tree, err := SnapshotTree(vm)
+	if err != nil {
+		return nil, err
+	}
+	found := FindSnapshots(tree, func(s types.VirtualMachineSnapshotTree) bool {
+		return s.Snapshot.Value == id
+	})
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return &found[0], nil
+}
+
+// SnapshotFromNameOrID returns the snapshot of the virtual machine with the
+// supplied managed object ID or, failing that, with the supplied name. An
+// error is returned if no snapshot or more than one snapshot matches.
+func SnapshotFromNameOrID(vm *object.VirtualMachine, s string) (*types.VirtualMachineSnapshotTree, error) {
+	tree, err := SnapshotTree(vm)
+	if err != nil {
+		return nil, err
+	}
+	found := FindSnapshots(tree, func(t types.VirtualMachineSnapshotTree) bool {
+		return t.Snapshot.Value == s
+	})
+	if len(found) == 0 {
+		found = FindSnapshots(tree, func(t types.VirtualMachineSnapshotTree) bool {
+			return t.Name == s
+		})
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("snapshot %q not found on virtual machine %q", s, vm.InventoryPath)
+	case 1:
+		return &found[0], nil
+	}
+	return nil, fmt.Errorf("%d snapshots named %q found on virtual machine %q, use the managed object ID of the snapshot instead", len(found), s, vm.InventoryPath)
+}
+
+// RenameSnapshot changes the name and description of a snapshot. Empty values
+// are left unchanged.
+func RenameSnapshot(vm *object.VirtualMachine, ref types.ManagedObjectReference, name, description string) error {
+	log.Printf("[DEBUG] Renaming snapshot %q of virtual machine %q to %q", ref.Value, vm.InventoryPath, name)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	_, err := methods.RenameSnapshot(ctx, vm.Client(), &types.RenameSnapshot{
+		This:        ref,
+		Name:        name,
+		Description: description,
+	})
+	return err
+}
+
+// RevertToSnapshot wraps the RevertToSnapshot task and the subsequent waiting
+// for the task to complete.
+func RevertToSnapshot(vm *object.VirtualMachine, ref types.ManagedObjectReference, suppressPowerOn bool) error {
+	log.Printf("[DEBUG] Reverting virtual machine %q to snapshot %q", vm.InventoryPath, ref.Value)
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	task, err := vm.RevertToSnapshot(ctx, ref.Value, suppressPowerOn)
+	if err != nil {
+		return err
+	}
+	tctx, tcancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer tcancel()
+	return task.Wait(tctx)
+}
+
+// ConsolidateDisks consolidates the disks of the virtual machine if vSphere
+// reports that this is needed, ie: after a snapshot was removed but its delta
+// disks could not be merged. It returns true if a consolidation was run.
+func ConsolidateDisks(vm *object.VirtualMachine, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	var props mo.VirtualMachine
+	if err := vm.Properties(ctx, vm.Reference(), []string{"runtime.consolidationNeeded"}, &props); err != nil {
+		return false, err
+	}
+	if props.Runtime.ConsolidationNeeded == nil || !*props.Runtime.ConsolidationNeeded {
+		return false, nil
+	}
+
+	log.Printf("[DEBUG] Consolidating disks of virtual machine %q", vm.InventoryPath)
+	tctx, tcancel := context.WithTimeout(context.Background(), timeout)
+	defer tcancel()
+	res, err := methods.ConsolidateVMDisks_Task(tctx, vm.Client(), &types.ConsolidateVMDisks_Task{
+		This: vm.Reference(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, object.NewTask(vm.Client(), res.Returnval).Wait(tctx)
+}
@@ -35,6 +35,25 @@ func VirtualMachineCloneSchema() map[string]*schema.Schema {
 		Default:     10,
 		Description: "The amount of time, in minutes, to wait for guest OS customization to complete before returning with an error. Setting this value to 0 or a negative value skips the waiter. Default: 10.",
 	}
+	for k, v := range customizationGuestLogSchema("clone.0.customize.0.") {
+		customizatonSpecSchema[k] = v
+	}
+	customizationSpecIDSchema := map[string]*schema.Schema{
+		"id": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The unique identifier of the customization specification is its name and is unique per vCenter Server instance.",
+		},
+		"timeout": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Default:     10,
+			Description: "The amount of time, in minutes, to wait for guest OS customization to complete before returning with an error. Setting this value to 0 or a negative value skips the waiter. Default: 10.",
+		},
+	}
+	for k, v := range customizationGuestLogSchema("clone.0.customization_spec.0.") {
+		customizationSpecIDSchema[k] = v
+	}
 
 	return map[string]*schema.Schema{
 		"template_uuid": {
@@ -68,21 +87,7 @@ func VirtualMachineCloneSchema() map[string]*schema.Schema {
 			MaxItems:      1,
 			Description:   "The customization specification for the virtual machine post-clone.",
 			ConflictsWith: []string{"clone.0.customize"},
-			Elem: &schema.Resource{
-				Schema: map[string]*schema.Schema{
-					"id": {
-						Type:        schema.TypeString,
-						Required:    true,
-						Description: "The unique identifier of the customization specification is its name and is unique per vCenter Server instance.",
-					},
-					"timeout": {
-						Type:        schema.TypeInt,
-						Optional:    true,
-						Default:     10,
-						Description: "The amount of time, in minutes, to wait for guest OS customization to complete before returning with an error. Setting this value to 0 or a negative value skips the waiter. Default: 10.",
-					},
-				},
-			},
+			Elem:          &schema.Resource{Schema: customizationSpecIDSchema},
 		},
 		"ovf_network_map": {
 			Type:        schema.TypeMap,
@@ -99,6 +104,27 @@ func VirtualMachineCloneSchema() map[string]*schema.Schema {
 	}
 }
 
+// customizationGuestLogSchema returns the schema for the guest credentials
+// used to retrieve the customization log from the guest when customization
+// fails. prefix is the full path to the block the keys are added to.
+func customizationGuestLogSchema(prefix string) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"guest_log_username": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			RequiredWith: []string{prefix + "guest_log_password"},
+			Description:  "The guest user name used to retrieve the customization log through guest operations when customization fails. The log is not retrieved if not set.",
+		},
+		"guest_log_password": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			RequiredWith: []string{prefix + "guest_log_username"},
+			Description:  "The password for guest_log_username.",
+		},
+	}
+}
+
 // ValidateVirtualMachineClone does pre-creation validation of a virtual
 // machine's configuration to make sure it's suitable for use in cloning.
 // This includes, but is not limited to checking to make sure that the disks in
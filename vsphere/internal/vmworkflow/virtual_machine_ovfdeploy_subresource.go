@@ -5,10 +5,12 @@ package vmworkflow
 
 import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
 )
 
 func VirtualMachineOvfDeploySchema() map[string]*schema.Schema {
-	return map[string]*schema.Schema{
+	s := map[string]*schema.Schema{
 		"local_ovf_path": {
 			Type:        schema.TypeString,
 			Optional:    true,
@@ -21,6 +23,18 @@ func VirtualMachineOvfDeploySchema() map[string]*schema.Schema {
 			Description: "URL to the remote ovf/ova file to be deployed.",
 			ForceNew:    true,
 		},
+		"source_datastore_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The ID of the datastore that holds the ovf/ova file to be deployed.",
+			ForceNew:    true,
+		},
+		"source_datastore_path": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The path to the ovf/ova file on the datastore in source_datastore_id.",
+			ForceNew:    true,
+		},
 		"ip_allocation_policy": {
 			Type:        schema.TypeString,
 			Optional:    true,
@@ -66,4 +80,6 @@ func VirtualMachineOvfDeploySchema() map[string]*schema.Schema {
 			ForceNew:    true,
 		},
 	}
+	structure.MergeSchema(s, ovfdeploy.SignatureSchema())
+	return s
 }
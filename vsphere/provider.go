@@ -9,12 +9,18 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
 )
 
 // defaultAPITimeout is a default timeout value that is passed to functions
 // requiring contexts, and other various waiters.
 var defaultAPITimeout = time.Minute * 5
 
+// defaultOvfDownloadCacheMaxSize is the default size, in MB, above which
+// templates are evicted from the OVF/OVA download cache.
+const defaultOvfDownloadCacheMaxSize = 20480
+
 // Provider returns a terraform.ResourceProvider.
 func Provider() *schema.Provider {
 	return &schema.Provider{
@@ -99,49 +105,102 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_API_TIMEOUT", 5),
 				Description: "API timeout in minutes (Default: 5)",
 			},
+			"deletion_protection_tag": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_DELETION_PROTECTION_TAG", ""),
+				Description: "A tag, in the form <category>/<tag>, that protects the objects it is attached to from being destroyed by resources that support deletion_protection.",
+			},
+			"deletion_protection_custom_attribute": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_DELETION_PROTECTION_CUSTOM_ATTRIBUTE", ""),
+				Description: "The name of a custom attribute that protects the objects on which it is set to a value other than false from being destroyed by resources that support deletion_protection.",
+			},
+			"ovf_download_cache_dir": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_OVF_DOWNLOAD_CACHE_DIR", ""),
+				Description: "A directory in which remote OVF/OVA templates are cached, so that each template is only downloaded once.",
+			},
+			"ovf_download_cache_max_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("VSPHERE_OVF_DOWNLOAD_CACHE_MAX_SIZE", defaultOvfDownloadCacheMaxSize),
+				Description:  "The size, in MB, above which the least recently used templates are evicted from the OVF/OVA download cache. Set to 0 for no limit.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"ovf_upload_parallelism": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("VSPHERE_OVF_UPLOAD_PARALLELISM", ovfdeploy.DefaultUploadParallelism),
+				Description:  "The number of files of an OVF/OVA template that are uploaded concurrently.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"ovf_upload_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("VSPHERE_OVF_UPLOAD_RETRIES", ovfdeploy.DefaultUploadRetries),
+				Description:  "The number of times the upload of a single file of an OVF/OVA template is retried.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"vsphere_compute_cluster":                         resourceVSphereComputeCluster(),
-			"vsphere_compute_cluster_host_group":              resourceVSphereComputeClusterHostGroup(),
-			"vsphere_compute_cluster_vm_affinity_rule":        resourceVSphereComputeClusterVMAffinityRule(),
-			"vsphere_compute_cluster_vm_anti_affinity_rule":   resourceVSphereComputeClusterVMAntiAffinityRule(),
-			"vsphere_compute_cluster_vm_dependency_rule":      resourceVSphereComputeClusterVMDependencyRule(),
-			"vsphere_compute_cluster_vm_group":                resourceVSphereComputeClusterVMGroup(),
-			"vsphere_compute_cluster_vm_host_rule":            resourceVSphereComputeClusterVMHostRule(),
-			"vsphere_content_library":                         resourceVSphereContentLibrary(),
-			"vsphere_content_library_item":                    resourceVSphereContentLibraryItem(),
-			"vsphere_custom_attribute":                        resourceVSphereCustomAttribute(),
-			"vsphere_datacenter":                              resourceVSphereDatacenter(),
-			"vsphere_datastore_cluster":                       resourceVSphereDatastoreCluster(),
-			"vsphere_datastore_cluster_vm_anti_affinity_rule": resourceVSphereDatastoreClusterVMAntiAffinityRule(),
-			"vsphere_distributed_port_group":                  resourceVSphereDistributedPortGroup(),
-			"vsphere_distributed_virtual_switch":              resourceVSphereDistributedVirtualSwitch(),
-			"vsphere_drs_vm_override":                         resourceVSphereDRSVMOverride(),
-			"vsphere_dpm_host_override":                       resourceVSphereDPMHostOverride(),
-			"vsphere_file":                                    resourceVSphereFile(),
-			"vsphere_folder":                                  resourceVSphereFolder(),
-			"vsphere_ha_vm_override":                          resourceVSphereHAVMOverride(),
-			"vsphere_host_port_group":                         resourceVSphereHostPortGroup(),
-			"vsphere_host_virtual_switch":                     resourceVSphereHostVirtualSwitch(),
-			"vsphere_license":                                 resourceVSphereLicense(),
-			"vsphere_resource_pool":                           resourceVSphereResourcePool(),
-			"vsphere_tag":                                     resourceVSphereTag(),
-			"vsphere_tag_category":                            resourceVSphereTagCategory(),
-			"vsphere_virtual_disk":                            resourceVSphereVirtualDisk(),
-			"vsphere_virtual_machine":                         resourceVSphereVirtualMachine(),
-			"vsphere_nas_datastore":                           resourceVSphereNasDatastore(),
-			"vsphere_storage_drs_vm_override":                 resourceVSphereStorageDrsVMOverride(),
-			"vsphere_vapp_container":                          resourceVSphereVAppContainer(),
-			"vsphere_vapp_entity":                             resourceVSphereVAppEntity(),
-			"vsphere_vmfs_datastore":                          resourceVSphereVmfsDatastore(),
-			"vsphere_virtual_machine_snapshot":                resourceVSphereVirtualMachineSnapshot(),
-			"vsphere_host":                                    resourceVsphereHost(),
-			"vsphere_vnic":                                    resourceVsphereNic(),
-			"vsphere_vm_storage_policy":                       resourceVMStoragePolicy(),
-			"vsphere_role":                                    resourceVsphereRole(),
-			"vsphere_entity_permissions":                      resourceVsphereEntityPermissions(),
-			"vsphere_guest_os_customization":                  resourceVSphereGuestOsCustomization(),
+			"vsphere_compute_cluster":                           resourceVSphereComputeCluster(),
+			"vsphere_compute_cluster_host_group":                resourceVSphereComputeClusterHostGroup(),
+			"vsphere_compute_cluster_vm_affinity_rule":          resourceVSphereComputeClusterVMAffinityRule(),
+			"vsphere_compute_cluster_vm_anti_affinity_rule":     resourceVSphereComputeClusterVMAntiAffinityRule(),
+			"vsphere_compute_cluster_vm_dependency_rule":        resourceVSphereComputeClusterVMDependencyRule(),
+			"vsphere_compute_cluster_vm_group":                  resourceVSphereComputeClusterVMGroup(),
+			"vsphere_compute_cluster_vm_host_rule":              resourceVSphereComputeClusterVMHostRule(),
+			"vsphere_content_library":                           resourceVSphereContentLibrary(),
+			"vsphere_content_library_item":                      resourceVSphereContentLibraryItem(),
+			"vsphere_custom_attribute":                          resourceVSphereCustomAttribute(),
+			"vsphere_datacenter":                                resourceVSphereDatacenter(),
+			"vsphere_datastore_cluster":                         resourceVSphereDatastoreCluster(),
+			"vsphere_datastore_cluster_vm_anti_affinity_rule":   resourceVSphereDatastoreClusterVMAntiAffinityRule(),
+			"vsphere_distributed_port_group":                    resourceVSphereDistributedPortGroup(),
+			"vsphere_distributed_virtual_switch":                resourceVSphereDistributedVirtualSwitch(),
+			"vsphere_drs_vm_override":                           resourceVSphereDRSVMOverride(),
+			"vsphere_dpm_host_override":                         resourceVSphereDPMHostOverride(),
+			"vsphere_file":                                      resourceVSphereFile(),
+			"vsphere_folder":                                    resourceVSphereFolder(),
+			"vsphere_ha_vm_override":                            resourceVSphereHAVMOverride(),
+			"vsphere_host_advanced_settings":                    resourceVSphereHostAdvancedSettings(),
+			"vsphere_host_certificate":                          resourceVSphereHostCertificate(),
+			"vsphere_host_certificate_signing_request":          resourceVSphereHostCertificateSigningRequest(),
+			"vsphere_host_dns":                                  resourceVSphereHostDNS(),
+			"vsphere_host_firewall_ruleset":                     resourceVSphereHostFirewallRuleset(),
+			"vsphere_host_iscsi_adapter":                        resourceVSphereHostIscsiAdapter(),
+			"vsphere_host_iscsi_port_binding":                   resourceVSphereHostIscsiPortBinding(),
+			"vsphere_host_iscsi_target":                         resourceVSphereHostIscsiTarget(),
+			"vsphere_host_ntp":                                  resourceVSphereHostNtp(),
+			"vsphere_host_pci_passthrough":                      resourceVSphereHostPciPassthrough(),
+			"vsphere_host_service":                              resourceVSphereHostService(),
+			"vsphere_host_profile":                              resourceVSphereHostProfile(),
+			"vsphere_host_profile_attachment":                   resourceVSphereHostProfileAttachment(),
+			"vsphere_host_port_group":                           resourceVSphereHostPortGroup(),
+			"vsphere_host_virtual_switch":                       resourceVSphereHostVirtualSwitch(),
+			"vsphere_license":                                   resourceVSphereLicense(),
+			"vsphere_resource_pool":                             resourceVSphereResourcePool(),
+			"vsphere_tag":                                       resourceVSphereTag(),
+			"vsphere_tag_category":                              resourceVSphereTagCategory(),
+			"vsphere_virtual_disk":                              resourceVSphereVirtualDisk(),
+			"vsphere_virtual_machine":                           resourceVSphereVirtualMachine(),
+			"vsphere_nas_datastore":                             resourceVSphereNasDatastore(),
+			"vsphere_storage_drs_vm_override":                   resourceVSphereStorageDrsVMOverride(),
+			"vsphere_vapp_container":                            resourceVSphereVAppContainer(),
+			"vsphere_vapp_entity":                               resourceVSphereVAppEntity(),
+			"vsphere_vmfs_datastore":                            resourceVSphereVmfsDatastore(),
+			"vsphere_virtual_machine_snapshot":                  resourceVSphereVirtualMachineSnapshot(),
+			"vsphere_host":                                      resourceVsphereHost(),
+			"vsphere_vnic":                                      resourceVsphereNic(),
+			"vsphere_vm_storage_policy":                         resourceVMStoragePolicy(),
+			"vsphere_role":                                      resourceVsphereRole(),
+			"vsphere_entity_permissions":                        resourceVsphereEntityPermissions(),
+			"vsphere_guest_os_customization":                    resourceVSphereGuestOsCustomization(),
+			"vsphere_guest_os_customization_encrypted_password": resourceVSphereGuestOSCustomizationEncryptedPassword(),
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
@@ -158,6 +217,8 @@ func Provider() *schema.Provider {
 			"vsphere_folder":                     dataSourceVSphereFolder(),
 			"vsphere_host":                       dataSourceVSphereHost(),
 			"vsphere_host_pci_device":            dataSourceVSphereHostPciDevice(),
+			"vsphere_host_profile":               dataSourceVSphereHostProfile(),
+			"vsphere_host_profile_compliance":    dataSourceVSphereHostProfileCompliance(),
 			"vsphere_host_thumbprint":            dataSourceVSphereHostThumbprint(),
 			"vsphere_license":                    dataSourceVSphereLicense(),
 			"vsphere_network":                    dataSourceVSphereNetwork(),
@@ -168,6 +229,7 @@ func Provider() *schema.Provider {
 			"vsphere_tag_category":               dataSourceVSphereTagCategory(),
 			"vsphere_vapp_container":             dataSourceVSphereVAppContainer(),
 			"vsphere_virtual_machine":            dataSourceVSphereVirtualMachine(),
+			"vsphere_virtual_machine_snapshots":  dataSourceVSphereVirtualMachineSnapshots(),
 			"vsphere_vmfs_disks":                 dataSourceVSphereVmfsDisks(),
 			"vsphere_role":                       dataSourceVsphereRole(),
 			"vsphere_guest_os_customization":     dataSourceVSphereGuestOSCustomization(),
@@ -657,6 +657,7 @@ func resourceVSphereComputeCluster() *schema.Resource {
 			},
 			vSphereTagAttributeKey:    tagsSchema(),
 			customattribute.ConfigKey: customattribute.ConfigSchema(),
+			deletionProtectionKey:     deletionProtectionSchema(),
 		},
 	}
 }
@@ -776,6 +777,9 @@ func resourceVSphereComputeClusterDelete(d *schema.ResourceData, meta interface{
 	if err != nil {
 		return err
 	}
+	if err := checkDeletionProtection(d, meta, cluster.Reference()); err != nil {
+		return err
+	}
 
 	client, err := resourceVSphereComputeClusterClient(meta)
 	if err != nil {
@@ -857,6 +861,7 @@ func resourceVSphereComputeClusterImportSetDefaults(d *schema.ResourceData) erro
 		"ha_admission_control_slot_policy_explicit_cpu":    s["ha_admission_control_slot_policy_explicit_cpu"].Default,
 		"ha_admission_control_slot_policy_explicit_memory": s["ha_admission_control_slot_policy_explicit_memory"].Default,
 		"host_cluster_exit_timeout":                        s["host_cluster_exit_timeout"].Default,
+		deletionProtectionKey:                              s[deletionProtectionKey].Default,
 	})
 }
 
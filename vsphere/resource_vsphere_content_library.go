@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/contentlibrary"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func resourceVSphereContentLibrary() *schema.Resource {
@@ -16,6 +17,7 @@ func resourceVSphereContentLibrary() *schema.Resource {
 		Create: resourceVSphereContentLibraryCreate,
 		Delete: resourceVSphereContentLibraryDelete,
 		Read:   resourceVSphereContentLibraryRead,
+		Update: resourceVSphereContentLibraryUpdate,
 		Importer: &schema.ResourceImporter{
 			State: resourceVSphereContentLibraryImport,
 		},
@@ -39,6 +41,7 @@ func resourceVSphereContentLibrary() *schema.Resource {
 				Description: "The name of the content library.",
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			deletionProtectionKey: deletionProtectionSchema(),
 			"publication": {
 				Type:          schema.TypeList,
 				Optional:      true,
@@ -175,6 +178,12 @@ func resourceVSphereContentLibraryCreate(d *schema.ResourceData, meta interface{
 	return resourceVSphereContentLibraryRead(d, meta)
 }
 
+// resourceVSphereContentLibraryUpdate only handles deletion_protection, all
+// other attributes force a new content library.
+func resourceVSphereContentLibraryUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceVSphereContentLibraryRead(d, meta)
+}
+
 func resourceVSphereContentLibraryDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] resourceVSphereContentLibraryDelete : Deleting Content Library (%s)", d.Id())
 	c := meta.(*Client).restClient
@@ -182,6 +191,9 @@ func resourceVSphereContentLibraryDelete(d *schema.ResourceData, meta interface{
 	if err != nil {
 		return err
 	}
+	if err := checkDeletionProtection(d, meta, types.ManagedObjectReference{Type: vSphereContentLibraryType, Value: lib.ID}); err != nil {
+		return err
+	}
 	log.Printf("[DEBUG] resourceVSphereContentLibraryDelete : Content Library (%s) deleted", d.Id())
 	return contentlibrary.DeleteLibrary(c, lib)
 }
@@ -196,5 +208,6 @@ func resourceVSphereContentLibraryImport(d *schema.ResourceData, meta interface{
 	if err != nil {
 		return nil, err
 	}
+	_ = d.Set(deletionProtectionKey, false)
 	return []*schema.ResourceData{d}, nil
 }
@@ -10,11 +10,13 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/contentlibrary"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/virtualmachine"
 )
 
 func resourceVSphereContentLibraryItem() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Create: resourceVSphereContentLibraryItemCreate,
 		Delete: resourceVSphereContentLibraryItemDelete,
 		Read:   resourceVSphereContentLibraryItemRead,
@@ -51,7 +53,7 @@ func resourceVSphereContentLibraryItem() *schema.Resource {
 				Optional:      true,
 				ForceNew:      true,
 				Description:   "ID of source VM of content library item.",
-				ConflictsWith: []string{"source_uuid"},
+				ConflictsWith: []string{"source_uuid", "source_datastore_id"},
 			},
 			"type": {
 				Type:        schema.TypeString,
@@ -65,10 +67,39 @@ func resourceVSphereContentLibraryItem() *schema.Resource {
 				Optional:      true,
 				ForceNew:      true,
 				Description:   "The managed object ID of an existing VM to be cloned to the content library.",
-				ConflictsWith: []string{"file_url"},
+				ConflictsWith: []string{"file_url", "source_datastore_id"},
 			},
+			"allow_unverified_ssl_cert": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Allow unverified ssl certificates while importing the item from file_url.",
+				// Items imported before the option existed have no value for it.
+				DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
+					return old == "" && new == "false"
+				},
+			},
+			"source_datastore_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "The ID of the datastore that holds the file to be uploaded to the content library.",
+				ConflictsWith: []string{"file_url", "source_uuid"},
+				RequiredWith:  []string{"source_datastore_path"},
+			},
+			"source_datastore_path": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "The path to the file on the datastore in source_datastore_id.",
+				RequiredWith: []string{"source_datastore_id"},
+			},
+			"ovf_signer": ovfdeploy.SignerSchema(),
 		},
 	}
+	structure.MergeSchema(r.Schema, ovfdeploy.SignatureSchema())
+	return r
 }
 
 func resourceVSphereContentLibraryItemUpgradeV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
@@ -121,11 +152,37 @@ func resourceVSphereContentLibraryItemCreate(d *schema.ResourceData, meta interf
 			return err
 		}
 	}
-	id, err := contentlibrary.CreateLibraryItem(rc, lib, d.Get("name").(string), d.Get("description").(string), d.Get("type").(string), d.Get("file_url").(string), moid.MOID)
+	file := d.Get("file_url").(string)
+	if c := meta.(*Client).ovfDownloadCache; c != nil && strings.HasPrefix(file, "http") && strings.HasSuffix(file, ".ova") {
+		// Remote OVFs are pulled by vCenter, so only OVAs, which are streamed
+		// through the provider, are cached.
+		localPath, err := c.LocalPath(file, true, d.Get("allow_unverified_ssl_cert").(bool))
+		if err != nil {
+			log.Printf("[WARN] Not using the ovf download cache for %s: %s", file, err)
+		} else {
+			file = localPath
+		}
+	}
+	var src ovfdeploy.Source
+	if dsID, ok := d.GetOk("source_datastore_id"); ok {
+		dsSrc, err := ovfdeploy.NewDatastoreSource(meta.(*Client).vimClient, dsID.(string), d.Get("source_datastore_path").(string))
+		if err != nil {
+			return err
+		}
+		src = dsSrc
+	}
+	id, signer, err := contentlibrary.CreateLibraryItem(rc, lib, d.Get("name").(string), d.Get("description").(string), d.Get("type").(string), file, src, moid.MOID,
+		d.Get("allow_unverified_ssl_cert").(bool), ovfdeploy.SignatureOptions{
+			Policy:          d.Get("ovf_signature_policy").(string),
+			TrustedCABundle: d.Get("ovf_trusted_ca_bundle").(string),
+		}, meta.(*Client).ovfUploadOptions)
 	if err != nil {
 		return err
 	}
 	d.SetId(*id)
+	if err = d.Set("ovf_signer", ovfdeploy.FlattenSigner(signer)); err != nil {
+		return err
+	}
 	log.Printf("[DEBUG] resourceVSphereContentLibraryItemCreate : Content Library item (%s) creation complete", d.Get("name").(string))
 	return resourceVSphereContentLibraryItemRead(d, meta)
 }
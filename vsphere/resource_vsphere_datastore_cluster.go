@@ -190,6 +190,7 @@ func resourceVSphereDatastoreCluster() *schema.Resource {
 			},
 			vSphereTagAttributeKey:    tagsSchema(),
 			customattribute.ConfigKey: customattribute.ConfigSchema(),
+			deletionProtectionKey:     deletionProtectionSchema(),
 		},
 	}
 }
@@ -284,6 +285,9 @@ func resourceVSphereDatastoreClusterDelete(d *schema.ResourceData, meta interfac
 	if err != nil {
 		return err
 	}
+	if err := checkDeletionProtection(d, meta, pod.Reference()); err != nil {
+		return err
+	}
 
 	// Very similar to how we handle folders, we don't delete a storage pod if
 	// there is child items in it. If there is, we fail with an error that
@@ -314,6 +318,7 @@ func resourceVSphereDatastoreClusterImport(d *schema.ResourceData, meta interfac
 
 	d.SetId(pod.Reference().Value)
 	_ = d.Set("datacenter_id", dc.Reference().Value)
+	_ = d.Set(deletionProtectionKey, resourceVSphereDatastoreCluster().Schema[deletionProtectionKey].Default)
 	return []*schema.ResourceData{d}, nil
 }
 
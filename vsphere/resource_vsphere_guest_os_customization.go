@@ -16,14 +16,19 @@ import (
 
 func resourceVSphereGuestOsCustomization() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceVSphereGuestOsCustomizationCreate,
-		Read:   resourceVSphereGuestOsCustomizationRead,
-		Update: resourceVSphereGuestOsCustomizationUpdate,
-		Delete: resourceVSphereGuestOsCustomizationDelete,
-		Schema: getSchema(),
+		Create:        resourceVSphereGuestOsCustomizationCreate,
+		Read:          resourceVSphereGuestOsCustomizationRead,
+		Update:        resourceVSphereGuestOsCustomizationUpdate,
+		Delete:        resourceVSphereGuestOsCustomizationDelete,
+		CustomizeDiff: resourceVSphereGuestOsCustomizationCustomizeDiff,
+		Schema:        getSchema(),
 	}
 }
 
+func resourceVSphereGuestOsCustomizationCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	return guestoscustomizations.ValidateDomainAdminPassword(d, false)
+}
+
 func resourceVSphereGuestOsCustomizationRead(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client).vimClient
 	specItem, err := guestoscustomizations.FromName(client, d.Id())
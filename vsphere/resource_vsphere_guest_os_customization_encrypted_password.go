@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/guestoscustomizations"
+)
+
+func resourceVSphereGuestOSCustomizationEncryptedPassword() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereGuestOSCustomizationEncryptedPasswordCreate,
+		Read:   resourceVSphereGuestOSCustomizationEncryptedPasswordRead,
+		Delete: resourceVSphereGuestOSCustomizationEncryptedPasswordDelete,
+		Schema: map[string]*schema.Schema{
+			"password": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Sensitive:   true,
+				StateFunc:   guestOSCustomizationPasswordHash,
+				Description: "The password to encrypt with the public key of the vCenter Server. Only a SHA-256 hash of the password is stored in the state.",
+			},
+			"encrypted_password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The password encrypted with the public key of the vCenter Server, suitable for use in the admin_password_encrypted and domain_admin_password_encrypted customization options.",
+			},
+		},
+	}
+}
+
+func resourceVSphereGuestOSCustomizationEncryptedPasswordCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] Beginning encryption of a guest customization password")
+	client := meta.(*Client).vimClient
+	key, err := guestoscustomizations.EncryptionKey(client)
+	if err != nil {
+		return fmt.Errorf("error fetching customization encryption key: %s", err)
+	}
+	encrypted, err := guestoscustomizations.EncryptPassword(key, d.Get("password").(string))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(guestOSCustomizationKeyFingerprint(key))
+	if err := d.Set("encrypted_password", encrypted); err != nil {
+		return err
+	}
+	return resourceVSphereGuestOSCustomizationEncryptedPasswordRead(d, meta)
+}
+
+// resourceVSphereGuestOSCustomizationEncryptedPasswordRead keeps the
+// ciphertext in the state, as the encryption is padded with random data and
+// would change on every read. The resource is only recreated, by removing it
+// from the state, when the public key of the vCenter Server changed.
+func resourceVSphereGuestOSCustomizationEncryptedPasswordRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	key, err := guestoscustomizations.EncryptionKey(client)
+	if err != nil {
+		return fmt.Errorf("error fetching customization encryption key: %s", err)
+	}
+	if fingerprint := guestOSCustomizationKeyFingerprint(key); fingerprint != d.Id() {
+		log.Printf("[DEBUG] Customization encryption key changed from %s to %s, the password must be encrypted again", d.Id(), fingerprint)
+		d.SetId("")
+	}
+	return nil
+}
+
+func resourceVSphereGuestOSCustomizationEncryptedPasswordDelete(d *schema.ResourceData, _ interface{}) error {
+	d.SetId("")
+	return nil
+}
+
+// guestOSCustomizationKeyFingerprint returns the SHA-256 hash of the
+// customization public key of a vCenter Server, in hexadecimal.
+func guestOSCustomizationKeyFingerprint(key []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(key))
+}
+
+// guestOSCustomizationPasswordHash stores the SHA-256 hash of the password in
+// the state instead of the password itself.
+func guestOSCustomizationPasswordHash(v interface{}) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(v.(string))))
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccResourceVSphereGuestOSCustomizationEncryptedPassword_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereGuestOSCustomizationEncryptedPasswordConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("vsphere_guest_os_customization_encrypted_password.password", "encrypted_password", regexp.MustCompile("^[A-Za-z0-9+/]+=*$")),
+					resource.TestCheckResourceAttr("vsphere_guest_os_customization_encrypted_password.password", "password", guestOSCustomizationPasswordHash("VMware1!")),
+				),
+			},
+			{
+				// The ciphertext must not change without a change to the password.
+				Config:   testAccResourceVSphereGuestOSCustomizationEncryptedPasswordConfig(),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestResourceVSphereGuestOSCustomizationEncryptedPasswordHash(t *testing.T) {
+	diff, err := resourceVSphereGuestOSCustomizationEncryptedPassword().Diff(
+		context.Background(),
+		nil,
+		terraform.NewResourceConfigRaw(map[string]interface{}{"password": "VMware1!"}),
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attr, ok := diff.Attributes["password"]
+	if !ok {
+		t.Fatal("expected a diff for password")
+	}
+	if expected := fmt.Sprintf("%x", sha256.Sum256([]byte("VMware1!"))); attr.New != expected {
+		t.Fatalf("expected the hash of the password %q in the state, got %q", expected, attr.New)
+	}
+}
+
+func testAccResourceVSphereGuestOSCustomizationEncryptedPasswordConfig() string {
+	return `
+resource "vsphere_guest_os_customization_encrypted_password" "password" {
+  password = "VMware1!"
+}
+`
+}
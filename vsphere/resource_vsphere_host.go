@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/provider"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/clustercomputeresource"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/customattribute"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
 	"github.com/vmware/govmomi/license"
 	"github.com/vmware/govmomi/object"
@@ -72,6 +74,7 @@ func resourceVsphereHost() *schema.Resource {
 			"thumbprint": {
 				Type:        schema.TypeString,
 				Optional:    true,
+				Computed:    true,
 				Description: "Host's certificate SHA-1 thumbprint. If not set then the CA that signed the host's certificate must be trusted.",
 			},
 			"license": {
@@ -104,6 +107,26 @@ func resourceVsphereHost() *schema.Resource {
 				Default:      "disabled",
 				ValidateFunc: validation.StringInSlice([]string{"disabled", "normal", "strict"}, true),
 			},
+			"lockdown_exception_users": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Description: "The users that keep their permissions when the host is in lockdown mode, such as backup and monitoring service accounts. If not set, the exception users are not managed.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"dcui_access_users": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Description: "The users that can log in to the Direct Console User Interface when the host is in normal lockdown mode, set in the DCUI.Access advanced option. If not set, the option is not managed.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.All(validation.StringIsNotWhiteSpace, validation.StringDoesNotContainAny(",")),
+				},
+			},
 
 			// Tagging
 			vSphereTagAttributeKey: tagsSchema(),
@@ -237,23 +260,9 @@ func resourceVsphereHostCreate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
-	lockdownModeString := d.Get("lockdown").(string)
-	lockdownMode, err := hostLockdownType(lockdownModeString)
-	if err != nil {
-		return err
-	}
-
 	if connectedState {
-		hostProps, err := hostsystem.Properties(host)
-		if err != nil {
-			return fmt.Errorf("error while retrieving properties for host %s. Error: %s", hostID, err)
-		}
-
-		hamRef := hostProps.ConfigManager.HostAccessManager.Reference()
-		ham := NewHostAccessManager(client.Client, hamRef)
-		err = ham.ChangeLockdownMode(context.TODO(), lockdownMode)
-		if err != nil {
-			return fmt.Errorf("error while changing lockdown mode for host %s. Error: %s", hostID, err)
+		if err := resourceVSphereHostApplyLockdown(d, meta); err != nil {
+			return err
 		}
 	}
 
@@ -320,6 +329,11 @@ func resourceVsphereHostRead(d *schema.ResourceData, meta interface{}) error {
 	}
 	_ = d.Set("connected", true)
 
+	// The thumbprint changes when the certificate of the host is replaced.
+	if thumbprint := host.Summary.Config.SslThumbprint; thumbprint != "" && !strings.EqualFold(thumbprint, d.Get("thumbprint").(string)) {
+		_ = d.Set("thumbprint", thumbprint)
+	}
+
 	lockdownMode, err := hostLockdownString(host.Config.LockdownMode)
 	if err != nil {
 		return err
@@ -328,6 +342,24 @@ func resourceVsphereHostRead(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("Setting lockdown to %s", lockdownMode)
 	_ = d.Set("lockdown", lockdownMode)
 
+	ham := NewHostAccessManager(client.Client, host.ConfigManager.HostAccessManager.Reference())
+	exceptionUsers, err := ham.QueryLockdownExceptions(context.TODO())
+	if err != nil {
+		return fmt.Errorf("error while retrieving lockdown exception users for host %s. Error: %s", hostID, err)
+	}
+	managedExceptionUsers := structure.SliceInterfacesToStrings(d.Get("lockdown_exception_users").(*schema.Set).List())
+	if err := d.Set("lockdown_exception_users", hostLockdownUserExceptions(exceptionUsers, managedExceptionUsers)); err != nil {
+		return fmt.Errorf("error setting lockdown_exception_users: %s", err)
+	}
+
+	dcuiUsers, err := hostDcuiAccessUsers(client, hostID)
+	if err != nil {
+		return err
+	}
+	if err := d.Set("dcui_access_users", dcuiUsers); err != nil {
+		return fmt.Errorf("error setting dcui_access_users: %s", err)
+	}
+
 	licenseKey := d.Get("license").(string)
 	if licenseKey != "" {
 		licFound, err := isLicenseAssigned(client.Client, hostID, licenseKey)
@@ -432,7 +464,6 @@ func resourceVsphereHostUpdate(d *schema.ResourceData, meta interface{}) error {
 		"license":     resourceVSphereHostUpdateLicense,
 		"cluster":     resourceVSphereHostUpdateCluster,
 		"maintenance": resourceVSphereHostUpdateMaintenanceMode,
-		"lockdown":    resourceVSphereHostUpdateLockdownMode,
 		"thumbprint":  resourceVSphereHostUpdateThumbprint,
 	}
 	for k, v := range mutableKeys {
@@ -448,6 +479,12 @@ func resourceVsphereHostUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
+	if d.HasChanges("lockdown", "lockdown_exception_users", "dcui_access_users") {
+		if err := resourceVSphereHostApplyLockdown(d, meta); err != nil {
+			return fmt.Errorf("error while updating lockdown: %s", err)
+		}
+	}
+
 	// Apply tags
 	if tagsClient != nil {
 		if err := processTagDiff(tagsClient, d, hostObject); err != nil {
@@ -515,32 +552,83 @@ func resourceVsphereHostDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func resourceVSphereHostUpdateLockdownMode(d *schema.ResourceData, meta, _, newVal interface{}) error {
+// resourceVSphereHostApplyLockdown sets the lockdown mode, the lockdown
+// exception users and the DCUI.Access list of the host. Users that are added
+// get their access before the lockdown mode changes, and users that are
+// removed only lose it afterwards, so that nobody who needs the host is locked
+// out in between.
+func resourceVSphereHostApplyLockdown(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client).vimClient
 	hostID := d.Id()
+	lockdownMode, err := hostLockdownType(d.Get("lockdown").(string))
+	if err != nil {
+		return err
+	}
 	host, err := hostsystem.FromID(client, hostID)
 	if err != nil {
 		return fmt.Errorf("error while retrieving HostSystem object for host ID %s. Error: %s", hostID, err)
 	}
-	lockdownModeString := newVal.(string)
-	lockdownMode, err := hostLockdownType(lockdownModeString)
+	hostProps, err := hostsystem.Properties(host)
 	if err != nil {
-		return err
+		return fmt.Errorf("error while retrieving properties for host %s. Error: %s", hostID, err)
+	}
+	if hostProps.Config == nil || hostProps.ConfigManager.HostAccessManager == nil {
+		return fmt.Errorf("host %s is not connected", hostID)
 	}
+	ham := NewHostAccessManager(client.Client, hostProps.ConfigManager.HostAccessManager.Reference())
 
-	var hostProps mo.HostSystem
-	err = host.Properties(context.TODO(), host.ConfigManager().Reference(), []string{"configManager.hostAccessManager"}, &hostProps)
+	currentExceptionUsers, err := ham.QueryLockdownExceptions(context.TODO())
 	if err != nil {
-		return fmt.Errorf("error while retrieving HostSystem properties for host ID %s. Error: %s", hostID, err)
+		return fmt.Errorf("error while retrieving lockdown exception users for host %s. Error: %s", hostID, err)
 	}
-
-	hamRef := hostProps.ConfigManager.HostAccessManager.Reference()
-	ham := NewHostAccessManager(client.Client, hamRef)
-	err = ham.ChangeLockdownMode(context.TODO(), lockdownMode)
+	exceptionUsers := currentExceptionUsers
+	if !d.GetRawConfig().GetAttr("lockdown_exception_users").IsNull() {
+		configured := structure.SliceInterfacesToStrings(d.Get("lockdown_exception_users").(*schema.Set).List())
+		exceptionUsers = hostLockdownUserUnion(configured, hostLockdownSystemUserExceptions(currentExceptionUsers))
+	}
+	currentDcuiUsers, err := hostDcuiAccessUsers(client, hostID)
 	if err != nil {
-		return fmt.Errorf("error while changing lonckdown mode for host ID %s to %s. Error: %s", hostID, lockdownMode, err)
+		return err
+	}
+	dcuiUsers := currentDcuiUsers
+	if !d.GetRawConfig().GetAttr("dcui_access_users").IsNull() {
+		dcuiUsers = structure.SliceInterfacesToStrings(d.Get("dcui_access_users").(*schema.Set).List())
+	}
+
+	grownExceptionUsers := hostLockdownUserUnion(currentExceptionUsers, exceptionUsers)
+	if !hostLockdownUsersEqual(grownExceptionUsers, currentExceptionUsers) {
+		log.Printf("[DEBUG] Adding lockdown exception users of host %s: %v", hostID, grownExceptionUsers)
+		if err := ham.UpdateLockdownExceptions(context.TODO(), grownExceptionUsers); err != nil {
+			return fmt.Errorf("error while updating lockdown exception users for host %s. Error: %s", hostID, err)
+		}
+	}
+	grownDcuiUsers := hostLockdownUserUnion(currentDcuiUsers, dcuiUsers)
+	if !hostLockdownUsersEqual(grownDcuiUsers, currentDcuiUsers) {
+		log.Printf("[DEBUG] Adding DCUI.Access users of host %s: %v", hostID, grownDcuiUsers)
+		if err := updateHostDcuiAccessUsers(client, hostID, grownDcuiUsers); err != nil {
+			return err
+		}
+	}
+
+	if hostProps.Config.LockdownMode != lockdownMode {
+		log.Printf("[DEBUG] Changing lockdown mode of host %s to %s", hostID, lockdownMode)
+		if err := ham.ChangeLockdownMode(context.TODO(), lockdownMode); err != nil {
+			return fmt.Errorf("error while changing lockdown mode for host ID %s to %s. Error: %s", hostID, lockdownMode, err)
+		}
 	}
 
+	if !hostLockdownUsersEqual(exceptionUsers, grownExceptionUsers) {
+		log.Printf("[DEBUG] Removing lockdown exception users of host %s: %v", hostID, exceptionUsers)
+		if err := ham.UpdateLockdownExceptions(context.TODO(), exceptionUsers); err != nil {
+			return fmt.Errorf("error while updating lockdown exception users for host %s. Error: %s", hostID, err)
+		}
+	}
+	if !hostLockdownUsersEqual(dcuiUsers, grownDcuiUsers) {
+		log.Printf("[DEBUG] Removing DCUI.Access users of host %s: %v", hostID, dcuiUsers)
+		if err := updateHostDcuiAccessUsers(client, hostID, dcuiUsers); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -817,3 +905,23 @@ func (h HostAccessManager) ChangeLockdownMode(ctx context.Context, mode types.Ho
 	_, err := methods.ChangeLockdownMode(ctx, h.Client(), &req)
 	return err
 }
+
+func (h HostAccessManager) QueryLockdownExceptions(ctx context.Context) ([]string, error) {
+	req := types.QueryLockdownExceptions{
+		This: h.Reference(),
+	}
+	res, err := methods.QueryLockdownExceptions(ctx, h.Client(), &req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Returnval, nil
+}
+
+func (h HostAccessManager) UpdateLockdownExceptions(ctx context.Context, users []string) error {
+	req := types.UpdateLockdownExceptions{
+		This:  h.Reference(),
+		Users: users,
+	}
+	_, err := methods.UpdateLockdownExceptions(ctx, h.Client(), &req)
+	return err
+}
@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostAdvancedSettingsName = "vsphere_host_advanced_settings"
+
+func resourceVSphereHostAdvancedSettings() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostAdvancedSettingsCreate,
+		Read:   resourceVSphereHostAdvancedSettingsRead,
+		Update: resourceVSphereHostAdvancedSettingsUpdate,
+		Delete: resourceVSphereHostAdvancedSettingsDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostAdvancedSettingsImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"settings": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "A map of advanced option keys to values. Values are converted to the type of the option.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"exclusive_prefixes": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Advanced option key prefixes, such as Net. or UserVars., that are managed exclusively. The options under these prefixes that are not in settings are reset to their default values.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotEmpty,
+				},
+			},
+		},
+	}
+}
+
+func resourceVSphereHostAdvancedSettingsCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostAdvancedSettingsIDString(d))
+	hsID := d.Get("host_system_id").(string)
+	if err := resourceVSphereHostAdvancedSettingsApply(d, meta, hsID, nil, d.Get("settings").(map[string]interface{})); err != nil {
+		return err
+	}
+	d.SetId(hsID)
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostAdvancedSettingsIDString(d))
+	return resourceVSphereHostAdvancedSettingsRead(d, meta)
+}
+
+func resourceVSphereHostAdvancedSettingsRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostAdvancedSettingsIDString(d))
+	client := meta.(*Client).vimClient
+	om, err := hostOptionManagerFromHostSystemID(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error loading host advanced option manager: %s", err)
+	}
+	defs, values, err := hostOptionManagerProperties(client, om)
+	if err != nil {
+		return err
+	}
+
+	settings := make(map[string]interface{})
+	for k, cv := range d.Get("settings").(map[string]interface{}) {
+		if v, ok := values[k]; ok {
+			settings[k] = hostOptionSettingString(defs[k], cv.(string), v)
+		}
+	}
+	if prefixes := structure.SliceInterfacesToStrings(d.Get("exclusive_prefixes").([]interface{})); len(prefixes) > 0 {
+		// Every other option under the exclusive prefixes that is not at its
+		// default value is managed as well, so that changes made outside of
+		// Terraform show up as a diff.
+		for k, v := range values {
+			def, ok := defs[k]
+			if !ok || hostOptionReadonly(def) || !hostOptionHasPrefix(k, prefixes) {
+				continue
+			}
+			if _, ok := settings[k]; ok {
+				continue
+			}
+			if dv, ok := hostOptionDefault(def); ok && hostOptionString(dv) == hostOptionString(v) {
+				continue
+			}
+			settings[k] = hostOptionString(v)
+		}
+	}
+
+	_ = d.Set("host_system_id", d.Id())
+	if err := d.Set("settings", settings); err != nil {
+		return fmt.Errorf("error setting settings: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostAdvancedSettingsIDString(d))
+	return nil
+}
+
+func resourceVSphereHostAdvancedSettingsUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostAdvancedSettingsIDString(d))
+	o, n := d.GetChange("settings")
+	if err := resourceVSphereHostAdvancedSettingsApply(d, meta, d.Id(), o.(map[string]interface{}), n.(map[string]interface{})); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostAdvancedSettingsIDString(d))
+	return resourceVSphereHostAdvancedSettingsRead(d, meta)
+}
+
+func resourceVSphereHostAdvancedSettingsDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostAdvancedSettingsIDString(d))
+	// Only the options in the state are returned to their defaults, even
+	// with exclusive prefixes.
+	_ = d.Set("exclusive_prefixes", nil)
+	if err := resourceVSphereHostAdvancedSettingsApply(d, meta, d.Id(), d.Get("settings").(map[string]interface{}), nil); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostAdvancedSettingsIDString(d))
+	return nil
+}
+
+func resourceVSphereHostAdvancedSettingsImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client).vimClient
+	hs, err := hostsystem.FromID(client, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error locating host system: %s", err)
+	}
+	_ = d.Set("host_system_id", hs.Reference().Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostAdvancedSettingsApply sets the options in newSettings,
+// converted to the types in their definitions, and resets the options that
+// are in oldSettings but no longer in newSettings to their defaults. All
+// other options under the exclusive prefixes that are not at their defaults
+// are reset as well. All conversion problems are reported before anything is
+// changed.
+func resourceVSphereHostAdvancedSettingsApply(d *schema.ResourceData, meta interface{}, hsID string, oldSettings, newSettings map[string]interface{}) error {
+	client := meta.(*Client).vimClient
+	om, err := hostOptionManagerFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host advanced option manager: %s", err)
+	}
+	defs, values, err := hostOptionManagerProperties(client, om)
+	if err != nil {
+		return err
+	}
+
+	reset := make(map[string]bool)
+	for k := range oldSettings {
+		reset[k] = true
+	}
+	prefixes := structure.SliceInterfacesToStrings(d.Get("exclusive_prefixes").([]interface{}))
+	for k := range values {
+		if hostOptionHasPrefix(k, prefixes) {
+			reset[k] = true
+		}
+	}
+
+	var problems []string
+	var opts []types.BaseOptionValue
+	for k, v := range newSettings {
+		def, ok := defs[k]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: not a supported advanced option on this host", k))
+			continue
+		}
+		value, err := hostOptionValue(def, v.(string))
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if hostOptionString(value) != hostOptionString(values[k]) {
+			opts = append(opts, &types.OptionValue{Key: k, Value: value})
+		}
+	}
+	for k := range reset {
+		if _, ok := newSettings[k]; ok {
+			continue
+		}
+		def, ok := defs[k]
+		if !ok || hostOptionReadonly(def) {
+			continue
+		}
+		dv, ok := hostOptionDefault(def)
+		if !ok || hostOptionString(dv) == hostOptionString(values[k]) {
+			continue
+		}
+		opts = append(opts, &types.OptionValue{Key: k, Value: dv})
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid advanced settings:\n- %s", strings.Join(problems, "\n- "))
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	log.Printf("[DEBUG] %s: Updating %d advanced options", resourceVSphereHostAdvancedSettingsIDString(d), len(opts))
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := om.Update(ctx, opts); err != nil {
+		return fmt.Errorf("error updating advanced options: %s", err)
+	}
+	return nil
+}
+
+// resourceVSphereHostAdvancedSettingsIDString prints a friendly string for
+// the vsphere_host_advanced_settings resource.
+func resourceVSphereHostAdvancedSettingsIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostAdvancedSettingsName)
+}
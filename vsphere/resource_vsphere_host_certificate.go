@@ -0,0 +1,279 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/object"
+)
+
+const resourceVSphereHostCertificateName = "vsphere_host_certificate"
+
+func resourceVSphereHostCertificate() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostCertificateCreate,
+		Read:   resourceVSphereHostCertificateRead,
+		Update: resourceVSphereHostCertificateUpdate,
+		Delete: resourceVSphereHostCertificateDelete,
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"certificate": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "The PEM encoded certificate to install on the host, signed from a request generated by the host.",
+				ValidateFunc: validateHostCertificatePEM,
+			},
+			"ca_certificates": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The PEM encoded CA certificates to be trusted by the host, in addition to the CA certificates that the host already trusts.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateHostCertificatePEM,
+				},
+				Set: func(v interface{}) int {
+					return schema.HashString(normalizeHostCertificatePEM(v))
+				},
+			},
+			"reconnect": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Reconnect the host to vCenter Server with the thumbprint of the new certificate after it is installed.",
+			},
+			"thumbprint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA-1 thumbprint of the certificate of the host.",
+			},
+			"subject": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The subject of the certificate of the host.",
+			},
+			"issuer": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The issuer of the certificate of the host.",
+			},
+			"not_before": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The start of the validity of the certificate of the host, in RFC3339 format.",
+			},
+			"not_after": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The expiry of the certificate of the host, in RFC3339 format.",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The status of the certificate of the host in vCenter Server, ie: good or expiring.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostCertificateCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostCertificateIDString(d))
+	hsID := d.Get("host_system_id").(string)
+	if err := resourceVSphereHostCertificateApply(d, meta, hsID, true); err != nil {
+		return err
+	}
+	d.SetId(hsID)
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostCertificateIDString(d))
+	return resourceVSphereHostCertificateRead(d, meta)
+}
+
+func resourceVSphereHostCertificateRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostCertificateIDString(d))
+	client := meta.(*Client).vimClient
+	cm, err := hostCertificateManagerFromHostSystemID(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error loading host certificate manager: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	info, err := cm.CertificateInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading host certificate information: %s", err)
+	}
+
+	// The thumbprint of the host is only known to vCenter Server, and is only
+	// current there once the host has been reconnected. Otherwise, it is taken
+	// from the configured certificate.
+	var configured string
+	if certs, err := parseHostCertificatePEM(d.Get("certificate").(string)); err == nil {
+		configured = hostCertificateThumbprint(certs[0])
+	}
+	thumbprint := info.ThumbprintSHA1
+	if thumbprint == "" || !d.Get("reconnect").(bool) {
+		thumbprint = configured
+	}
+	if !strings.EqualFold(thumbprint, configured) {
+		log.Printf("[DEBUG] %s: Host certificate thumbprint %s does not match the configured certificate", resourceVSphereHostCertificateIDString(d), thumbprint)
+		_ = d.Set("certificate", "")
+	}
+	_ = d.Set("host_system_id", d.Id())
+	_ = d.Set("thumbprint", thumbprint)
+	_ = d.Set("subject", info.Subject)
+	_ = d.Set("issuer", info.Issuer)
+	_ = d.Set("status", info.Status)
+	_ = d.Set("not_before", "")
+	if info.NotBefore != nil {
+		_ = d.Set("not_before", info.NotBefore.Format(time.RFC3339))
+	}
+	_ = d.Set("not_after", "")
+	if info.NotAfter != nil {
+		_ = d.Set("not_after", info.NotAfter.Format(time.RFC3339))
+	}
+
+	// Only the managed CA certificates that are still trusted by the host are
+	// saved, as the host trusts other CA certificates as well.
+	if v, ok := d.GetOk("ca_certificates"); ok {
+		caCerts, err := cm.ListCACertificates(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing trusted CA certificates: %s", err)
+		}
+		trusted := make(map[string]bool)
+		for _, c := range caCerts {
+			trusted[normalizeHostCertificatePEM(c)] = true
+		}
+		var managed []string
+		for _, c := range v.(*schema.Set).List() {
+			if trusted[normalizeHostCertificatePEM(c)] {
+				managed = append(managed, c.(string))
+			}
+		}
+		if err := d.Set("ca_certificates", managed); err != nil {
+			return fmt.Errorf("error setting ca_certificates: %s", err)
+		}
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostCertificateIDString(d))
+	return nil
+}
+
+func resourceVSphereHostCertificateUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostCertificateIDString(d))
+	if err := resourceVSphereHostCertificateApply(d, meta, d.Id(), false); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostCertificateIDString(d))
+	return resourceVSphereHostCertificateRead(d, meta)
+}
+
+func resourceVSphereHostCertificateDelete(d *schema.ResourceData, _ interface{}) error {
+	// A host always has a certificate, so the certificate and the trusted CA
+	// certificates are left in place.
+	log.Printf("[DEBUG] %s: Removing from state", resourceVSphereHostCertificateIDString(d))
+	return nil
+}
+
+// resourceVSphereHostCertificateApply adds the trusted CA certificates to the
+// host, then installs the certificate and reconnects the host to vCenter
+// Server with its new thumbprint. The CA certificates are pushed first, so
+// that the host trusts the chain of the certificate when it is installed.
+func resourceVSphereHostCertificateApply(d *schema.ResourceData, meta interface{}, hsID string, create bool) error {
+	client := meta.(*Client).vimClient
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error locating host system: %s", err)
+	}
+	cm, err := hostCertificateManagerFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host certificate manager: %s", err)
+	}
+
+	if (create && d.Get("ca_certificates").(*schema.Set).Len() > 0) || (!create && d.HasChange("ca_certificates")) {
+		o, n := d.GetChange("ca_certificates")
+		add := structure.SliceInterfacesToStrings(n.(*schema.Set).List())
+		remove := structure.SliceInterfacesToStrings(o.(*schema.Set).Difference(n.(*schema.Set)).List())
+		if err := resourceVSphereHostCertificateUpdateCACertificates(d, cm, add, remove); err != nil {
+			return err
+		}
+	}
+	if create || d.HasChange("certificate") {
+		cert := d.Get("certificate").(string)
+		certs, err := parseHostCertificatePEM(cert)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate: %s", err)
+		}
+		thumbprint := hostCertificateThumbprint(certs[0])
+		log.Printf("[DEBUG] %s: Installing certificate with thumbprint %s", resourceVSphereHostCertificateIDString(d), thumbprint)
+		ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+		defer cancel()
+		if err := cm.InstallServerCertificate(ctx, cert); err != nil {
+			return fmt.Errorf("error installing certificate: %s", err)
+		}
+		if d.Get("reconnect").(bool) {
+			if err := reconnectHostWithThumbprint(client, hs, thumbprint); err != nil {
+				return fmt.Errorf("error reconnecting host after installing certificate: %s", err)
+			}
+		}
+	}
+	return nil
+}
+
+// resourceVSphereHostCertificateUpdateCACertificates adds the certificates
+// in add to the CA certificates trusted by the host, and removes the ones in
+// remove, which were previously managed by the resource. The other CA
+// certificates trusted by the host, including the VMCA root certificate, and
+// its certificate revocation lists are kept.
+func resourceVSphereHostCertificateUpdateCACertificates(d *schema.ResourceData, cm *object.HostCertificateManager, add, remove []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	current, err := cm.ListCACertificates(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing trusted CA certificates: %s", err)
+	}
+	caCerts, changed := mergeHostCACertificates(current, add, remove)
+	if !changed {
+		return nil
+	}
+	log.Printf("[DEBUG] %s: Updating trusted CA certificates (%d to %d)", resourceVSphereHostCertificateIDString(d), len(current), len(caCerts))
+	crls, err := cm.ListCACertificateRevocationLists(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing CA certificate revocation lists: %s", err)
+	}
+	if err := cm.ReplaceCACertificatesAndCRLs(ctx, caCerts, crls); err != nil {
+		return fmt.Errorf("error updating trusted CA certificates: %s", err)
+	}
+	return nil
+}
+
+// normalizeHostCertificatePEM re-encodes PEM certificates, so that
+// certificates read from the host compare equal to the configured ones
+// regardless of their formatting.
+func normalizeHostCertificatePEM(v interface{}) string {
+	certs, err := parseHostCertificatePEM(v.(string))
+	if err != nil {
+		return v.(string)
+	}
+	var s string
+	for _, cert := range certs {
+		s += hostCertificatePEM(cert)
+	}
+	return s
+}
+
+// resourceVSphereHostCertificateIDString prints a friendly string for the
+// vsphere_host_certificate resource.
+func resourceVSphereHostCertificateIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostCertificateName)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+)
+
+const resourceVSphereHostCertificateSigningRequestName = "vsphere_host_certificate_signing_request"
+
+func resourceVSphereHostCertificateSigningRequest() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostCertificateSigningRequestCreate,
+		Read:   resourceVSphereHostCertificateSigningRequestRead,
+		Delete: resourceVSphereHostCertificateSigningRequestDelete,
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"use_ip_address_as_common_name": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ForceNew:      true,
+				Description:   "Use the IP address of the host as the common name of the request, instead of its host name.",
+				ConflictsWith: []string{"distinguished_name"},
+			},
+			"distinguished_name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "The distinguished name to use as the subject of the request, ie: CN=esxi-01.example.com,O=Example. If not set, the host generates the subject.",
+				ConflictsWith: []string{"use_ip_address_as_common_name"},
+			},
+			"csr_pem": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The PEM encoded certificate signing request.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostCertificateSigningRequestCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostCertificateSigningRequestIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Get("host_system_id").(string)
+	cm, err := hostCertificateManagerFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host certificate manager: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	var csr string
+	if dn, ok := d.GetOk("distinguished_name"); ok {
+		csr, err = cm.GenerateCertificateSigningRequestByDn(ctx, dn.(string))
+	} else {
+		csr, err = cm.GenerateCertificateSigningRequest(ctx, d.Get("use_ip_address_as_common_name").(bool))
+	}
+	if err != nil {
+		return fmt.Errorf("error generating certificate signing request: %s", err)
+	}
+
+	d.SetId(hsID)
+	_ = d.Set("csr_pem", csr)
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostCertificateSigningRequestIDString(d))
+	return resourceVSphereHostCertificateSigningRequestRead(d, meta)
+}
+
+func resourceVSphereHostCertificateSigningRequestRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostCertificateSigningRequestIDString(d))
+	// The host does not keep the request, so only the existence of the host
+	// is checked.
+	client := meta.(*Client).vimClient
+	if _, err := hostsystem.FromID(client, d.Id()); err != nil {
+		if viapi.IsManagedObjectNotFoundError(err) {
+			log.Printf("[DEBUG] %s: Host not found, removing from state", resourceVSphereHostCertificateSigningRequestIDString(d))
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error locating host system: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostCertificateSigningRequestIDString(d))
+	return nil
+}
+
+func resourceVSphereHostCertificateSigningRequestDelete(d *schema.ResourceData, _ interface{}) error {
+	log.Printf("[DEBUG] %s: Removing from state", resourceVSphereHostCertificateSigningRequestIDString(d))
+	return nil
+}
+
+// resourceVSphereHostCertificateSigningRequestIDString prints a friendly
+// string for the vsphere_host_certificate_signing_request resource.
+func resourceVSphereHostCertificateSigningRequestIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostCertificateSigningRequestName)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostCertificateSigningRequest_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostCertificateSigningRequestPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostCertificateSigningRequestConfig(`
+  distinguished_name = "CN=terraform-test,O=Terraform"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr(
+						"vsphere_host_certificate_signing_request.csr", "csr_pem",
+						regexp.MustCompile("BEGIN CERTIFICATE REQUEST"),
+					),
+				),
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostCertificateSigningRequestPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_certificate_signing_request acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostCertificateSigningRequestConfig(args string) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_certificate_signing_request" "csr" {
+  host_system_id = data.vsphere_host.esxi_host.id
+%s}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		args,
+	)
+}
@@ -0,0 +1,210 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostDNSName = "vsphere_host_dns"
+
+func resourceVSphereHostDNS() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereHostDNSCreate,
+		Read:          resourceVSphereHostDNSRead,
+		Update:        resourceVSphereHostDNSUpdate,
+		Delete:        resourceVSphereHostDNSDelete,
+		CustomizeDiff: resourceVSphereHostDNSCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostDNSImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"host_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The host name of the host.",
+			},
+			"domain_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The domain name of the host.",
+			},
+			"dhcp": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Obtain the DNS servers and search domains through DHCP on dhcp_virtual_nic. When false, dns_servers and search_domains are used.",
+			},
+			"dhcp_virtual_nic": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The VMkernel network adapter used to obtain the DNS configuration through DHCP, ie: vmk0. Required when dhcp is true.",
+			},
+			"dhcp_ipv6_virtual_nic": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The VMkernel network adapter used to obtain the DNS configuration through DHCPv6.",
+			},
+			"dns_servers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				Description: "The IP addresses of the DNS servers, in order of preference. Cannot be set when dhcp is true.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"search_domains": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				Description: "The domains in which to search for hosts, in order of preference. Cannot be set when dhcp is true.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceVSphereHostDNSCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostDNSIDString(d))
+	hsID := d.Get("host_system_id").(string)
+	if err := resourceVSphereHostDNSApply(d, meta, hsID); err != nil {
+		return err
+	}
+	d.SetId(hsID)
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostDNSIDString(d))
+	return resourceVSphereHostDNSRead(d, meta)
+}
+
+func resourceVSphereHostDNSRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostDNSIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Id()
+	ns, err := hostNetworkSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host network system: %s", err)
+	}
+	config, err := hostDNSConfig(client, ns)
+	if err != nil {
+		return err
+	}
+
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("host_name", config.HostName)
+	_ = d.Set("domain_name", config.DomainName)
+	_ = d.Set("dhcp", config.Dhcp)
+	// The adapters are only meaningful, and only managed, in DHCP mode.
+	if config.Dhcp {
+		_ = d.Set("dhcp_virtual_nic", config.VirtualNicDevice)
+		_ = d.Set("dhcp_ipv6_virtual_nic", config.Ipv6VirtualNicDevice)
+	} else {
+		_ = d.Set("dhcp_virtual_nic", "")
+		_ = d.Set("dhcp_ipv6_virtual_nic", "")
+	}
+	if err := d.Set("dns_servers", config.Address); err != nil {
+		return fmt.Errorf("error setting dns_servers: %s", err)
+	}
+	if err := d.Set("search_domains", config.SearchDomain); err != nil {
+		return fmt.Errorf("error setting search_domains: %s", err)
+	}
+
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostDNSIDString(d))
+	return nil
+}
+
+func resourceVSphereHostDNSUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostDNSIDString(d))
+	if err := resourceVSphereHostDNSApply(d, meta, d.Id()); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostDNSIDString(d))
+	return resourceVSphereHostDNSRead(d, meta)
+}
+
+// resourceVSphereHostDNSDelete only removes the resource from the state. The
+// network identity of a host cannot be reset to a meaningful default, so the
+// host keeps its current configuration.
+func resourceVSphereHostDNSDelete(d *schema.ResourceData, _ interface{}) error {
+	log.Printf("[DEBUG] %s: Removing from state, the host keeps its DNS configuration", resourceVSphereHostDNSIDString(d))
+	d.SetId("")
+	return nil
+}
+
+func resourceVSphereHostDNSImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client).vimClient
+	hs, err := hostsystem.FromID(client, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error locating host system: %s", err)
+	}
+	_ = d.Set("host_system_id", hs.Reference().Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVSphereHostDNSCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	dhcp := d.Get("dhcp").(bool)
+	nic := d.Get("dhcp_virtual_nic").(string)
+	raw := d.GetRawConfig()
+	switch {
+	case dhcp && nic == "" && raw.GetAttr("dhcp_virtual_nic").IsKnown():
+		return fmt.Errorf("dhcp_virtual_nic must be set when dhcp is true")
+	case dhcp && !raw.GetAttr("dns_servers").IsNull():
+		return fmt.Errorf("dns_servers cannot be set when dhcp is true")
+	case dhcp && !raw.GetAttr("search_domains").IsNull():
+		return fmt.Errorf("search_domains cannot be set when dhcp is true")
+	case !dhcp && !raw.GetAttr("dhcp_virtual_nic").IsNull():
+		return fmt.Errorf("dhcp_virtual_nic can only be set when dhcp is true")
+	case !dhcp && !raw.GetAttr("dhcp_ipv6_virtual_nic").IsNull():
+		return fmt.Errorf("dhcp_ipv6_virtual_nic can only be set when dhcp is true")
+	}
+	return nil
+}
+
+// resourceVSphereHostDNSApply sends the DNS configuration in the resource
+// data to the host.
+func resourceVSphereHostDNSApply(d *schema.ResourceData, meta interface{}, hsID string) error {
+	client := meta.(*Client).vimClient
+	ns, err := hostNetworkSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host network system: %s", err)
+	}
+
+	config := &types.HostDnsConfig{
+		Dhcp:       d.Get("dhcp").(bool),
+		HostName:   d.Get("host_name").(string),
+		DomainName: d.Get("domain_name").(string),
+	}
+	if config.Dhcp {
+		config.VirtualNicDevice = d.Get("dhcp_virtual_nic").(string)
+		config.Ipv6VirtualNicDevice = d.Get("dhcp_ipv6_virtual_nic").(string)
+	} else {
+		config.Address = structure.SliceInterfacesToStrings(d.Get("dns_servers").([]interface{}))
+		config.SearchDomain = structure.SliceInterfacesToStrings(d.Get("search_domains").([]interface{}))
+	}
+
+	log.Printf("[DEBUG] %s: Updating DNS configuration (DHCP: %t)", resourceVSphereHostDNSIDString(d), config.Dhcp)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := ns.UpdateDnsConfig(ctx, config); err != nil {
+		return fmt.Errorf("error updating DNS configuration: %s", err)
+	}
+	return nil
+}
+
+// resourceVSphereHostDNSIDString prints a friendly string for the
+// vsphere_host_dns resource.
+func resourceVSphereHostDNSIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostDNSName)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostDNS_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostDNSPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostDNSConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_dns.dns", "dhcp", "false"),
+					resource.TestCheckResourceAttr("vsphere_host_dns.dns", "dns_servers.#", "1"),
+					resource.TestCheckResourceAttr("vsphere_host_dns.dns", "dns_servers.0", os.Getenv("TF_VAR_VSPHERE_ESXI_DNS_SERVER")),
+					resource.TestCheckResourceAttr("vsphere_host_dns.dns", "search_domains.0", os.Getenv("TF_VAR_VSPHERE_ESXI_DOMAIN")),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_dns.dns",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccResourceVSphereHostDNS_dhcpWithServers(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostDNSPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccResourceVSphereHostDNSConfigDHCPWithServers(),
+				ExpectError: regexp.MustCompile("dns_servers cannot be set when dhcp is true"),
+				PlanOnly:    true,
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostDNSPreCheck(t *testing.T) {
+	testAccCheckEnvVariables(t, []string{
+		"TF_VAR_VSPHERE_ESXI1",
+		"TF_VAR_VSPHERE_ESXI_HOSTNAME",
+		"TF_VAR_VSPHERE_ESXI_DOMAIN",
+		"TF_VAR_VSPHERE_ESXI_DNS_SERVER",
+	})
+}
+
+func testAccResourceVSphereHostDNSConfig() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_dns" "dns" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  host_name      = "%s"
+  domain_name    = "%s"
+  dns_servers    = ["%s"]
+  search_domains = ["%s"]
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		os.Getenv("TF_VAR_VSPHERE_ESXI_HOSTNAME"),
+		os.Getenv("TF_VAR_VSPHERE_ESXI_DOMAIN"),
+		os.Getenv("TF_VAR_VSPHERE_ESXI_DNS_SERVER"),
+		os.Getenv("TF_VAR_VSPHERE_ESXI_DOMAIN"),
+	)
+}
+
+func testAccResourceVSphereHostDNSConfigDHCPWithServers() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_dns" "dns" {
+  host_system_id   = data.vsphere_host.esxi_host.id
+  host_name        = "%s"
+  dhcp             = true
+  dhcp_virtual_nic = "vmk0"
+  dns_servers      = ["%s"]
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		os.Getenv("TF_VAR_VSPHERE_ESXI_HOSTNAME"),
+		os.Getenv("TF_VAR_VSPHERE_ESXI_DNS_SERVER"),
+	)
+}
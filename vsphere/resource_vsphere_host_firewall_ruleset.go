@@ -0,0 +1,391 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostFirewallRulesetName = "vsphere_host_firewall_ruleset"
+
+func resourceVSphereHostFirewallRuleset() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereHostFirewallRulesetCreate,
+		Read:          resourceVSphereHostFirewallRulesetRead,
+		Update:        resourceVSphereHostFirewallRulesetUpdate,
+		Delete:        resourceVSphereHostFirewallRulesetDelete,
+		CustomizeDiff: resourceVSphereHostFirewallRulesetCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostFirewallRulesetImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"key": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The key of the firewall ruleset, ie: syslog or nfs41Client.",
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Required:    true,
+				Description: "Whether the firewall ruleset is enabled.",
+			},
+			"allow_all_ip": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Allow connections from all IP addresses. Set to false to only allow allowed_ip_addresses and allowed_networks.",
+			},
+			"allowed_ip_addresses": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The IP addresses allowed to connect when allow_all_ip is false.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsIPAddress,
+				},
+			},
+			"allowed_networks": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The networks, in CIDR notation, allowed to connect when allow_all_ip is false.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateHostFirewallRulesetNetwork,
+				},
+			},
+			"label": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The display name of the firewall ruleset.",
+			},
+			"required": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the firewall ruleset is required for the host to work, and cannot be disabled.",
+			},
+			"service": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The key of the service that uses the firewall ruleset.",
+			},
+			"rule": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The rules of the firewall ruleset.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"port": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The port, or the first port of the port range.",
+						},
+						"end_port": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The last port of the port range, 0 for a single port.",
+						},
+						"direction": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The direction of the rule, inbound or outbound.",
+						},
+						"port_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Whether the port is a source or destination port.",
+						},
+						"protocol": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The protocol of the rule, tcp or udp.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceVSphereHostFirewallRulesetCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostFirewallRulesetIDString(d))
+	hsID := d.Get("host_system_id").(string)
+	key := d.Get("key").(string)
+	if err := resourceVSphereHostFirewallRulesetApply(d, meta, hsID, key); err != nil {
+		return err
+	}
+	d.SetId(hostFirewallRulesetResourceID(hsID, key))
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostFirewallRulesetIDString(d))
+	return resourceVSphereHostFirewallRulesetRead(d, meta)
+}
+
+func resourceVSphereHostFirewallRulesetRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostFirewallRulesetIDString(d))
+	client := meta.(*Client).vimClient
+	hsID, key, err := splitHostFirewallRulesetResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	fs, err := hostFirewallSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host firewall system: %s", err)
+	}
+	rs, err := hostFirewallRulesetFromKey(fs, key)
+	if err != nil {
+		return err
+	}
+
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("key", rs.Key)
+	_ = d.Set("enabled", rs.Enabled)
+	_ = d.Set("label", rs.Label)
+	_ = d.Set("required", rs.Required)
+	_ = d.Set("service", rs.Service)
+	if err := flattenHostFirewallRulesetIPList(d, rs.AllowedHosts); err != nil {
+		return err
+	}
+	var rules []interface{}
+	for _, r := range rs.Rule {
+		rules = append(rules, map[string]interface{}{
+			"port":      int(r.Port),
+			"end_port":  int(r.EndPort),
+			"direction": string(r.Direction),
+			"port_type": string(r.PortType),
+			"protocol":  r.Protocol,
+		})
+	}
+	if err := d.Set("rule", rules); err != nil {
+		return fmt.Errorf("error setting rule: %s", err)
+	}
+
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostFirewallRulesetIDString(d))
+	return nil
+}
+
+func resourceVSphereHostFirewallRulesetUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostFirewallRulesetIDString(d))
+	hsID, key, err := splitHostFirewallRulesetResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	if err := resourceVSphereHostFirewallRulesetApply(d, meta, hsID, key); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostFirewallRulesetIDString(d))
+	return resourceVSphereHostFirewallRulesetRead(d, meta)
+}
+
+// resourceVSphereHostFirewallRulesetDelete only removes the resource from the
+// state. Rulesets have no single default, so the host keeps the current
+// settings of the ruleset.
+func resourceVSphereHostFirewallRulesetDelete(d *schema.ResourceData, _ interface{}) error {
+	log.Printf("[DEBUG] %s: Removing from state, the ruleset keeps its settings", resourceVSphereHostFirewallRulesetIDString(d))
+	d.SetId("")
+	return nil
+}
+
+func resourceVSphereHostFirewallRulesetImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client).vimClient
+	hsID, key, err := splitHostFirewallRulesetResourceID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	fs, err := hostFirewallSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading host firewall system: %s", err)
+	}
+	if _, err := hostFirewallRulesetFromKey(fs, key); err != nil {
+		return nil, err
+	}
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("key", key)
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVSphereHostFirewallRulesetCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.Get("allow_all_ip").(bool) {
+		return nil
+	}
+	if d.Get("allowed_ip_addresses").(*schema.Set).Len() > 0 || d.Get("allowed_networks").(*schema.Set).Len() > 0 {
+		return fmt.Errorf("allowed_ip_addresses and allowed_networks require allow_all_ip to be false")
+	}
+	return nil
+}
+
+// resourceVSphereHostFirewallRulesetApply enables or disables the ruleset
+// and updates its allowed hosts. The allowed hosts are only sent if they
+// differ from the ones on the host, as some rulesets do not allow changing
+// them.
+func resourceVSphereHostFirewallRulesetApply(d *schema.ResourceData, meta interface{}, hsID, key string) error {
+	client := meta.(*Client).vimClient
+	fs, err := hostFirewallSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host firewall system: %s", err)
+	}
+	rs, err := hostFirewallRulesetFromKey(fs, key)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := expandHostFirewallRulesetIPList(d)
+	if err != nil {
+		return err
+	}
+	current := types.HostFirewallRulesetIpList{AllIp: true}
+	if rs.AllowedHosts != nil {
+		current = *rs.AllowedHosts
+	}
+	if !reflect.DeepEqual(normalizeHostFirewallRulesetIPList(current), normalizeHostFirewallRulesetIPList(allowed)) {
+		log.Printf("[DEBUG] %s: Updating allowed hosts", resourceVSphereHostFirewallRulesetIDString(d))
+		if err := updateHostFirewallRuleset(fs, key, allowed); err != nil {
+			return fmt.Errorf("error updating the allowed hosts of ruleset %s: %s", key, err)
+		}
+	}
+
+	enabled := d.Get("enabled").(bool)
+	if enabled == rs.Enabled {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if enabled {
+		log.Printf("[DEBUG] %s: Enabling ruleset", resourceVSphereHostFirewallRulesetIDString(d))
+		if err := fs.EnableRuleset(ctx, key); err != nil {
+			return fmt.Errorf("error enabling ruleset %s: %s", key, err)
+		}
+		return nil
+	}
+	log.Printf("[DEBUG] %s: Disabling ruleset", resourceVSphereHostFirewallRulesetIDString(d))
+	if err := fs.DisableRuleset(ctx, key); err != nil {
+		return fmt.Errorf("error disabling ruleset %s: %s", key, err)
+	}
+	return nil
+}
+
+// expandHostFirewallRulesetIPList reads the allowed hosts of the ruleset
+// into a HostFirewallRulesetIpList.
+func expandHostFirewallRulesetIPList(d *schema.ResourceData) (types.HostFirewallRulesetIpList, error) {
+	list := types.HostFirewallRulesetIpList{
+		AllIp: d.Get("allow_all_ip").(bool),
+	}
+	if list.AllIp {
+		return list, nil
+	}
+	list.IpAddress = structure.SliceInterfacesToStrings(d.Get("allowed_ip_addresses").(*schema.Set).List())
+	for _, v := range d.Get("allowed_networks").(*schema.Set).List() {
+		_, network, err := net.ParseCIDR(v.(string))
+		if err != nil {
+			return list, fmt.Errorf("invalid network %q: %s", v, err)
+		}
+		prefix, _ := network.Mask.Size()
+		list.IpNetwork = append(list.IpNetwork, types.HostFirewallRulesetIpNetwork{
+			Network:      network.IP.String(),
+			PrefixLength: int32(prefix),
+		})
+	}
+	return list, nil
+}
+
+// flattenHostFirewallRulesetIPList saves the allowed hosts of the ruleset.
+// The addresses and networks that the host keeps when all IPs are allowed
+// are not saved, as they are ignored, like in
+// normalizeHostFirewallRulesetIPList.
+func flattenHostFirewallRulesetIPList(d *schema.ResourceData, list *types.HostFirewallRulesetIpList) error {
+	normalized := types.HostFirewallRulesetIpList{AllIp: true}
+	if list != nil {
+		normalized = normalizeHostFirewallRulesetIPList(*list)
+	}
+	list = &normalized
+	var networks []string
+	for _, n := range list.IpNetwork {
+		networks = append(networks, fmt.Sprintf("%s/%d", n.Network, n.PrefixLength))
+	}
+	_ = d.Set("allow_all_ip", list.AllIp)
+	if err := d.Set("allowed_ip_addresses", list.IpAddress); err != nil {
+		return fmt.Errorf("error setting allowed_ip_addresses: %s", err)
+	}
+	if err := d.Set("allowed_networks", networks); err != nil {
+		return fmt.Errorf("error setting allowed_networks: %s", err)
+	}
+	return nil
+}
+
+// normalizeHostFirewallRulesetIPList returns a copy of list without the
+// addresses and networks when all IPs are allowed, as the host ignores them
+// in that case. The addresses and networks are sorted, so that lists are
+// compared regardless of their order.
+func normalizeHostFirewallRulesetIPList(list types.HostFirewallRulesetIpList) types.HostFirewallRulesetIpList {
+	if list.AllIp {
+		return types.HostFirewallRulesetIpList{AllIp: true}
+	}
+	normalized := types.HostFirewallRulesetIpList{
+		IpAddress: append([]string{}, list.IpAddress...),
+		IpNetwork: append([]types.HostFirewallRulesetIpNetwork{}, list.IpNetwork...),
+	}
+	sort.Strings(normalized.IpAddress)
+	sort.Slice(normalized.IpNetwork, func(i, j int) bool {
+		a, b := normalized.IpNetwork[i], normalized.IpNetwork[j]
+		if a.Network != b.Network {
+			return a.Network < b.Network
+		}
+		return a.PrefixLength < b.PrefixLength
+	})
+	return normalized
+}
+
+// validateHostFirewallRulesetNetwork is a schema validation function for
+// networks in CIDR notation. The address must be the network address, such
+// as 10.10.0.0/16 rather than 10.10.1.1/16, as the host only saves the
+// network address and the configuration would otherwise never match it.
+func validateHostFirewallRulesetNetwork(v interface{}, k string) ([]string, []error) {
+	s := v.(string)
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %q is not a valid network in CIDR notation", k, s)}
+	}
+	if network.String() != s {
+		return nil, []error{fmt.Errorf("%s: %q is not a network address, use %q", k, s, network.String())}
+	}
+	return nil, nil
+}
+
+// hostFirewallRulesetResourceID makes an ID for the
+// vsphere_host_firewall_ruleset resource.
+func hostFirewallRulesetResourceID(hsID, key string) string {
+	return fmt.Sprintf("%s:%s", hsID, key)
+}
+
+// splitHostFirewallRulesetResourceID splits a vsphere_host_firewall_ruleset
+// resource ID into the HostSystem ID and the ruleset key.
+func splitHostFirewallRulesetResourceID(raw string) (string, string, error) {
+	s := strings.SplitN(raw, ":", 2)
+	if len(s) != 2 || s[0] == "" || s[1] == "" {
+		return "", "", fmt.Errorf("invalid ID %q, expected <host_system_id>:<ruleset_key>", raw)
+	}
+	return s[0], s[1], nil
+}
+
+// resourceVSphereHostFirewallRulesetIDString prints a friendly string for the
+// vsphere_host_firewall_ruleset resource.
+func resourceVSphereHostFirewallRulesetIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostFirewallRulesetName)
+}
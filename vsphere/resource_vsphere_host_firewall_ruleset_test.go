@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccResourceVSphereHostFirewallRuleset_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostFirewallRulesetPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostFirewallRulesetConfig(`
+  enabled              = true
+  allow_all_ip         = false
+  allowed_ip_addresses = ["192.168.1.10"]
+  allowed_networks     = ["10.0.0.0/8"]
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_firewall_ruleset.syslog", "enabled", "true"),
+					resource.TestCheckResourceAttr("vsphere_host_firewall_ruleset.syslog", "allow_all_ip", "false"),
+					resource.TestCheckTypeSetElemAttr("vsphere_host_firewall_ruleset.syslog", "allowed_ip_addresses.*", "192.168.1.10"),
+					resource.TestCheckTypeSetElemAttr("vsphere_host_firewall_ruleset.syslog", "allowed_networks.*", "10.0.0.0/8"),
+					resource.TestCheckResourceAttrSet("vsphere_host_firewall_ruleset.syslog", "rule.0.port"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostFirewallRulesetConfig(`
+  enabled = false
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_firewall_ruleset.syslog", "enabled", "false"),
+					resource.TestCheckResourceAttr("vsphere_host_firewall_ruleset.syslog", "allow_all_ip", "true"),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_firewall_ruleset.syslog",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccResourceVSphereHostFirewallRuleset_allowAllWithAddresses(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostFirewallRulesetPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostFirewallRulesetConfig(`
+  enabled              = true
+  allowed_ip_addresses = ["192.168.1.10"]
+`),
+				ExpectError: regexp.MustCompile("require allow_all_ip to be false"),
+				PlanOnly:    true,
+			},
+		},
+	})
+}
+
+func TestValidateHostFirewallRulesetNetwork(t *testing.T) {
+	for _, tc := range []struct {
+		network string
+		valid   bool
+	}{
+		{"10.10.0.0/16", true},
+		{"2001:db8::/32", true},
+		{"10.10.1.1/16", false},
+		{"2001:DB8::/32", false},
+		{"10.10.0.0", false},
+	} {
+		if _, errs := validateHostFirewallRulesetNetwork(tc.network, "allowed_networks"); tc.valid != (len(errs) == 0) {
+			t.Errorf("%s: expected valid %t, got %v", tc.network, tc.valid, errs)
+		}
+	}
+}
+
+func TestNormalizeHostFirewallRulesetIPList(t *testing.T) {
+	a := types.HostFirewallRulesetIpList{
+		IpAddress: []string{"192.168.1.20", "192.168.1.10"},
+		IpNetwork: []types.HostFirewallRulesetIpNetwork{
+			{Network: "10.0.0.0", PrefixLength: 8},
+			{Network: "10.0.0.0", PrefixLength: 16},
+			{Network: "172.16.0.0", PrefixLength: 12},
+		},
+	}
+	b := types.HostFirewallRulesetIpList{
+		IpAddress: []string{"192.168.1.10", "192.168.1.20"},
+		IpNetwork: []types.HostFirewallRulesetIpNetwork{
+			{Network: "172.16.0.0", PrefixLength: 12},
+			{Network: "10.0.0.0", PrefixLength: 16},
+			{Network: "10.0.0.0", PrefixLength: 8},
+		},
+	}
+	if !reflect.DeepEqual(normalizeHostFirewallRulesetIPList(a), normalizeHostFirewallRulesetIPList(b)) {
+		t.Fatal("expected lists in a different order to be equal")
+	}
+	if a.IpAddress[0] != "192.168.1.20" {
+		t.Fatal("expected the original list not to be sorted")
+	}
+	b.AllIp = true
+	if !reflect.DeepEqual(normalizeHostFirewallRulesetIPList(b), types.HostFirewallRulesetIpList{AllIp: true}) {
+		t.Fatal("expected addresses and networks to be ignored when all IPs are allowed")
+	}
+}
+
+func TestFlattenHostFirewallRulesetIPList(t *testing.T) {
+	d := resourceVSphereHostFirewallRuleset().TestResourceData()
+	err := flattenHostFirewallRulesetIPList(d, &types.HostFirewallRulesetIpList{
+		AllIp:     true,
+		IpAddress: []string{"192.168.1.10"},
+		IpNetwork: []types.HostFirewallRulesetIpNetwork{{Network: "10.0.0.0", PrefixLength: 8}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Get("allow_all_ip").(bool) || d.Get("allowed_ip_addresses").(*schema.Set).Len() != 0 || d.Get("allowed_networks").(*schema.Set).Len() != 0 {
+		t.Fatal("expected addresses and networks not to be saved when all IPs are allowed")
+	}
+
+	err = flattenHostFirewallRulesetIPList(d, &types.HostFirewallRulesetIpList{
+		IpAddress: []string{"192.168.1.10"},
+		IpNetwork: []types.HostFirewallRulesetIpNetwork{{Network: "10.0.0.0", PrefixLength: 8}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Get("allow_all_ip").(bool) || !d.Get("allowed_ip_addresses").(*schema.Set).Contains("192.168.1.10") || !d.Get("allowed_networks").(*schema.Set).Contains("10.0.0.0/8") {
+		t.Fatal("expected addresses and networks to be saved")
+	}
+}
+
+func testAccResourceVSphereHostFirewallRulesetPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_firewall_ruleset acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostFirewallRulesetConfig(args string) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_firewall_ruleset" "syslog" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  key            = "syslog"
+%s}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		args,
+	)
+}
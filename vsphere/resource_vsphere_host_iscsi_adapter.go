@@ -0,0 +1,215 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostIscsiAdapterName = "vsphere_host_iscsi_adapter"
+
+func resourceVSphereHostIscsiAdapter() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostIscsiAdapterCreate,
+		Read:   resourceVSphereHostIscsiAdapterRead,
+		Update: resourceVSphereHostIscsiAdapterUpdate,
+		Delete: resourceVSphereHostIscsiAdapterDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostIscsiAdapterImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"iscsi_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The iSCSI qualified name (IQN) of the adapter.",
+			},
+			"alias": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The iSCSI alias of the adapter.",
+			},
+			"chap": schemaHostInternetScsiChap(),
+			"device": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The device name of the software iSCSI adapter, ie: vmhba65.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostIscsiAdapterCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostIscsiAdapterIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Get("host_system_id").(string)
+	ss, err := hostStorageSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	hba, err := hostSoftwareInternetScsiHba(client, ss)
+	if err != nil {
+		return err
+	}
+	if hba == nil {
+		log.Printf("[DEBUG] %s: Enabling software iSCSI", resourceVSphereHostIscsiAdapterIDString(d))
+		if err := updateSoftwareInternetScsiEnabled(ss, true); err != nil {
+			return fmt.Errorf("error enabling software iSCSI: %s", err)
+		}
+		if hba, err = hostSoftwareInternetScsiHba(client, ss); err != nil {
+			return err
+		}
+		if hba == nil {
+			return fmt.Errorf("software iSCSI adapter not found after enabling software iSCSI on host %s", hsID)
+		}
+	}
+	d.SetId(hsID)
+	if err := resourceVSphereHostIscsiAdapterApply(d, meta, hba, true); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostIscsiAdapterIDString(d))
+	return resourceVSphereHostIscsiAdapterRead(d, meta)
+}
+
+func resourceVSphereHostIscsiAdapterRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostIscsiAdapterIDString(d))
+	client := meta.(*Client).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	hba, err := hostSoftwareInternetScsiHba(client, ss)
+	if err != nil {
+		return err
+	}
+	if hba == nil {
+		log.Printf("[DEBUG] %s: Software iSCSI is disabled, removing from state", resourceVSphereHostIscsiAdapterIDString(d))
+		d.SetId("")
+		return nil
+	}
+
+	_ = d.Set("host_system_id", d.Id())
+	_ = d.Set("device", hba.Device)
+	_ = d.Set("iscsi_name", hba.IScsiName)
+	_ = d.Set("alias", hba.IScsiAlias)
+	if err := d.Set("chap", flattenHostInternetScsiChap(d, &hba.AuthenticationProperties)); err != nil {
+		return fmt.Errorf("error setting chap: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostIscsiAdapterIDString(d))
+	return nil
+}
+
+func resourceVSphereHostIscsiAdapterUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostIscsiAdapterIDString(d))
+	client := meta.(*Client).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	hba, err := hostSoftwareInternetScsiHba(client, ss)
+	if err != nil {
+		return err
+	}
+	if hba == nil {
+		return fmt.Errorf("software iSCSI is not enabled on host %s", d.Id())
+	}
+	if err := resourceVSphereHostIscsiAdapterApply(d, meta, hba, false); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostIscsiAdapterIDString(d))
+	return resourceVSphereHostIscsiAdapterRead(d, meta)
+}
+
+func resourceVSphereHostIscsiAdapterDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostIscsiAdapterIDString(d))
+	client := meta.(*Client).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	if err := updateSoftwareInternetScsiEnabled(ss, false); err != nil {
+		return fmt.Errorf("error disabling software iSCSI: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostIscsiAdapterIDString(d))
+	return nil
+}
+
+func resourceVSphereHostIscsiAdapterImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error loading host storage system: %s", err)
+	}
+	hba, err := hostSoftwareInternetScsiHba(client, ss)
+	if err != nil {
+		return nil, err
+	}
+	if hba == nil {
+		return nil, fmt.Errorf("software iSCSI is not enabled on host %s", d.Id())
+	}
+	_ = d.Set("host_system_id", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostIscsiAdapterApply sets the name, alias and CHAP settings
+// of the software iSCSI adapter, for the attributes that are set on create or
+// have changed on update.
+func resourceVSphereHostIscsiAdapterApply(d *schema.ResourceData, meta interface{}, hba *types.HostInternetScsiHba, create bool) error {
+	client := meta.(*Client).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Id())
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+
+	if name := d.Get("iscsi_name").(string); name != "" && name != hba.IScsiName {
+		log.Printf("[DEBUG] %s: Setting the iSCSI name to %q", resourceVSphereHostIscsiAdapterIDString(d), name)
+		if _, err := methods.UpdateInternetScsiName(ctx, ss.Client(), &types.UpdateInternetScsiName{
+			This:           ss.Reference(),
+			IScsiHbaDevice: hba.Device,
+			IScsiName:      name,
+		}); err != nil {
+			return fmt.Errorf("error updating iSCSI name: %s", err)
+		}
+	}
+	if alias := d.Get("alias").(string); alias != hba.IScsiAlias && (create && alias != "" || d.HasChange("alias")) {
+		if _, err := methods.UpdateInternetScsiAlias(ctx, ss.Client(), &types.UpdateInternetScsiAlias{
+			This:           ss.Reference(),
+			IScsiHbaDevice: hba.Device,
+			IScsiAlias:     alias,
+		}); err != nil {
+			return fmt.Errorf("error updating iSCSI alias: %s", err)
+		}
+	}
+	if _, ok := d.GetOk("chap"); (create && ok) || (!create && d.HasChange("chap")) {
+		log.Printf("[DEBUG] %s: Updating CHAP settings", resourceVSphereHostIscsiAdapterIDString(d))
+		props := expandHostInternetScsiChap(d.Get("chap").([]interface{}), false)
+		if err := updateHostInternetScsiAuthentication(ss, hba.Device, props, nil); err != nil {
+			return fmt.Errorf("error updating CHAP settings: %s", err)
+		}
+	}
+	return nil
+}
+
+// resourceVSphereHostIscsiAdapterIDString prints a friendly string for the
+// vsphere_host_iscsi_adapter resource.
+func resourceVSphereHostIscsiAdapterIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostIscsiAdapterName)
+}
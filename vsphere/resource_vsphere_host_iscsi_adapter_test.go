@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostIscsiAdapter_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostIscsiAdapterPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostIscsiAdapterConfig(`
+  alias = "terraform-test"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("vsphere_host_iscsi_adapter.adapter", "device"),
+					resource.TestCheckResourceAttrSet("vsphere_host_iscsi_adapter.adapter", "iscsi_name"),
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_adapter.adapter", "alias", "terraform-test"),
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_adapter.adapter", "chap.#", "0"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostIscsiAdapterConfig(`
+  alias = "terraform-test"
+
+  chap {
+    method = "chapRequired"
+    name   = "terraform"
+    secret = "terraform-secret"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_adapter.adapter", "chap.0.method", "chapRequired"),
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_adapter.adapter", "chap.0.name", "terraform"),
+				),
+			},
+			{
+				ResourceName:            "vsphere_host_iscsi_adapter.adapter",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"chap.0.secret"},
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostIscsiAdapterPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_iscsi_adapter acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostIscsiAdapterConfig(args string) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_iscsi_adapter" "adapter" {
+  host_system_id = data.vsphere_host.esxi_host.id
+%s}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		args,
+	)
+}
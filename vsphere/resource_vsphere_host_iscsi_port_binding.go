@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostIscsiPortBindingName = "vsphere_host_iscsi_port_binding"
+
+func resourceVSphereHostIscsiPortBinding() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostIscsiPortBindingCreate,
+		Read:   resourceVSphereHostIscsiPortBindingRead,
+		Update: resourceVSphereHostIscsiPortBindingUpdate,
+		Delete: resourceVSphereHostIscsiPortBindingDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostIscsiPortBindingImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"adapter": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The device name of the iSCSI adapter, ie: vmhba65.",
+			},
+			"virtual_nic": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The VMkernel network adapter to bind to the iSCSI adapter, ie: vmk1.",
+			},
+			"force_unbind": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Unbind the VMkernel network adapter on destroy even if it has active iSCSI sessions.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostIscsiPortBindingCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostIscsiPortBindingIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Get("host_system_id").(string)
+	im, err := hostIscsiManagerFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host iSCSI manager: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if _, err := methods.BindVnic(ctx, client.Client, &types.BindVnic{
+		This:         *im,
+		IScsiHbaName: d.Get("adapter").(string),
+		VnicDevice:   d.Get("virtual_nic").(string),
+	}); err != nil {
+		return fmt.Errorf("error binding VMkernel adapter to iSCSI adapter: %s", err)
+	}
+	d.SetId(strings.Join([]string{hsID, d.Get("adapter").(string), d.Get("virtual_nic").(string)}, ":"))
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostIscsiPortBindingIDString(d))
+	return resourceVSphereHostIscsiPortBindingRead(d, meta)
+}
+
+func resourceVSphereHostIscsiPortBindingRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostIscsiPortBindingIDString(d))
+	client := meta.(*Client).vimClient
+	im, err := hostIscsiManagerFromHostSystemID(client, d.Get("host_system_id").(string))
+	if err != nil {
+		return fmt.Errorf("error loading host iSCSI manager: %s", err)
+	}
+	vnics, err := hostIscsiBoundVnics(client, *im, d.Get("adapter").(string))
+	if err != nil {
+		return fmt.Errorf("error querying bound VMkernel adapters: %s", err)
+	}
+	for _, v := range vnics {
+		if v == d.Get("virtual_nic").(string) {
+			log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostIscsiPortBindingIDString(d))
+			return nil
+		}
+	}
+	log.Printf("[DEBUG] %s: Binding not found, removing from state", resourceVSphereHostIscsiPortBindingIDString(d))
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereHostIscsiPortBindingUpdate only applies to force_unbind,
+// which is used on destroy.
+func resourceVSphereHostIscsiPortBindingUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceVSphereHostIscsiPortBindingRead(d, meta)
+}
+
+func resourceVSphereHostIscsiPortBindingDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostIscsiPortBindingIDString(d))
+	client := meta.(*Client).vimClient
+	im, err := hostIscsiManagerFromHostSystemID(client, d.Get("host_system_id").(string))
+	if err != nil {
+		return fmt.Errorf("error loading host iSCSI manager: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if _, err := methods.UnbindVnic(ctx, client.Client, &types.UnbindVnic{
+		This:         *im,
+		IScsiHbaName: d.Get("adapter").(string),
+		VnicDevice:   d.Get("virtual_nic").(string),
+		Force:        d.Get("force_unbind").(bool),
+	}); err != nil {
+		return fmt.Errorf("error unbinding VMkernel adapter from iSCSI adapter: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostIscsiPortBindingIDString(d))
+	return nil
+}
+
+func resourceVSphereHostIscsiPortBindingImport(d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	s := strings.Split(d.Id(), ":")
+	if len(s) != 3 || s[0] == "" || s[1] == "" || s[2] == "" {
+		return nil, fmt.Errorf("invalid ID %q, expected <host_system_id>:<adapter>:<virtual_nic>", d.Id())
+	}
+	_ = d.Set("host_system_id", s[0])
+	_ = d.Set("adapter", s[1])
+	_ = d.Set("virtual_nic", s[2])
+	_ = d.Set("force_unbind", false)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostIscsiPortBindingIDString prints a friendly string for
+// the vsphere_host_iscsi_port_binding resource.
+func resourceVSphereHostIscsiPortBindingIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostIscsiPortBindingName)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostIscsiPortBinding_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostIscsiPortBindingPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostIscsiPortBindingConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_port_binding.binding", "virtual_nic", "vmk0"),
+					resource.TestCheckResourceAttrPair(
+						"vsphere_host_iscsi_port_binding.binding", "adapter",
+						"vsphere_host_iscsi_adapter.adapter", "device",
+					),
+				),
+			},
+			{
+				ResourceName:            "vsphere_host_iscsi_port_binding.binding",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"force_unbind"},
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostIscsiPortBindingPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_iscsi_port_binding acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostIscsiPortBindingConfig() string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_iscsi_adapter" "adapter" {
+  host_system_id = data.vsphere_host.esxi_host.id
+}
+
+resource "vsphere_host_iscsi_port_binding" "binding" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  adapter        = vsphere_host_iscsi_adapter.adapter.device
+  virtual_nic    = "vmk0"
+  force_unbind   = true
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+	)
+}
@@ -0,0 +1,369 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostIscsiTargetName = "vsphere_host_iscsi_target"
+
+const (
+	hostIscsiTargetTypeSend   = "send"
+	hostIscsiTargetTypeStatic = "static"
+)
+
+var hostIscsiTargetTypeAllowedValues = []string{
+	hostIscsiTargetTypeSend,
+	hostIscsiTargetTypeStatic,
+}
+
+func resourceVSphereHostIscsiTarget() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereHostIscsiTargetCreate,
+		Read:          resourceVSphereHostIscsiTargetRead,
+		Update:        resourceVSphereHostIscsiTargetUpdate,
+		Delete:        resourceVSphereHostIscsiTargetDelete,
+		CustomizeDiff: resourceVSphereHostIscsiTargetCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostIscsiTargetImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"adapter": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The device name of the iSCSI adapter, ie: vmhba65.",
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "The type of target: send for a dynamic discovery (send targets) server, or static for a static target.",
+				ValidateFunc: validation.StringInSlice(hostIscsiTargetTypeAllowedValues, false),
+			},
+			"address": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The IP address or host name of the target.",
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3260,
+				ForceNew:     true,
+				Description:  "The TCP port of the target.",
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"iqn": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The iSCSI name of a static target. Required for, and only allowed on, static targets.",
+			},
+			"chap": schemaHostInternetScsiChap(),
+			"rescan": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Rescan the storage adapters and VMFS volumes of the host after the target is added or removed, so that its LUNs can be used right away.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostIscsiTargetCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostIscsiTargetIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Get("host_system_id").(string)
+	adapter := d.Get("adapter").(string)
+	ss, err := hostStorageSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	targets := expandHostIscsiTargetSet(d)
+	if d.Get("type").(string) == hostIscsiTargetTypeSend {
+		_, err = methods.AddInternetScsiSendTargets(ctx, ss.Client(), &types.AddInternetScsiSendTargets{
+			This:           ss.Reference(),
+			IScsiHbaDevice: adapter,
+			Targets:        targets.SendTargets,
+		})
+	} else {
+		_, err = methods.AddInternetScsiStaticTargets(ctx, ss.Client(), &types.AddInternetScsiStaticTargets{
+			This:           ss.Reference(),
+			IScsiHbaDevice: adapter,
+			Targets:        targets.StaticTargets,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("error adding iSCSI target: %s", err)
+	}
+	d.SetId(hostIscsiTargetResourceID(d))
+
+	if _, ok := d.GetOk("chap"); ok {
+		if err := resourceVSphereHostIscsiTargetUpdateChap(d, ss, adapter, targets); err != nil {
+			return err
+		}
+	}
+	if d.Get("rescan").(bool) {
+		if err := rescanHostStorage(ss); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostIscsiTargetIDString(d))
+	return resourceVSphereHostIscsiTargetRead(d, meta)
+}
+
+func resourceVSphereHostIscsiTargetRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostIscsiTargetIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Get("host_system_id").(string)
+	adapter := d.Get("adapter").(string)
+	ss, err := hostStorageSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	hba, err := hostInternetScsiHbaFromDevice(client, ss, adapter)
+	if err != nil {
+		return err
+	}
+
+	address := d.Get("address").(string)
+	port := int32(d.Get("port").(int))
+	var props *types.HostInternetScsiHbaAuthenticationProperties
+	found := false
+	if d.Get("type").(string) == hostIscsiTargetTypeSend {
+		for _, t := range hba.ConfiguredSendTarget {
+			if t.Address == address && hostIscsiTargetPort(t.Port) == port {
+				found, props = true, t.AuthenticationProperties
+				break
+			}
+		}
+	} else {
+		iqn := d.Get("iqn").(string)
+		for _, t := range hba.ConfiguredStaticTarget {
+			if t.Address == address && hostIscsiTargetPort(t.Port) == port && t.IScsiName == iqn {
+				found, props = true, t.AuthenticationProperties
+				break
+			}
+		}
+	}
+	if !found {
+		log.Printf("[DEBUG] %s: Target not found, removing from state", resourceVSphereHostIscsiTargetIDString(d))
+		d.SetId("")
+		return nil
+	}
+	if err := d.Set("chap", flattenHostInternetScsiChap(d, props)); err != nil {
+		return fmt.Errorf("error setting chap: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostIscsiTargetIDString(d))
+	return nil
+}
+
+func resourceVSphereHostIscsiTargetUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostIscsiTargetIDString(d))
+	client := meta.(*Client).vimClient
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Get("host_system_id").(string))
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+	if d.HasChange("chap") {
+		if err := resourceVSphereHostIscsiTargetUpdateChap(d, ss, d.Get("adapter").(string), expandHostIscsiTargetSet(d)); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostIscsiTargetIDString(d))
+	return resourceVSphereHostIscsiTargetRead(d, meta)
+}
+
+func resourceVSphereHostIscsiTargetDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostIscsiTargetIDString(d))
+	client := meta.(*Client).vimClient
+	adapter := d.Get("adapter").(string)
+	ss, err := hostStorageSystemFromHostSystemID(client, d.Get("host_system_id").(string))
+	if err != nil {
+		return fmt.Errorf("error loading host storage system: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	targets := expandHostIscsiTargetSet(d)
+	if d.Get("type").(string) == hostIscsiTargetTypeSend {
+		_, err = methods.RemoveInternetScsiSendTargets(ctx, ss.Client(), &types.RemoveInternetScsiSendTargets{
+			This:           ss.Reference(),
+			IScsiHbaDevice: adapter,
+			Targets:        targets.SendTargets,
+		})
+	} else {
+		_, err = methods.RemoveInternetScsiStaticTargets(ctx, ss.Client(), &types.RemoveInternetScsiStaticTargets{
+			This:           ss.Reference(),
+			IScsiHbaDevice: adapter,
+			Targets:        targets.StaticTargets,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("error removing iSCSI target: %s", err)
+	}
+	if d.Get("rescan").(bool) {
+		if err := rescanHostStorage(ss); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostIscsiTargetIDString(d))
+	return nil
+}
+
+func resourceVSphereHostIscsiTargetImport(d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	hsID, adapter, targetType, address, port, iqn, err := splitHostIscsiTargetResourceID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("adapter", adapter)
+	_ = d.Set("type", targetType)
+	_ = d.Set("address", address)
+	_ = d.Set("port", port)
+	_ = d.Set("iqn", iqn)
+	_ = d.Set("rescan", true)
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVSphereHostIscsiTargetCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("iqn") || !d.NewValueKnown("type") {
+		return nil
+	}
+	iqn := d.Get("iqn").(string)
+	switch d.Get("type").(string) {
+	case hostIscsiTargetTypeStatic:
+		if iqn == "" {
+			return fmt.Errorf("iqn is required for static targets")
+		}
+	case hostIscsiTargetTypeSend:
+		if iqn != "" {
+			return fmt.Errorf("iqn can only be set on static targets")
+		}
+	}
+	return nil
+}
+
+// resourceVSphereHostIscsiTargetUpdateChap sets the CHAP settings of the
+// target.
+func resourceVSphereHostIscsiTargetUpdateChap(d *schema.ResourceData, ss *object.HostStorageSystem, adapter string, targets *types.HostInternetScsiHbaTargetSet) error {
+	log.Printf("[DEBUG] %s: Updating CHAP settings", resourceVSphereHostIscsiTargetIDString(d))
+	props := expandHostInternetScsiChap(d.Get("chap").([]interface{}), true)
+	if err := updateHostInternetScsiAuthentication(ss, adapter, props, targets); err != nil {
+		return fmt.Errorf("error updating CHAP settings of iSCSI target: %s", err)
+	}
+	return nil
+}
+
+// expandHostIscsiTargetSet returns a target set holding the target of the
+// resource, for use in the add, remove and authentication calls.
+func expandHostIscsiTargetSet(d *schema.ResourceData) *types.HostInternetScsiHbaTargetSet {
+	address := d.Get("address").(string)
+	port := int32(d.Get("port").(int))
+	if d.Get("type").(string) == hostIscsiTargetTypeSend {
+		return &types.HostInternetScsiHbaTargetSet{
+			SendTargets: []types.HostInternetScsiHbaSendTarget{{Address: address, Port: port}},
+		}
+	}
+	return &types.HostInternetScsiHbaTargetSet{
+		StaticTargets: []types.HostInternetScsiHbaStaticTarget{{Address: address, Port: port, IScsiName: d.Get("iqn").(string)}},
+	}
+}
+
+// hostIscsiTargetPort returns the port of a target, which the host omits when
+// it is the default port.
+func hostIscsiTargetPort(port int32) int32 {
+	if port == 0 {
+		return 3260
+	}
+	return port
+}
+
+// hostIscsiTargetResourceID makes an ID for the vsphere_host_iscsi_target
+// resource, in the form host:adapter:type:address:port, followed by :iqn for
+// a static target. IPv6 addresses are enclosed in brackets.
+func hostIscsiTargetResourceID(d *schema.ResourceData) string {
+	id := strings.Join([]string{
+		d.Get("host_system_id").(string),
+		d.Get("adapter").(string),
+		d.Get("type").(string),
+		net.JoinHostPort(d.Get("address").(string), strconv.Itoa(d.Get("port").(int))),
+	}, ":")
+	if iqn := d.Get("iqn").(string); iqn != "" {
+		id += ":" + iqn
+	}
+	return id
+}
+
+// splitHostIscsiTargetResourceID splits a vsphere_host_iscsi_target resource
+// ID into its parts.
+func splitHostIscsiTargetResourceID(raw string) (string, string, string, string, int, string, error) {
+	invalid := fmt.Errorf("invalid ID %q, expected <host_system_id>:<adapter>:send:<address>:<port> or <host_system_id>:<adapter>:static:<address>:<port>:<iqn>", raw)
+	s := strings.SplitN(raw, ":", 4)
+	if len(s) != 4 || s[0] == "" || s[1] == "" {
+		return "", "", "", "", 0, "", invalid
+	}
+	rest := s[3]
+	var address string
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return "", "", "", "", 0, "", invalid
+		}
+		address, rest = rest[1:end], rest[end+2:]
+	} else {
+		p := strings.SplitN(rest, ":", 2)
+		if len(p) != 2 {
+			return "", "", "", "", 0, "", invalid
+		}
+		address, rest = p[0], p[1]
+	}
+	p := strings.SplitN(rest, ":", 2)
+	port, err := strconv.Atoi(p[0])
+	if err != nil || address == "" {
+		return "", "", "", "", 0, "", invalid
+	}
+	iqn := ""
+	if len(p) == 2 {
+		iqn = p[1]
+	}
+	switch {
+	case s[2] == hostIscsiTargetTypeSend && iqn == "":
+	case s[2] == hostIscsiTargetTypeStatic && iqn != "":
+	default:
+		return "", "", "", "", 0, "", invalid
+	}
+	return s[0], s[1], s[2], address, port, iqn, nil
+}
+
+// resourceVSphereHostIscsiTargetIDString prints a friendly string for the
+// vsphere_host_iscsi_target resource.
+func resourceVSphereHostIscsiTargetIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostIscsiTargetName)
+}
@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestSplitHostIscsiTargetResourceID(t *testing.T) {
+	cases := []struct {
+		id         string
+		targetType string
+		address    string
+		port       int
+		iqn        string
+		err        bool
+	}{
+		{id: "host-1:vmhba65:send:10.0.0.1:3260", targetType: "send", address: "10.0.0.1", port: 3260},
+		{id: "host-1:vmhba65:send:[fd00::1]:3261", targetType: "send", address: "fd00::1", port: 3261},
+		{id: "host-1:vmhba65:static:san.example.com:3260:iqn.2000-01.com.example:lun1", targetType: "static", address: "san.example.com", port: 3260, iqn: "iqn.2000-01.com.example:lun1"},
+		{id: "host-1:vmhba65:static:[fd00::1]:3260:iqn.2000-01.com.example:lun1", targetType: "static", address: "fd00::1", port: 3260, iqn: "iqn.2000-01.com.example:lun1"},
+		{id: "host-1:vmhba65:send:10.0.0.1:3260:iqn.2000-01.com.example", err: true},
+		{id: "host-1:vmhba65:static:10.0.0.1:3260", err: true},
+		{id: "host-1:vmhba65:dynamic:10.0.0.1:3260", err: true},
+		{id: "host-1:vmhba65:send:10.0.0.1", err: true},
+		{id: "host-1:vmhba65:send:[fd00::1:3260", err: true},
+	}
+	for _, tc := range cases {
+		hsID, adapter, targetType, address, port, iqn, err := splitHostIscsiTargetResourceID(tc.id)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.id, err)
+			continue
+		}
+		if hsID != "host-1" || adapter != "vmhba65" || targetType != tc.targetType || address != tc.address || port != tc.port || iqn != tc.iqn {
+			t.Errorf("%s: got %s, %s, %s, %s, %d, %s", tc.id, hsID, adapter, targetType, address, port, iqn)
+		}
+	}
+}
+
+func TestAccResourceVSphereHostIscsiTarget_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostIscsiTargetPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostIscsiTargetConfig(`
+  type    = "send"
+  address = "%s"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_target.target", "port", "3260"),
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_target.target", "chap.#", "0"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostIscsiTargetConfig(`
+  type    = "send"
+  address = "%s"
+
+  chap {
+    method = "chapRequired"
+    name   = "terraform"
+    secret = "terraform-secret"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_iscsi_target.target", "chap.0.method", "chapRequired"),
+				),
+			},
+			{
+				ResourceName:            "vsphere_host_iscsi_target.target",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"chap.0.secret"},
+			},
+		},
+	})
+}
+
+func TestAccResourceVSphereHostIscsiTarget_staticWithoutIqn(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostIscsiTargetPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostIscsiTargetConfig(`
+  type    = "static"
+  address = "%s"
+`),
+				ExpectError: regexp.MustCompile("iqn is required for static targets"),
+				PlanOnly:    true,
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostIscsiTargetPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_iscsi_target acceptance tests")
+	}
+	if os.Getenv("TF_VAR_VSPHERE_ISCSI_TARGET") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ISCSI_TARGET to run vsphere_host_iscsi_target acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostIscsiTargetConfig(args string) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_iscsi_adapter" "adapter" {
+  host_system_id = data.vsphere_host.esxi_host.id
+}
+
+resource "vsphere_host_iscsi_target" "target" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  adapter        = vsphere_host_iscsi_adapter.adapter.device
+%s}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		fmt.Sprintf(args, os.Getenv("TF_VAR_VSPHERE_ISCSI_TARGET")),
+	)
+}
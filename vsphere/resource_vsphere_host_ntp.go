@@ -0,0 +1,348 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostNtpName = "vsphere_host_ntp"
+
+// hostNtpServiceKey is the key of the NTP daemon in the services of a host.
+const hostNtpServiceKey = "ntpd"
+
+var hostDateTimeProtocolAllowedValues = []string{
+	string(types.HostDateTimeInfoProtocolNtp),
+	string(types.HostDateTimeInfoProtocolPtp),
+}
+
+var hostPtpDeviceTypeAllowedValues = []string{
+	string(types.HostPtpConfigDeviceTypeNone),
+	string(types.HostPtpConfigDeviceTypeVirtualNic),
+	string(types.HostPtpConfigDeviceTypePciPassthruNic),
+}
+
+func resourceVSphereHostNtp() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostNtpCreate,
+		Read:   resourceVSphereHostNtpRead,
+		Update: resourceVSphereHostNtpUpdate,
+		Delete: resourceVSphereHostNtpDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostNtpImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"ntp_servers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "The NTP servers of the host, as IP addresses or fully qualified domain names.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"protocol": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				Description:  "The protocol used to discipline the system clock of the host. Can be one of ntp or ptp. Requires ESXi 7.0 Update 3 or higher.",
+				ValidateFunc: validation.StringInSlice(hostDateTimeProtocolAllowedValues, false),
+			},
+			"ptp": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "The PTP configuration of the host. Requires ESXi 7.0 Update 3 or higher.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"domain": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      0,
+							Description:  "The PTP domain number.",
+							ValidateFunc: validation.IntBetween(0, 255),
+						},
+						"port": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "The PTP ports, in the order of their index.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"device_type": {
+										Type:         schema.TypeString,
+										Required:     true,
+										Description:  "The type of network device used by the port. Can be one of none, virtualNic or pciPassthruNic.",
+										ValidateFunc: validation.StringInSlice(hostPtpDeviceTypeAllowedValues, false),
+									},
+									"device": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "The name of the virtual NIC, or the PCI ID of the passthrough NIC, used by the port.",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"ntpd_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.HostServicePolicyOn),
+				Description:  "The startup policy of the NTP daemon. Can be one of on, off or automatic.",
+				ValidateFunc: validation.StringInSlice(hostServicePolicyAllowedValues, false),
+			},
+			"ntpd_running": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the NTP daemon is running.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostNtpCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostNtpIDString(d))
+	hsID := d.Get("host_system_id").(string)
+	if err := resourceVSphereHostNtpApply(d, meta, hsID, true); err != nil {
+		return err
+	}
+	d.SetId(hsID)
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostNtpIDString(d))
+	return resourceVSphereHostNtpRead(d, meta)
+}
+
+func resourceVSphereHostNtpRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostNtpIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Id()
+	dts, err := hostDateTimeSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host date and time system: %s", err)
+	}
+	info, err := hostDateTimeInfo(client, dts)
+	if err != nil {
+		return err
+	}
+	ss, err := hostServiceSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host service system: %s", err)
+	}
+	service, err := hostServiceFromKey(ss, hostNtpServiceKey)
+	if err != nil {
+		return err
+	}
+
+	var servers []string
+	if info.NtpConfig != nil {
+		servers = info.NtpConfig.Server
+	}
+	_ = d.Set("host_system_id", hsID)
+	if err := d.Set("ntp_servers", servers); err != nil {
+		return fmt.Errorf("error setting ntp_servers: %s", err)
+	}
+	if info.SystemClockProtocol != "" {
+		_ = d.Set("protocol", info.SystemClockProtocol)
+	}
+	// The ptp block is always read when PTP is in use, or when it is already
+	// in the state, so that a block with only default values, such as
+	// domain 0, does not show a diff.
+	keepPtp := info.SystemClockProtocol == string(types.HostDateTimeInfoProtocolPtp) || len(d.Get("ptp").([]interface{})) > 0
+	if err := d.Set("ptp", flattenHostPtpConfig(info.PtpConfig, keepPtp)); err != nil {
+		return fmt.Errorf("error setting ptp: %s", err)
+	}
+	_ = d.Set("ntpd_policy", service.Policy)
+	_ = d.Set("ntpd_running", service.Running)
+
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostNtpIDString(d))
+	return nil
+}
+
+func resourceVSphereHostNtpUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostNtpIDString(d))
+	if err := resourceVSphereHostNtpApply(d, meta, d.Id(), false); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostNtpIDString(d))
+	return resourceVSphereHostNtpRead(d, meta)
+}
+
+func resourceVSphereHostNtpDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostNtpIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Id()
+	dts, err := hostDateTimeSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host date and time system: %s", err)
+	}
+
+	// Reset the host to its defaults: no NTP servers, no PTP ports, the NTP
+	// protocol, and a stopped NTP daemon. The protocol is only sent if it is
+	// not NTP already, so that it is not sent to hosts that do not support it.
+	config := types.HostDateTimeConfig{
+		NtpConfig: &types.HostNtpConfig{},
+	}
+	if v := d.Get("protocol").(string); v != "" && v != string(types.HostDateTimeInfoProtocolNtp) {
+		config.Protocol = string(types.HostDateTimeInfoProtocolNtp)
+	}
+	if len(d.Get("ptp").([]interface{})) > 0 {
+		config.PtpConfig = expandHostPtpConfig(nil, d.Get("ptp.0.port.#").(int))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if err := dts.UpdateConfig(ctx, config); err != nil {
+		return fmt.Errorf("error resetting date and time configuration: %s", err)
+	}
+
+	ss, err := hostServiceSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host service system: %s", err)
+	}
+	if err := updateHostService(ss, hostNtpServiceKey, string(types.HostServicePolicyOff), false, false); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostNtpIDString(d))
+	return nil
+}
+
+func resourceVSphereHostNtpImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client).vimClient
+	hs, err := hostsystem.FromID(client, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("error locating host system: %s", err)
+	}
+	_ = d.Set("host_system_id", hs.Reference().Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostNtpApply applies the date and time configuration and
+// the NTP daemon settings to the host. Only the attributes that have changed
+// are sent on update, so that PTP settings are not sent to hosts that do not
+// support them.
+func resourceVSphereHostNtpApply(d *schema.ResourceData, meta interface{}, hsID string, create bool) error {
+	client := meta.(*Client).vimClient
+	dts, err := hostDateTimeSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host date and time system: %s", err)
+	}
+	ss, err := hostServiceSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host service system: %s", err)
+	}
+
+	serversChanged := create || d.HasChange("ntp_servers")
+	if create || d.HasChanges("ntp_servers", "protocol", "ptp") {
+		config := types.HostDateTimeConfig{
+			NtpConfig: &types.HostNtpConfig{
+				Server: structure.SliceInterfacesToStrings(d.Get("ntp_servers").([]interface{})),
+			},
+		}
+		if v, ok := d.GetOk("protocol"); ok && (create || d.HasChange("protocol")) {
+			config.Protocol = v.(string)
+		}
+		if _, ok := d.GetOk("ptp"); (create && ok) || (!create && d.HasChange("ptp")) {
+			o, _ := d.GetChange("ptp.0.port.#")
+			config.PtpConfig = expandHostPtpConfig(d.Get("ptp").([]interface{}), o.(int))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+		defer cancel()
+		if err := dts.UpdateConfig(ctx, config); err != nil {
+			return fmt.Errorf("error updating date and time configuration: %s", err)
+		}
+	}
+
+	return updateHostService(ss, hostNtpServiceKey, d.Get("ntpd_policy").(string), d.Get("ntpd_running").(bool), serversChanged)
+}
+
+// expandHostPtpConfig reads the ptp block into a HostPtpConfig. The index of
+// each port is its position in the port list. Ports that were in the previous
+// configuration but are no longer in the list, up to oldPorts, are set to
+// none to disable them.
+func expandHostPtpConfig(l []interface{}, oldPorts int) *types.HostPtpConfig {
+	config := &types.HostPtpConfig{}
+	var ports []interface{}
+	if len(l) > 0 && l[0] != nil {
+		m := l[0].(map[string]interface{})
+		config.Domain = int32(m["domain"].(int))
+		ports = m["port"].([]interface{})
+	}
+	for i, v := range ports {
+		pm := v.(map[string]interface{})
+		config.Port = append(config.Port, types.HostPtpConfigPtpPort{
+			Index:      int32(i),
+			DeviceType: pm["device_type"].(string),
+			Device:     pm["device"].(string),
+		})
+	}
+	for i := len(ports); i < oldPorts; i++ {
+		config.Port = append(config.Port, types.HostPtpConfigPtpPort{
+			Index:      int32(i),
+			DeviceType: string(types.HostPtpConfigDeviceTypeNone),
+		})
+	}
+	return config
+}
+
+// flattenHostPtpConfig returns the ptp block for a HostPtpConfig. Trailing
+// ports of type none are omitted. Unless keep is set, no block is returned if
+// no port is in use and the domain is the default, so that hosts with no PTP
+// configuration do not show a diff.
+func flattenHostPtpConfig(config *types.HostPtpConfig, keep bool) []interface{} {
+	if config == nil {
+		if !keep {
+			return nil
+		}
+		config = &types.HostPtpConfig{}
+	}
+	n := 0
+	for _, p := range config.Port {
+		if p.DeviceType != "" && p.DeviceType != string(types.HostPtpConfigDeviceTypeNone) && int(p.Index)+1 > n {
+			n = int(p.Index) + 1
+		}
+	}
+	if n == 0 && config.Domain == 0 && !keep {
+		return nil
+	}
+	ports := make([]interface{}, n)
+	for i := range ports {
+		ports[i] = map[string]interface{}{
+			"device_type": string(types.HostPtpConfigDeviceTypeNone),
+			"device":      "",
+		}
+	}
+	for _, p := range config.Port {
+		if int(p.Index) < n && p.DeviceType != "" && p.DeviceType != string(types.HostPtpConfigDeviceTypeNone) {
+			ports[p.Index] = map[string]interface{}{
+				"device_type": p.DeviceType,
+				"device":      p.Device,
+			}
+		}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"domain": int(config.Domain),
+			"port":   ports,
+		},
+	}
+}
+
+// resourceVSphereHostNtpIDString prints a friendly string for the
+// vsphere_host_ntp resource.
+func resourceVSphereHostNtpIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostNtpName)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccResourceVSphereHostNtp_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostNtpPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccResourceVSphereHostNtpServers(nil),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostNtpConfig(`["0.pool.ntp.org", "1.pool.ntp.org"]`, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereHostNtpServers([]string{"0.pool.ntp.org", "1.pool.ntp.org"}),
+					resource.TestCheckResourceAttr("vsphere_host_ntp.ntp", "ntpd_policy", "on"),
+					resource.TestCheckResourceAttr("vsphere_host_ntp.ntp", "ntpd_running", "true"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostNtpConfig(`["2.pool.ntp.org"]`, false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereHostNtpServers([]string{"2.pool.ntp.org"}),
+					resource.TestCheckResourceAttr("vsphere_host_ntp.ntp", "ntpd_running", "false"),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_ntp.ntp",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestFlattenHostPtpConfig(t *testing.T) {
+	defaults := []interface{}{
+		map[string]interface{}{
+			"domain": 0,
+			"port":   []interface{}{},
+		},
+	}
+	if l := flattenHostPtpConfig(nil, false); l != nil {
+		t.Fatalf("expected no ptp block without a configuration, got %v", l)
+	}
+	if l := flattenHostPtpConfig(&types.HostPtpConfig{}, false); l != nil {
+		t.Fatalf("expected no ptp block with the default configuration, got %v", l)
+	}
+	if l := flattenHostPtpConfig(nil, true); !reflect.DeepEqual(l, defaults) {
+		t.Fatalf("expected the default ptp block, got %v", l)
+	}
+	if l := flattenHostPtpConfig(&types.HostPtpConfig{}, true); !reflect.DeepEqual(l, defaults) {
+		t.Fatalf("expected the default ptp block, got %v", l)
+	}
+
+	config := &types.HostPtpConfig{
+		Domain: 1,
+		Port: []types.HostPtpConfigPtpPort{
+			{Index: 0, DeviceType: string(types.HostPtpConfigDeviceTypeNone)},
+			{Index: 1, DeviceType: string(types.HostPtpConfigDeviceTypeVirtualNic), Device: "vmk1"},
+			{Index: 2, DeviceType: string(types.HostPtpConfigDeviceTypeNone)},
+		},
+	}
+	expected := []interface{}{
+		map[string]interface{}{
+			"domain": 1,
+			"port": []interface{}{
+				map[string]interface{}{"device_type": "none", "device": ""},
+				map[string]interface{}{"device_type": "virtualNic", "device": "vmk1"},
+			},
+		},
+	}
+	if l := flattenHostPtpConfig(config, false); !reflect.DeepEqual(l, expected) {
+		t.Fatalf("expected %v, got %v", expected, l)
+	}
+}
+
+func testAccResourceVSphereHostNtpPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_ntp acceptance tests")
+	}
+}
+
+// testAccResourceVSphereHostNtpServers checks the NTP servers configured on
+// the host. A nil list expects the resource to be gone from the state.
+func testAccResourceVSphereHostNtpServers(expected []string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vars, err := testClientVariablesForResource(s, "vsphere_host_ntp.ntp")
+		if err != nil {
+			if expected == nil {
+				return nil
+			}
+			return errors.New("vsphere_host_ntp.ntp not found in state")
+		}
+		dts, err := hostDateTimeSystemFromHostSystemID(vars.client, vars.resourceID)
+		if err != nil {
+			return fmt.Errorf("error loading host date and time system: %s", err)
+		}
+		info, err := hostDateTimeInfo(vars.client, dts)
+		if err != nil {
+			return err
+		}
+		var actual []string
+		if info.NtpConfig != nil {
+			actual = info.NtpConfig.Server
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("expected NTP servers %v, got %v", expected, actual)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereHostNtpConfig(servers string, running bool) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_ntp" "ntp" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  ntp_servers    = %s
+  ntpd_running   = %t
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		servers,
+		running,
+	)
+}
@@ -0,0 +1,325 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostPciPassthroughName = "vsphere_host_pci_passthrough"
+
+func resourceVSphereHostPciPassthrough() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereHostPciPassthroughCreate,
+		Read:          resourceVSphereHostPciPassthroughRead,
+		Update:        resourceVSphereHostPciPassthroughUpdate,
+		Delete:        resourceVSphereHostPciPassthroughDelete,
+		CustomizeDiff: resourceVSphereHostPciPassthroughCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostPciPassthroughImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"device_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the PCI device, ie: 0000:3b:00.0.",
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether passthrough is enabled for the PCI device.",
+			},
+			"reboot": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Put the host in maintenance mode and reboot it when a change of passthrough is pending, so that it becomes active. Requires vCenter Server.",
+			},
+			"reboot_on_destroy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Put the host in maintenance mode and reboot it when passthrough is disabled on destroy. Requires vCenter Server.",
+			},
+			"reboot_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1800,
+				Description:  "The time, in seconds, to wait for the host to enter maintenance mode and to come back from the reboot.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"capable": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the PCI device supports passthrough.",
+			},
+			"active": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether passthrough is active for the PCI device.",
+			},
+			"reboot_required": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether a reboot of the host is pending for the passthrough setting to become active.",
+			},
+			"dependent_device": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the PCI device that must be passed through together with this device, if any.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostPciPassthroughCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostPciPassthroughIDString(d))
+	client := meta.(*Client).vimClient
+	hsID := d.Get("host_system_id").(string)
+	deviceID := d.Get("device_id").(string)
+	ps, err := hostPciPassthruSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host PCI passthrough system: %s", err)
+	}
+	info, err := hostPciPassthruInfoFromID(client, ps, deviceID)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return fmt.Errorf("PCI device %s not found on host %s", deviceID, hsID)
+	}
+	if !info.PassthruCapable {
+		return fmt.Errorf("PCI device %s on host %s does not support passthrough", deviceID, hsID)
+	}
+	d.SetId(hostPciPassthroughResourceID(hsID, deviceID))
+	if err := resourceVSphereHostPciPassthroughApply(d, meta, d.Get("enabled").(bool), d.Get("reboot").(bool)); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostPciPassthroughIDString(d))
+	return resourceVSphereHostPciPassthroughRead(d, meta)
+}
+
+func resourceVSphereHostPciPassthroughRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostPciPassthroughIDString(d))
+	client := meta.(*Client).vimClient
+	hsID, deviceID, err := splitHostPciPassthroughResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	ps, err := hostPciPassthruSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host PCI passthrough system: %s", err)
+	}
+	info, err := hostPciPassthruInfoFromID(client, ps, deviceID)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		log.Printf("[DEBUG] %s: PCI device not found, removing from state", resourceVSphereHostPciPassthroughIDString(d))
+		d.SetId("")
+		return nil
+	}
+
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("device_id", deviceID)
+	_ = d.Set("enabled", info.PassthruEnabled)
+	_ = d.Set("capable", info.PassthruCapable)
+	_ = d.Set("active", info.PassthruActive)
+	_ = d.Set("reboot_required", info.PassthruEnabled != info.PassthruActive)
+	_ = d.Set("dependent_device", info.DependentDevice)
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostPciPassthroughIDString(d))
+	return nil
+}
+
+func resourceVSphereHostPciPassthroughUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostPciPassthroughIDString(d))
+	if err := resourceVSphereHostPciPassthroughApply(d, meta, d.Get("enabled").(bool), d.Get("reboot").(bool)); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostPciPassthroughIDString(d))
+	return resourceVSphereHostPciPassthroughRead(d, meta)
+}
+
+func resourceVSphereHostPciPassthroughDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostPciPassthroughIDString(d))
+	if err := resourceVSphereHostPciPassthroughApply(d, meta, false, d.Get("reboot_on_destroy").(bool)); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostPciPassthroughIDString(d))
+	return nil
+}
+
+// resourceVSphereHostPciPassthroughCustomizeDiff plans an update when reboot
+// is set and a reboot of the host is still pending, ie: because the reboot of
+// a previous apply failed or reboot was not set then, so that the reboot is
+// retried.
+func resourceVSphereHostPciPassthroughCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || !d.Get("reboot").(bool) {
+		return nil
+	}
+	if old, _ := d.GetChange("reboot_required"); !old.(bool) {
+		return nil
+	}
+	return d.SetNewComputed("reboot_required")
+}
+
+func resourceVSphereHostPciPassthroughImport(d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	hsID, deviceID, err := splitHostPciPassthroughResourceID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("device_id", deviceID)
+	_ = d.Set("reboot", false)
+	_ = d.Set("reboot_on_destroy", false)
+	_ = d.Set("reboot_timeout", 1800)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostPciPassthroughApply sets passthrough for the PCI device
+// and, if reboot is set and the setting is not active yet, runs a maintenance
+// mode reboot cycle of the host. The reboots of a host are serialized, and a
+// reboot is skipped if the setting became active with the reboot of another
+// resource, so that the devices of a host updated in the same run share one
+// reboot when possible.
+func resourceVSphereHostPciPassthroughApply(d *schema.ResourceData, meta interface{}, enabled, reboot bool) error {
+	client := meta.(*Client).vimClient
+	hsID, deviceID, err := splitHostPciPassthroughResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	if reboot {
+		if err := viapi.ValidateVirtualCenter(client); err != nil {
+			return fmt.Errorf("rebooting host %s requires vCenter Server: %s", hsID, err)
+		}
+	}
+	ps, err := hostPciPassthruSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host PCI passthrough system: %s", err)
+	}
+	lock := hostPciPassthroughRebootLock(hsID)
+	lock.RLock()
+	info, err := resourceVSphereHostPciPassthroughUpdateEnabled(d, client, ps, hsID, deviceID, enabled)
+	lock.RUnlock()
+	if err != nil {
+		return err
+	}
+	if !reboot || info.PassthruActive == enabled {
+		return nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if info, err = hostPciPassthruInfoFromID(client, ps, deviceID); err != nil {
+		return err
+	}
+	if info == nil {
+		return fmt.Errorf("PCI device %s not found on host %s", deviceID, hsID)
+	}
+	if info.PassthruActive == enabled {
+		log.Printf("[DEBUG] %s: Passthrough became active with another reboot of the host", resourceVSphereHostPciPassthroughIDString(d))
+		return nil
+	}
+
+	hs, err := hostsystem.FromID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error locating host system: %s", err)
+	}
+	// A host that was already in maintenance mode is left in it.
+	maintenance, err := hostsystem.HostInMaintenance(hs)
+	if err != nil {
+		return fmt.Errorf("error checking maintenance mode of host %s: %s", hsID, err)
+	}
+	// The host is taken out of maintenance mode even if entering maintenance
+	// mode or the reboot failed, so that a failed apply does not leave it
+	// evacuated.
+	timeout := time.Duration(d.Get("reboot_timeout").(int)) * time.Second
+	var rebootErr error
+	if err := hostsystem.EnterMaintenanceMode(hs, timeout, true); err != nil {
+		rebootErr = fmt.Errorf("error putting host %s in maintenance mode: %s", hsID, err)
+	} else if err := hostsystem.Reboot(hs, timeout); err != nil {
+		rebootErr = fmt.Errorf("error rebooting host %s: %s", hsID, err)
+	}
+	if maintenance {
+		return rebootErr
+	}
+	if err := hostsystem.ExitMaintenanceMode(hs, timeout); err != nil {
+		if rebootErr != nil {
+			return fmt.Errorf("%s; error taking host %s out of maintenance mode: %s", rebootErr, hsID, err)
+		}
+		return fmt.Errorf("error taking host %s out of maintenance mode: %s", hsID, err)
+	}
+	return rebootErr
+}
+
+// resourceVSphereHostPciPassthroughUpdateEnabled enables or disables
+// passthrough for the PCI device if needed, and returns the passthrough
+// information of the device.
+func resourceVSphereHostPciPassthroughUpdateEnabled(d *schema.ResourceData, client *govmomi.Client, ps types.ManagedObjectReference, hsID, deviceID string, enabled bool) (*types.HostPciPassthruInfo, error) {
+	info, err := hostPciPassthruInfoFromID(client, ps, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("PCI device %s not found on host %s", deviceID, hsID)
+	}
+	if info.PassthruEnabled == enabled {
+		return info, nil
+	}
+	log.Printf("[DEBUG] %s: Setting passthrough enabled to %t", resourceVSphereHostPciPassthroughIDString(d), enabled)
+	if err := updateHostPciPassthruEnabled(client, ps, deviceID, enabled); err != nil {
+		return nil, fmt.Errorf("error updating passthrough for PCI device %s: %s", deviceID, err)
+	}
+	if info, err = hostPciPassthruInfoFromID(client, ps, deviceID); err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("PCI device %s not found on host %s after updating passthrough", deviceID, hsID)
+	}
+	return info, nil
+}
+
+// hostPciPassthroughResourceID makes an ID for the
+// vsphere_host_pci_passthrough resource.
+func hostPciPassthroughResourceID(hsID, deviceID string) string {
+	return hsID + ":" + deviceID
+}
+
+// splitHostPciPassthroughResourceID splits a vsphere_host_pci_passthrough
+// resource ID into the managed object ID of the host and the ID of the PCI
+// device, which contains colons itself.
+func splitHostPciPassthroughResourceID(id string) (string, string, error) {
+	s := strings.SplitN(id, ":", 2)
+	if len(s) != 2 || s[0] == "" || s[1] == "" {
+		return "", "", fmt.Errorf("invalid ID %q, expected <host_system_id>:<device_id>", id)
+	}
+	return s[0], s[1], nil
+}
+
+// resourceVSphereHostPciPassthroughIDString prints a friendly string for the
+// vsphere_host_pci_passthrough resource.
+func resourceVSphereHostPciPassthroughIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostPciPassthroughName)
+}
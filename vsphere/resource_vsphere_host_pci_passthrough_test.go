@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestSplitHostPciPassthroughResourceID(t *testing.T) {
+	hsID, deviceID, err := splitHostPciPassthroughResourceID("host-1:0000:3b:00.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if hsID != "host-1" || deviceID != "0000:3b:00.0" {
+		t.Fatalf("unexpected result: %s, %s", hsID, deviceID)
+	}
+	for _, id := range []string{"", "host-1", "host-1:", ":0000:3b:00.0"} {
+		if _, _, err := splitHostPciPassthroughResourceID(id); err == nil {
+			t.Errorf("%q: expected error", id)
+		}
+	}
+}
+
+func TestHostPciPassthroughRebootLock(t *testing.T) {
+	if hostPciPassthroughRebootLock("host-1") != hostPciPassthroughRebootLock("host-1") {
+		t.Fatal("expected the same lock for the same host")
+	}
+	if hostPciPassthroughRebootLock("host-1") == hostPciPassthroughRebootLock("host-2") {
+		t.Fatal("expected different locks for different hosts")
+	}
+}
+
+func TestAccResourceVSphereHostPciPassthrough_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostPciPassthroughPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostPciPassthroughConfig(true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_pci_passthrough.device", "enabled", "true"),
+					resource.TestCheckResourceAttr("vsphere_host_pci_passthrough.device", "capable", "true"),
+					resource.TestCheckResourceAttrSet("vsphere_host_pci_passthrough.device", "reboot_required"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostPciPassthroughConfig(false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_pci_passthrough.device", "enabled", "false"),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_pci_passthrough.device",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostPciPassthroughPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_pci_passthrough acceptance tests")
+	}
+	if os.Getenv("TF_VAR_VSPHERE_PCI_PASSTHROUGH_DEVICE") == "" {
+		t.Skip("set TF_VAR_VSPHERE_PCI_PASSTHROUGH_DEVICE to run vsphere_host_pci_passthrough acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostPciPassthroughConfig(enabled bool) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_pci_passthrough" "device" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  device_id      = "%s"
+  enabled        = %t
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		os.Getenv("TF_VAR_VSPHERE_PCI_PASSTHROUGH_DEVICE"),
+		enabled,
+	)
+}
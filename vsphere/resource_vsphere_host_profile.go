@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostProfileName = "vsphere_host_profile"
+
+func resourceVSphereHostProfile() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostProfileCreate,
+		Read:   resourceVSphereHostProfileRead,
+		Update: resourceVSphereHostProfileUpdate,
+		Delete: resourceVSphereHostProfileDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the host profile.",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The description of the host profile.",
+			},
+			"reference_host_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The managed object ID of the host the profile is extracted from. Changing it extracts the profile again from the new host.",
+			},
+			"entity_ids": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "The managed object IDs of the hosts and clusters the profile is attached to.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceVSphereHostProfileCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostProfileIDString(d))
+	client := meta.(*Client).vimClient
+	if err := viapi.ValidateVirtualCenter(client); err != nil {
+		return err
+	}
+	pm, err := hostProfileManagerReference(client)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	res, err := methods.CreateProfile(ctx, client.Client, &types.CreateProfile{
+		This: pm,
+		CreateSpec: &types.HostProfileHostBasedConfigSpec{
+			HostProfileConfigSpec: types.HostProfileConfigSpec{
+				ProfileCreateSpec: types.ProfileCreateSpec{
+					Name:       d.Get("name").(string),
+					Annotation: d.Get("description").(string),
+					Enabled:    types.NewBool(true),
+				},
+			},
+			Host: types.ManagedObjectReference{Type: "HostSystem", Value: d.Get("reference_host_id").(string)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating host profile: %s", err)
+	}
+	d.SetId(res.Returnval.Value)
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostProfileIDString(d))
+	return resourceVSphereHostProfileRead(d, meta)
+}
+
+func resourceVSphereHostProfileRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostProfileIDString(d))
+	client := meta.(*Client).vimClient
+	props, err := hostProfileProperties(client, d.Id())
+	if err != nil {
+		if viapi.IsManagedObjectNotFoundError(err) {
+			log.Printf("[DEBUG] %s: Host profile not found, removing from state", resourceVSphereHostProfileIDString(d))
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error reading host profile: %s", err)
+	}
+
+	info := hostProfileConfigInfo(props)
+	_ = d.Set("name", info.Name)
+	_ = d.Set("description", info.Annotation)
+	_ = d.Set("reference_host_id", "")
+	if props.ReferenceHost != nil {
+		_ = d.Set("reference_host_id", props.ReferenceHost.Value)
+	}
+	var entities []string
+	for _, e := range props.Entity {
+		entities = append(entities, e.Value)
+	}
+	if err := d.Set("entity_ids", entities); err != nil {
+		return fmt.Errorf("error setting entity_ids: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostProfileIDString(d))
+	return nil
+}
+
+func resourceVSphereHostProfileUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostProfileIDString(d))
+	client := meta.(*Client).vimClient
+	ref := types.ManagedObjectReference{Type: "HostProfile", Value: d.Id()}
+	createSpec := types.ProfileCreateSpec{
+		Name:       d.Get("name").(string),
+		Annotation: d.Get("description").(string),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if d.HasChange("reference_host_id") {
+		host := types.ManagedObjectReference{Type: "HostSystem", Value: d.Get("reference_host_id").(string)}
+		log.Printf("[DEBUG] %s: Extracting host profile from host %q", resourceVSphereHostProfileIDString(d), host.Value)
+		if _, err := methods.UpdateReferenceHost(ctx, client.Client, &types.UpdateReferenceHost{
+			This: ref,
+			Host: &host,
+		}); err != nil {
+			return fmt.Errorf("error updating reference host: %s", err)
+		}
+		if _, err := methods.UpdateHostProfile(ctx, client.Client, &types.UpdateHostProfile{
+			This: ref,
+			Config: &types.HostProfileHostBasedConfigSpec{
+				HostProfileConfigSpec: types.HostProfileConfigSpec{ProfileCreateSpec: createSpec},
+				Host:                  host,
+			},
+		}); err != nil {
+			return fmt.Errorf("error extracting host profile from reference host: %s", err)
+		}
+	} else if d.HasChanges("name", "description") {
+		if _, err := methods.UpdateHostProfile(ctx, client.Client, &types.UpdateHostProfile{
+			This: ref,
+			Config: &types.HostProfileCompleteConfigSpec{
+				HostProfileConfigSpec: types.HostProfileConfigSpec{ProfileCreateSpec: createSpec},
+			},
+		}); err != nil {
+			return fmt.Errorf("error updating host profile: %s", err)
+		}
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostProfileIDString(d))
+	return resourceVSphereHostProfileRead(d, meta)
+}
+
+func resourceVSphereHostProfileDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostProfileIDString(d))
+	client := meta.(*Client).vimClient
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if _, err := methods.DestroyProfile(ctx, client.Client, &types.DestroyProfile{
+		This: types.ManagedObjectReference{Type: "HostProfile", Value: d.Id()},
+	}); err != nil {
+		return fmt.Errorf("error deleting host profile: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostProfileIDString(d))
+	return nil
+}
+
+// resourceVSphereHostProfileIDString prints a friendly string for the
+// vsphere_host_profile resource.
+func resourceVSphereHostProfileIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostProfileName)
+}
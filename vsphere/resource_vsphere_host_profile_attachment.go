@@ -0,0 +1,263 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const resourceVSphereHostProfileAttachmentName = "vsphere_host_profile_attachment"
+
+func resourceVSphereHostProfileAttachment() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceVSphereHostProfileAttachmentCreate,
+		Read:          resourceVSphereHostProfileAttachmentRead,
+		Update:        resourceVSphereHostProfileAttachmentUpdate,
+		Delete:        resourceVSphereHostProfileAttachmentDelete,
+		CustomizeDiff: resourceVSphereHostProfileAttachmentCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostProfileAttachmentImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_profile_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host profile.",
+			},
+			"host_system_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "The managed object ID of the host to attach the profile to.",
+				ConflictsWith: []string{"compute_cluster_id"},
+			},
+			"compute_cluster_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "The managed object ID of the cluster to attach the profile to.",
+				ConflictsWith: []string{"host_system_id"},
+			},
+			"remediate": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Apply the host profile to the hosts that are not compliant with it on every apply. Each host is put in maintenance mode while the profile is applied.",
+			},
+			"remediation_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1800,
+				Description:  "The time, in seconds, to wait for each host to enter maintenance mode and for the profile to be applied.",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"check_compliance": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Check the compliance of the attached hosts with the profile on every refresh. Otherwise, compliance is only checked when the profile is attached or applied.",
+			},
+			"compliance_status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The compliance status of the attached hosts: compliant, nonCompliant or unknown.",
+			},
+			"compliance_failure": schemaHostProfileComplianceFailure(),
+		},
+	}
+}
+
+func resourceVSphereHostProfileAttachmentCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostProfileAttachmentIDString(d))
+	client := meta.(*Client).vimClient
+	if err := viapi.ValidateVirtualCenter(client); err != nil {
+		return err
+	}
+	profile := types.ManagedObjectReference{Type: "HostProfile", Value: d.Get("host_profile_id").(string)}
+	entity := resourceVSphereHostProfileAttachmentEntity(d)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if _, err := methods.AssociateProfile(ctx, client.Client, &types.AssociateProfile{
+		This:   profile,
+		Entity: []types.ManagedObjectReference{entity},
+	}); err != nil {
+		return fmt.Errorf("error attaching host profile: %s", err)
+	}
+	d.SetId(profile.Value + ":" + entity.Value)
+
+	if d.Get("remediate").(bool) {
+		if err := resourceVSphereHostProfileAttachmentRemediate(d, meta); err != nil {
+			return err
+		}
+	}
+	if err := resourceVSphereHostProfileAttachmentCheckCompliance(d, meta); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostProfileAttachmentIDString(d))
+	return resourceVSphereHostProfileAttachmentRead(d, meta)
+}
+
+func resourceVSphereHostProfileAttachmentRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostProfileAttachmentIDString(d))
+	client := meta.(*Client).vimClient
+	props, err := hostProfileProperties(client, d.Get("host_profile_id").(string))
+	if err != nil {
+		if viapi.IsManagedObjectNotFoundError(err) {
+			log.Printf("[DEBUG] %s: Host profile not found, removing from state", resourceVSphereHostProfileAttachmentIDString(d))
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("error reading host profile: %s", err)
+	}
+	entity := resourceVSphereHostProfileAttachmentEntity(d)
+	attached := false
+	for _, e := range props.Entity {
+		if e == entity {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		log.Printf("[DEBUG] %s: Host profile no longer attached, removing from state", resourceVSphereHostProfileAttachmentIDString(d))
+		d.SetId("")
+		return nil
+	}
+
+	// Compliance is also checked if it never was, such as after an import.
+	if d.Get("check_compliance").(bool) || d.Get("compliance_status").(string) == "" {
+		if err := resourceVSphereHostProfileAttachmentCheckCompliance(d, meta); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostProfileAttachmentIDString(d))
+	return nil
+}
+
+func resourceVSphereHostProfileAttachmentUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostProfileAttachmentIDString(d))
+	if d.Get("remediate").(bool) {
+		if err := resourceVSphereHostProfileAttachmentRemediate(d, meta); err != nil {
+			return err
+		}
+		if err := resourceVSphereHostProfileAttachmentCheckCompliance(d, meta); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostProfileAttachmentIDString(d))
+	return resourceVSphereHostProfileAttachmentRead(d, meta)
+}
+
+func resourceVSphereHostProfileAttachmentDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning delete", resourceVSphereHostProfileAttachmentIDString(d))
+	client := meta.(*Client).vimClient
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	if _, err := methods.DissociateProfile(ctx, client.Client, &types.DissociateProfile{
+		This:   types.ManagedObjectReference{Type: "HostProfile", Value: d.Get("host_profile_id").(string)},
+		Entity: []types.ManagedObjectReference{resourceVSphereHostProfileAttachmentEntity(d)},
+	}); err != nil {
+		return fmt.Errorf("error detaching host profile: %s", err)
+	}
+	log.Printf("[DEBUG] %s: Deleted successfully", resourceVSphereHostProfileAttachmentIDString(d))
+	return nil
+}
+
+func resourceVSphereHostProfileAttachmentImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	s := strings.Split(d.Id(), ":")
+	if len(s) != 2 || s[0] == "" || s[1] == "" {
+		return nil, fmt.Errorf("invalid ID %q, expected <host_profile_id>:<entity_id>", d.Id())
+	}
+	_ = d.Set("host_profile_id", s[0])
+	client := meta.(*Client).vimClient
+	if _, err := hostsystem.FromID(client, s[1]); err == nil {
+		_ = d.Set("host_system_id", s[1])
+	} else {
+		_ = d.Set("compute_cluster_id", s[1])
+	}
+	_ = d.Set("remediate", false)
+	_ = d.Set("remediation_timeout", 1800)
+	_ = d.Set("check_compliance", true)
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVSphereHostProfileAttachmentCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	hostSet := !d.GetRawConfig().GetAttr("host_system_id").IsNull()
+	clusterSet := !d.GetRawConfig().GetAttr("compute_cluster_id").IsNull()
+	if !hostSet && !clusterSet {
+		return errors.New("one of host_system_id or compute_cluster_id must be set")
+	}
+	// Plan an update when remediation is enabled and the last compliance check
+	// found drift, so that the profile is applied again.
+	if d.Id() != "" && d.Get("remediate").(bool) && d.Get("compliance_status").(string) == string(types.ComplianceResultStatusNonCompliant) {
+		return d.SetNewComputed("compliance_status")
+	}
+	return nil
+}
+
+// resourceVSphereHostProfileAttachmentCheckCompliance checks the compliance
+// of the attached hosts with the host profile and saves the result.
+func resourceVSphereHostProfileAttachmentCheckCompliance(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	profile := types.ManagedObjectReference{Type: "HostProfile", Value: d.Get("host_profile_id").(string)}
+	results, err := checkHostProfileCompliance(client, &profile, []types.ManagedObjectReference{resourceVSphereHostProfileAttachmentEntity(d)})
+	if err != nil {
+		return fmt.Errorf("error checking host profile compliance: %s", err)
+	}
+	status, failures := hostProfileComplianceStatus(results)
+	_ = d.Set("compliance_status", status)
+	if err := d.Set("compliance_failure", failures); err != nil {
+		return fmt.Errorf("error setting compliance_failure: %s", err)
+	}
+	return nil
+}
+
+// resourceVSphereHostProfileAttachmentRemediate applies the host profile to
+// every attached host that is not compliant with it, one host at a time.
+func resourceVSphereHostProfileAttachmentRemediate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	profile := types.ManagedObjectReference{Type: "HostProfile", Value: d.Get("host_profile_id").(string)}
+	results, err := checkHostProfileCompliance(client, &profile, []types.ManagedObjectReference{resourceVSphereHostProfileAttachmentEntity(d)})
+	if err != nil {
+		return fmt.Errorf("error checking host profile compliance: %s", err)
+	}
+	timeout := time.Duration(d.Get("remediation_timeout").(int)) * time.Second
+	for _, host := range nonCompliantHosts(results) {
+		log.Printf("[DEBUG] %s: Remediating host %q", resourceVSphereHostProfileAttachmentIDString(d), host.Value)
+		if err := remediateHostProfile(client, profile, host, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceVSphereHostProfileAttachmentEntity returns the reference to the
+// host or cluster the profile is attached to.
+func resourceVSphereHostProfileAttachmentEntity(d *schema.ResourceData) types.ManagedObjectReference {
+	if id, ok := d.GetOk("host_system_id"); ok {
+		return types.ManagedObjectReference{Type: "HostSystem", Value: id.(string)}
+	}
+	return types.ManagedObjectReference{Type: "ClusterComputeResource", Value: d.Get("compute_cluster_id").(string)}
+}
+
+// resourceVSphereHostProfileAttachmentIDString prints a friendly string for
+// the vsphere_host_profile_attachment resource.
+func resourceVSphereHostProfileAttachmentIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostProfileAttachmentName)
+}
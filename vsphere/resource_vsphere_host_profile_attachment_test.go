@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostProfileAttachment_basic(t *testing.T) {
+	name := acctest.RandomWithPrefix("terraform-test-host-profile")
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostProfileAttachmentPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostProfileAttachmentConfig(name),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("vsphere_host_profile_attachment.attachment", "compliance_status"),
+					resource.TestCheckResourceAttrPair(
+						"data.vsphere_host_profile_compliance.compliance", "compliance_status",
+						"vsphere_host_profile_attachment.attachment", "compliance_status",
+					),
+				),
+			},
+			{
+				ResourceName:            "vsphere_host_profile_attachment.attachment",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"remediation_timeout"},
+			},
+		},
+	})
+}
+
+func TestAccResourceVSphereHostProfileAttachment_noEntity(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "vsphere_host_profile_attachment" "attachment" {
+  host_profile_id = "hostprofile-1"
+}
+`,
+				ExpectError: regexp.MustCompile("one of host_system_id or compute_cluster_id must be set"),
+				PlanOnly:    true,
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostProfileAttachmentPreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_profile_attachment acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostProfileAttachmentConfig(name string) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_profile" "profile" {
+  name              = "%s"
+  reference_host_id = data.vsphere_host.esxi_host.id
+}
+
+resource "vsphere_host_profile_attachment" "attachment" {
+  host_profile_id = vsphere_host_profile.profile.id
+  host_system_id  = data.vsphere_host.esxi_host.id
+}
+
+data "vsphere_host_profile_compliance" "compliance" {
+  host_system_id  = vsphere_host_profile_attachment.attachment.host_system_id
+  host_profile_id = vsphere_host_profile.profile.id
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		name,
+	)
+}
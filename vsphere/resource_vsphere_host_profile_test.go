@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostProfile_basic(t *testing.T) {
+	name := acctest.RandomWithPrefix("terraform-test-host-profile")
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostProfilePreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostProfileConfig(name, "created by terraform"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_profile.profile", "name", name),
+					resource.TestCheckResourceAttr("vsphere_host_profile.profile", "description", "created by terraform"),
+					resource.TestCheckResourceAttrPair(
+						"vsphere_host_profile.profile", "reference_host_id",
+						"data.vsphere_host.esxi_host", "id",
+					),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostProfileConfig(name+"-renamed", "updated by terraform") + `
+data "vsphere_host_profile" "profile" {
+  name = vsphere_host_profile.profile.name
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("vsphere_host_profile.profile", "name", name+"-renamed"),
+					resource.TestCheckResourceAttr("vsphere_host_profile.profile", "description", "updated by terraform"),
+					resource.TestCheckResourceAttrPair(
+						"data.vsphere_host_profile.profile", "id",
+						"vsphere_host_profile.profile", "id",
+					),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_profile.profile",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostProfilePreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_profile acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostProfileConfig(name, description string) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_profile" "profile" {
+  name              = "%s"
+  description       = "%s"
+  reference_host_id = data.vsphere_host.esxi_host.id
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		name,
+		description,
+	)
+}
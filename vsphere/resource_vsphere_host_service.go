@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+)
+
+const resourceVSphereHostServiceName = "vsphere_host_service"
+
+func resourceVSphereHostService() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostServiceCreate,
+		Read:   resourceVSphereHostServiceRead,
+		Update: resourceVSphereHostServiceUpdate,
+		Delete: resourceVSphereHostServiceDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereHostServiceImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"host_system_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The managed object ID of the host.",
+			},
+			"key": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The key of the service, ie: TSM-SSH, lbtd or sfcbd-watchdog.",
+			},
+			"policy": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "The startup policy of the service. Can be one of on, off or automatic.",
+				ValidateFunc: validation.StringInSlice(hostServicePolicyAllowedValues, false),
+			},
+			"running": {
+				Type:        schema.TypeBool,
+				Required:    true,
+				Description: "Whether the service is running.",
+			},
+			"label": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The display name of the service.",
+			},
+			"required": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the service is required for the host to work, and cannot be stopped.",
+			},
+			"uninstallable": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the service can be uninstalled.",
+			},
+			"rulesets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The keys of the firewall rulesets used by the service.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"source_package": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the software package that provides the service.",
+			},
+		},
+	}
+}
+
+func resourceVSphereHostServiceCreate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning create", resourceVSphereHostServiceIDString(d))
+	hsID := d.Get("host_system_id").(string)
+	key := d.Get("key").(string)
+	if err := resourceVSphereHostServiceApply(d, meta, hsID, key); err != nil {
+		return err
+	}
+	d.SetId(hostServiceResourceID(hsID, key))
+	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereHostServiceIDString(d))
+	return resourceVSphereHostServiceRead(d, meta)
+}
+
+func resourceVSphereHostServiceRead(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning read", resourceVSphereHostServiceIDString(d))
+	client := meta.(*Client).vimClient
+	hsID, key, err := splitHostServiceResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	ss, err := hostServiceSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host service system: %s", err)
+	}
+	service, err := hostServiceFromKey(ss, key)
+	if err != nil {
+		return err
+	}
+
+	sourcePackage := ""
+	if service.SourcePackage != nil {
+		sourcePackage = service.SourcePackage.SourcePackageName
+	}
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("key", service.Key)
+	_ = d.Set("policy", service.Policy)
+	_ = d.Set("running", service.Running)
+	_ = d.Set("label", service.Label)
+	_ = d.Set("required", service.Required)
+	_ = d.Set("uninstallable", service.Uninstallable)
+	_ = d.Set("source_package", sourcePackage)
+	if err := d.Set("rulesets", service.Ruleset); err != nil {
+		return fmt.Errorf("error setting rulesets: %s", err)
+	}
+
+	log.Printf("[DEBUG] %s: Read completed successfully", resourceVSphereHostServiceIDString(d))
+	return nil
+}
+
+func resourceVSphereHostServiceUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] %s: Beginning update", resourceVSphereHostServiceIDString(d))
+	hsID, key, err := splitHostServiceResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	if err := resourceVSphereHostServiceApply(d, meta, hsID, key); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] %s: Update finished successfully", resourceVSphereHostServiceIDString(d))
+	return resourceVSphereHostServiceRead(d, meta)
+}
+
+// resourceVSphereHostServiceDelete only removes the resource from the state.
+// Services have no single default, so the host keeps the current policy and
+// running state of the service.
+func resourceVSphereHostServiceDelete(d *schema.ResourceData, _ interface{}) error {
+	log.Printf("[DEBUG] %s: Removing from state, the service keeps its policy and state", resourceVSphereHostServiceIDString(d))
+	d.SetId("")
+	return nil
+}
+
+func resourceVSphereHostServiceImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client).vimClient
+	hsID, key, err := splitHostServiceResourceID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	ss, err := hostServiceSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading host service system: %s", err)
+	}
+	if _, err := hostServiceFromKey(ss, key); err != nil {
+		return nil, err
+	}
+	_ = d.Set("host_system_id", hsID)
+	_ = d.Set("key", key)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereHostServiceApply sets the policy and running state of the
+// service.
+func resourceVSphereHostServiceApply(d *schema.ResourceData, meta interface{}, hsID, key string) error {
+	client := meta.(*Client).vimClient
+	ss, err := hostServiceSystemFromHostSystemID(client, hsID)
+	if err != nil {
+		return fmt.Errorf("error loading host service system: %s", err)
+	}
+	return updateHostService(ss, key, d.Get("policy").(string), d.Get("running").(bool), false)
+}
+
+// hostServiceResourceID makes an ID for the vsphere_host_service resource.
+func hostServiceResourceID(hsID, key string) string {
+	return fmt.Sprintf("%s:%s", hsID, key)
+}
+
+// splitHostServiceResourceID splits a vsphere_host_service resource ID into
+// the HostSystem ID and the service key.
+func splitHostServiceResourceID(raw string) (string, string, error) {
+	s := strings.SplitN(raw, ":", 2)
+	if len(s) != 2 || s[0] == "" || s[1] == "" {
+		return "", "", fmt.Errorf("invalid ID %q, expected <host_system_id>:<service_key>", raw)
+	}
+	return s[0], s[1], nil
+}
+
+// resourceVSphereHostServiceIDString prints a friendly string for the
+// vsphere_host_service resource.
+func resourceVSphereHostServiceIDString(d structure.ResourceIDStringer) string {
+	return structure.ResourceIDString(d, resourceVSphereHostServiceName)
+}
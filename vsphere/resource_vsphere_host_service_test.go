@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/testhelper"
+)
+
+func TestAccResourceVSphereHostService_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereHostServicePreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereHostServiceConfig("on", true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereHostServiceState("on", true),
+					resource.TestCheckResourceAttr("vsphere_host_service.ssh", "label", "SSH"),
+				),
+			},
+			{
+				Config: testAccResourceVSphereHostServiceConfig("off", false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereHostServiceState("off", false),
+				),
+			},
+			{
+				ResourceName:      "vsphere_host_service.ssh",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccResourceVSphereHostServicePreCheck(t *testing.T) {
+	if os.Getenv("TF_VAR_VSPHERE_ESXI1") == "" {
+		t.Skip("set TF_VAR_VSPHERE_ESXI1 to run vsphere_host_service acceptance tests")
+	}
+}
+
+func testAccResourceVSphereHostServiceState(policy string, running bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		vars, err := testClientVariablesForResource(s, "vsphere_host_service.ssh")
+		if err != nil {
+			return errors.New("vsphere_host_service.ssh not found in state")
+		}
+		hsID, key, err := splitHostServiceResourceID(vars.resourceID)
+		if err != nil {
+			return err
+		}
+		ss, err := hostServiceSystemFromHostSystemID(vars.client, hsID)
+		if err != nil {
+			return fmt.Errorf("error loading host service system: %s", err)
+		}
+		service, err := hostServiceFromKey(ss, key)
+		if err != nil {
+			return err
+		}
+		if service.Policy != policy || service.Running != running {
+			return fmt.Errorf("expected policy %q and running %t, got policy %q and running %t", policy, running, service.Policy, service.Running)
+		}
+		return nil
+	}
+}
+
+func testAccResourceVSphereHostServiceConfig(policy string, running bool) string {
+	return fmt.Sprintf(`
+%s
+
+data "vsphere_host" "esxi_host" {
+  name          = "%s"
+  datacenter_id = data.vsphere_datacenter.rootdc1.id
+}
+
+resource "vsphere_host_service" "ssh" {
+  host_system_id = data.vsphere_host.esxi_host.id
+  key            = "TSM-SSH"
+  policy         = "%s"
+  running        = %t
+}
+`,
+		testhelper.ConfigDataRootDC1(),
+		os.Getenv("TF_VAR_VSPHERE_ESXI1"),
+		policy,
+		running,
+	)
+}
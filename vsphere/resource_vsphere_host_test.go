@@ -164,6 +164,38 @@ func TestAccResourceVSphereHost_lockdown(t *testing.T) {
 	})
 }
 
+func TestAccResourceVSphereHost_lockdownExceptions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccCheckEnvVariables(t, []string{"ESX_HOSTNAME", "ESX_USERNAME", "ESX_PASSWORD"})
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccVSphereHostDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testaccvspherehostconfigLockdownExceptions("strict", `["root"]`, `["root"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereHostExists("vsphere_host.h1"),
+					testAccVSphereHostLockdownState("vsphere_host.h1", "strict"),
+					resource.TestCheckResourceAttr("vsphere_host.h1", "lockdown_exception_users.#", "1"),
+					resource.TestCheckTypeSetElemAttr("vsphere_host.h1", "lockdown_exception_users.*", "root"),
+					resource.TestCheckResourceAttr("vsphere_host.h1", "dcui_access_users.#", "1"),
+				),
+			},
+			{
+				Config: testaccvspherehostconfigLockdownExceptions("disabled", `["root"]`, `["root"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccVSphereHostExists("vsphere_host.h1"),
+					testAccVSphereHostLockdownState("vsphere_host.h1", "disabled"),
+					resource.TestCheckResourceAttr("vsphere_host.h1", "lockdown_exception_users.#", "1"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccResourceVSphereHost_lockdown_invalid(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck: func() {
@@ -561,3 +593,37 @@ func testaccvspherehostconfigLockdown(lockdown string) string {
 		os.Getenv("TF_VAR_VSPHERE_LICENSE"),
 		lockdown)
 }
+
+func testaccvspherehostconfigLockdownExceptions(lockdown, exceptionUsers, dcuiUsers string) string {
+	return fmt.Sprintf(`
+	%s
+
+	resource "vsphere_compute_cluster" "c1" {
+	  name = "%s"
+	  datacenter_id = data.vsphere_datacenter.rootdc1.id
+	}
+
+	resource "vsphere_host" "h1" {
+	  hostname = "%s"
+	  username = "%s"
+	  password = "%s"
+	  thumbprint = data.vsphere_host_thumbprint.id
+
+	  license = "%s"
+	  connected = "true"
+	  maintenance = "false"
+	  lockdown = "%s"
+	  lockdown_exception_users = %s
+	  dcui_access_users = %s
+	  cluster = vsphere_compute_cluster.c1.id
+	}
+	`, testhelper.ConfigDataRootDC1(),
+		"TestCluster",
+		os.Getenv("ESX_HOSTNAME"),
+		os.Getenv("ESX_USERNAME"),
+		os.Getenv("ESX_PASSWORD"),
+		os.Getenv("TF_VAR_VSPHERE_LICENSE"),
+		lockdown,
+		exceptionUsers,
+		dcuiUsers)
+}
@@ -51,6 +51,8 @@ func resourceVSphereNasDatastore() *schema.Resource {
 	s[vSphereTagAttributeKey] = tagsSchema()
 	// Add custom attribute schema
 	s[customattribute.ConfigKey] = customattribute.ConfigSchema()
+	// Add deletion protection schema
+	s[deletionProtectionKey] = deletionProtectionSchema()
 
 	return &schema.Resource{
 		Create: resourceVSphereNasDatastoreCreate,
@@ -263,6 +265,9 @@ func resourceVSphereNasDatastoreDelete(d *schema.ResourceData, meta interface{})
 	if err != nil {
 		return fmt.Errorf("cannot find datastore: %s", err)
 	}
+	if err := checkDeletionProtection(d, meta, ds.Reference()); err != nil {
+		return err
+	}
 
 	// Unmount the datastore from every host. Once the last host is unmounted we
 	// are done and the datastore will delete itself.
@@ -319,5 +324,6 @@ func resourceVSphereNasDatastoreImport(d *schema.ResourceData, meta interface{})
 	}
 	_ = d.Set("access_mode", accessMode)
 	_ = d.Set("type", t)
+	_ = d.Set(deletionProtectionKey, resourceVSphereNasDatastore().Schema[deletionProtectionKey].Default)
 	return []*schema.ResourceData{d}, nil
 }
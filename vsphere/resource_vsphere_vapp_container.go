@@ -7,17 +7,21 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/customattribute"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/folder"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/resourcepool"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/vappcontainer"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/vmworkflow"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -116,6 +120,79 @@ func resourceVSphereVAppContainer() *schema.Resource {
 			Optional:    true,
 			Default:     -1,
 		},
+		"ovf_deploy": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			ForceNew:    true,
+			MaxItems:    1,
+			Description: "A specification for deploying an OVF/OVA template describing a VirtualSystemCollection as the vApp container.",
+			Elem:        &schema.Resource{Schema: vAppContainerOvfDeploySchema()},
+		},
+		"ovf_signer": ovfdeploy.SignerSchema(),
+		"power_on_timeout": {
+			Type:         schema.TypeInt,
+			Description:  "The amount of time, in seconds, to wait for the vApp container deployed from ovf_deploy to power on, including the start delays of its virtual machines.",
+			Optional:     true,
+			Default:      300,
+			ValidateFunc: validation.IntAtLeast(300),
+		},
+		"virtual_machine": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The virtual machines that are members of the vApp container, with their start order settings.",
+			Elem: &schema.Resource{Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The name of the virtual machine.",
+				},
+				"moid": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The managed object ID of the virtual machine.",
+				},
+				"uuid": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The UUID of the virtual machine.",
+				},
+				"ovf_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The ID of the VirtualSystem in the OVF descriptor the virtual machine was deployed from.",
+				},
+				"start_order": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Order to start and stop the virtual machine in the vApp container.",
+				},
+				"start_delay": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Delay in seconds before continuing with the next entity in the order of entities to be started.",
+				},
+				"start_action": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "How to start the virtual machine.",
+				},
+				"stop_delay": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Delay in seconds before continuing with the next entity in the order of entities to be stopped.",
+				},
+				"stop_action": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "How to stop the virtual machine.",
+				},
+				"wait_for_guest": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether the virtual machine is marked as started when VMware Tools are ready instead of waiting for start_delay.",
+				},
+			}},
+		},
 		vSphereTagAttributeKey:    tagsSchema(),
 		customattribute.ConfigKey: customattribute.ConfigSchema(),
 	}
@@ -141,6 +218,7 @@ func resourceVSphereVAppContainerImport(d *schema.ResourceData, meta interface{}
 		return nil, err
 	}
 	d.SetId(vc.Reference().Value)
+	_ = d.Set("power_on_timeout", resourceVSphereVAppContainer().Schema["power_on_timeout"].Default)
 	return []*schema.ResourceData{d}, nil
 }
 
@@ -183,14 +261,27 @@ func resourceVSphereVAppContainerCreate(d *schema.ResourceData, meta interface{}
 		}
 	}
 
-	vc, err := vappcontainer.Create(prp, d.Get("name").(string), rpSpec, vcSpec, f)
+	var vc *object.VirtualApp
+	if len(d.Get("ovf_deploy").([]interface{})) > 0 {
+		vc, err = resourceVSphereVAppContainerDeployOvf(d, meta.(*Client), rpSpec, f)
+	} else {
+		vc, err = vappcontainer.Create(prp, d.Get("name").(string), rpSpec, vcSpec, f)
+	}
 	if err != nil {
 		return err
 	}
+	// Set the ID before any further step, so that a vApp container that fails
+	// to be tagged or powered on is tainted and destroyed rather than orphaned.
+	d.SetId(vc.Reference().Value)
 	if err = resourceVSphereVAppContainerApplyTags(d, meta, vc); err != nil {
 		return err
 	}
-	d.SetId(vc.Reference().Value)
+	if d.Get("ovf_deploy.0.power_on").(bool) {
+		timeout := time.Duration(d.Get("power_on_timeout").(int)) * time.Second
+		if err = vappcontainer.PowerOn(vc, timeout); err != nil {
+			return fmt.Errorf("error powering on vApp container: %s", err)
+		}
+	}
 	log.Printf("[DEBUG] %s: Create finished successfully", resourceVSphereVAppContainerIDString(d))
 	return resourceVSphereVAppContainerRead(d, meta)
 }
@@ -234,6 +325,9 @@ func resourceVSphereVAppContainerRead(d *schema.ResourceData, meta interface{})
 	if err = flattenVAppContainerConfigSpec(d, vcProps.Config); err != nil {
 		return err
 	}
+	if err = flattenVAppContainerVirtualMachines(client, d, vcProps); err != nil {
+		return err
+	}
 	log.Printf("[DEBUG] %s: Read finished successfully", resourceVSphereVAppContainerIDString(d))
 	return nil
 }
@@ -283,7 +377,13 @@ func resourceVSphereVAppContainerDelete(d *schema.ResourceData, meta interface{}
 	if err != nil {
 		return err
 	}
-	if err = resourceVSphereVAppContainerValidateEmpty(vc); err != nil {
+	// Virtual machines deployed from an OVF are owned by the vApp container and
+	// are destroyed with it.
+	if len(d.Get("ovf_deploy").([]interface{})) > 0 {
+		if err = vappcontainer.PowerOff(vc); err != nil {
+			return fmt.Errorf("error powering off vApp container: %s", err)
+		}
+	} else if err = resourceVSphereVAppContainerValidateEmpty(vc); err != nil {
 		return err
 	}
 	if err = vappcontainer.Delete(vc); err != nil {
@@ -333,6 +433,134 @@ func expandVAppContainerConfigSpec(d *schema.ResourceData) *types.ResourceConfig
 	}
 }
 
+// vAppContainerOvfDeploySchema returns the schema for the ovf_deploy block of
+// vsphere_vapp_container. It shares the source and mapping options with the
+// ovf_deploy block of vsphere_virtual_machine.
+func vAppContainerOvfDeploySchema() map[string]*schema.Schema {
+	s := vmworkflow.VirtualMachineOvfDeploySchema()
+	delete(s, "enable_hidden_properties")
+	sources := []string{"ovf_deploy.0.local_ovf_path", "ovf_deploy.0.remote_ovf_url", "ovf_deploy.0.source_datastore_id"}
+	for _, k := range []string{"local_ovf_path", "remote_ovf_url", "source_datastore_id"} {
+		s[k].ExactlyOneOf = sources
+	}
+	s["source_datastore_id"].RequiredWith = []string{"ovf_deploy.0.source_datastore_path"}
+	s["source_datastore_path"].RequiredWith = []string{"ovf_deploy.0.source_datastore_id"}
+	s["host_system_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "The ID of the host system to deploy the virtual machines of the vApp container to.",
+	}
+	s["datastore_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "The ID of the datastore to deploy the virtual machines of the vApp container to.",
+	}
+	s["power_on"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     true,
+		Description: "Power on the vApp container after deployment, starting its virtual machines in the start order from the OVF.",
+	}
+	return s
+}
+
+// resourceVSphereVAppContainerDeployOvf deploys the OVF in the ovf_deploy
+// block, which must describe a VirtualSystemCollection, as the vApp container.
+// The StartupSection of the collection is applied to the entities of the
+// vApp container by the import. The ID is set as soon as the vApp container
+// is imported.
+func resourceVSphereVAppContainerDeployOvf(d *schema.ResourceData, c *Client, rpSpec *types.ResourceConfigSpec, f *object.Folder) (*object.VirtualApp, error) {
+	client := c.vimClient
+	log.Printf("[DEBUG] %s: Deploying vApp container from OVF", resourceVSphereVAppContainerIDString(d))
+	ovfHelper, err := ovfdeploy.NewOvfHelper(client, &ovfdeploy.OvfHelperParams{
+		AllowUnverifiedSSL: d.Get("ovf_deploy.0.allow_unverified_ssl_cert").(bool),
+		DatastoreID:        d.Get("ovf_deploy.0.datastore_id").(string),
+		DeploymentOption:   d.Get("ovf_deploy.0.deployment_option").(string),
+		DiskProvisioning:   d.Get("ovf_deploy.0.disk_provisioning").(string),
+		DownloadCache:      c.ovfDownloadCache,
+		FilePath:           d.Get("ovf_deploy.0.local_ovf_path").(string),
+		HostID:             d.Get("ovf_deploy.0.host_system_id").(string),
+		IPAllocationPolicy: d.Get("ovf_deploy.0.ip_allocation_policy").(string),
+		IPProtocol:         d.Get("ovf_deploy.0.ip_protocol").(string),
+		Name:               d.Get("name").(string),
+		NetworkMappings:    d.Get("ovf_deploy.0.ovf_network_map").(map[string]interface{}),
+		OvfURL:             d.Get("ovf_deploy.0.remote_ovf_url").(string),
+		PoolID:             d.Get("parent_resource_pool_id").(string),
+		SignatureOptions: ovfdeploy.SignatureOptions{
+			Policy:          d.Get("ovf_deploy.0.ovf_signature_policy").(string),
+			TrustedCABundle: d.Get("ovf_deploy.0.ovf_trusted_ca_bundle").(string),
+		},
+		SourceDatastoreID:   d.Get("ovf_deploy.0.source_datastore_id").(string),
+		SourceDatastorePath: d.Get("ovf_deploy.0.source_datastore_path").(string),
+		UploadOptions:       c.ovfUploadOptions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while extracting OVF parameters: %s", err)
+	}
+	if f != nil {
+		ovfHelper.Folder = f
+	}
+
+	is, err := ovfHelper.GetImportSpec(client)
+	if err != nil {
+		return nil, fmt.Errorf("while retrieving ovf import spec from the API: %s", err)
+	}
+	collection, err := ovfdeploy.GetVirtualSystemCollection(ovfHelper.OvfDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	vais, ok := is.ImportSpec.(*types.VirtualAppImportSpec)
+	if collection == nil || !ok {
+		return nil, fmt.Errorf("ovf %s does not describe a VirtualSystemCollection. Use vsphere_virtual_machine to deploy a single virtual machine", ovfHelper.FilePath)
+	}
+	vais.ResourcePoolSpec = *rpSpec
+
+	vc, err := ovfHelper.DeployVApp(client, is)
+	if err != nil {
+		return nil, fmt.Errorf("error while importing ovf/ova template: %s", err)
+	}
+	d.SetId(vc.Reference().Value)
+	if err = d.Set("ovf_signer", ovfdeploy.FlattenSigner(ovfHelper.Signer)); err != nil {
+		return vc, err
+	}
+	return vc, nil
+}
+
+// flattenVAppContainerVirtualMachines saves the virtual machines that are
+// members of the vApp container, along with their entity settings, to the
+// virtual_machine attribute.
+func flattenVAppContainerVirtualMachines(client *govmomi.Client, d *schema.ResourceData, props *mo.VirtualApp) error {
+	vms, err := vappcontainer.VirtualMachines(client, props)
+	if err != nil {
+		return err
+	}
+	var result []map[string]interface{}
+	for _, vm := range vms {
+		m := map[string]interface{}{
+			"name": vm.Name,
+			"moid": vm.Reference().Value,
+		}
+		if vm.Config != nil {
+			m["uuid"] = vm.Config.Uuid
+		}
+		if props.VAppConfig != nil {
+			if e := resourceVSphereVAppEntityFromKey(vm.Reference().Value, props); e != nil {
+				m["ovf_id"] = e.Tag
+				m["start_order"] = e.StartOrder
+				m["start_delay"] = e.StartDelay
+				m["start_action"] = e.StartAction
+				m["stop_delay"] = e.StopDelay
+				m["stop_action"] = e.StopAction
+				if e.WaitingForGuest != nil {
+					m["wait_for_guest"] = *e.WaitingForGuest
+				}
+			}
+		}
+		result = append(result, m)
+	}
+	return d.Set("virtual_machine", result)
+}
+
 func resourceVSphereVAppContainerClient(meta interface{}) (*govmomi.Client, error) {
 	client := meta.(*Client).vimClient
 	if err := viapi.ValidateVirtualCenter(client); err != nil {
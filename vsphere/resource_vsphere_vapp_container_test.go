@@ -252,6 +252,83 @@ func TestAccResourceVSphereVAppContainer_vmMoveIntoVAppSDRS(t *testing.T) {
 	})
 }
 
+func TestResourceVSphereVAppContainerOvfDeploySources(t *testing.T) {
+	cases := []struct {
+		name      string
+		sources   map[string]interface{}
+		expectErr bool
+	}{
+		{
+			name:    "local",
+			sources: map[string]interface{}{"local_ovf_path": "/tmp/vapp.ova"},
+		},
+		{
+			name:    "datastore",
+			sources: map[string]interface{}{"source_datastore_id": "datastore-1", "source_datastore_path": "vapp.ova"},
+		},
+		{
+			name:      "none",
+			sources:   map[string]interface{}{},
+			expectErr: true,
+		},
+		{
+			name:      "local and remote",
+			sources:   map[string]interface{}{"local_ovf_path": "/tmp/vapp.ova", "remote_ovf_url": "https://example.com/vapp.ova"},
+			expectErr: true,
+		},
+		{
+			name:      "datastore without path",
+			sources:   map[string]interface{}{"source_datastore_id": "datastore-1"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ovfDeploy := map[string]interface{}{
+				"host_system_id": "host-1",
+				"datastore_id":   "datastore-1",
+			}
+			for k, v := range tc.sources {
+				ovfDeploy[k] = v
+			}
+			diags := resourceVSphereVAppContainer().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":                    "vapp",
+				"parent_resource_pool_id": "resgroup-1",
+				"ovf_deploy":              []interface{}{ovfDeploy},
+			}))
+			if tc.expectErr != diags.HasError() {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, diags)
+			}
+		})
+	}
+}
+
+func TestAccResourceVSphereVAppContainer_ovfDeploy(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVAppContainerPreCheck(t)
+			if os.Getenv("TF_VAR_VSPHERE_OVF_COLLECTION_URL") == "" {
+				t.Skip("set TF_VAR_VSPHERE_OVF_COLLECTION_URL to run vsphere_vapp_container OVF acceptance tests")
+			}
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccResourceVSphereVAppContainerCheckExists(false),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereVAppContainerConfigOvfDeploy(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceVSphereVAppContainerCheckExists(true),
+					resource.TestCheckResourceAttrSet("vsphere_vapp_container.vapp_container", "virtual_machine.0.moid"),
+					resource.TestCheckResourceAttrSet("vsphere_vapp_container.vapp_container", "virtual_machine.0.ovf_id"),
+					resource.TestCheckResourceAttrSet("vsphere_vapp_container.vapp_container", "virtual_machine.1.moid"),
+				),
+			},
+		},
+	})
+}
+
 func testAccResourceVSphereVAppContainerPreCheck(t *testing.T) {
 	if os.Getenv("TF_VAR_VSPHERE_DATACENTER") == "" {
 		t.Skip("set TF_VAR_VSPHERE_DATACENTER to run vsphere_vapp_container acceptance tests")
@@ -455,6 +532,29 @@ resource "vsphere_vapp_container" "vapp_container" {
 	)
 }
 
+func testAccResourceVSphereVAppContainerConfigOvfDeploy() string {
+	return fmt.Sprintf(`
+%s
+
+resource "vsphere_vapp_container" "vapp_container" {
+  name                    = "vapp-container-test"
+  parent_resource_pool_id = data.vsphere_compute_cluster.rootcompute_cluster1.resource_pool_id
+
+  ovf_deploy {
+    remote_ovf_url            = "%s"
+    host_system_id            = data.vsphere_host.roothost1.id
+    datastore_id              = vsphere_nas_datastore.ds1.id
+    disk_provisioning         = "thin"
+    allow_unverified_ssl_cert = true
+    power_on                  = false
+  }
+}
+`,
+		testhelper.CombineConfigs(testhelper.ConfigDataRootDC1(), testhelper.ConfigDataRootHost1(), testhelper.ConfigResDS1(), testhelper.ConfigDataRootComputeCluster1()),
+		os.Getenv("TF_VAR_VSPHERE_OVF_COLLECTION_URL"),
+	)
+}
+
 func testAccResourceVSphereVAppContainerConfigVMSdrsNoVApp() string {
 	return fmt.Sprintf(`
 %s
@@ -20,10 +20,14 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/clustercomputeresource"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/computeresource"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/customattribute"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/datastore"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/envbrowse"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/folder"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/hostsystem"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/provider"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/resourcepool"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/spbm"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/storagepod"
@@ -177,6 +181,35 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			Default:     true,
 			Description: "Set to true to force power-off a virtual machine if a graceful guest shutdown failed for a necessary operation.",
 		},
+		"consolidate_disks": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Set to true to consolidate the disks of the virtual machine during apply when vSphere reports that consolidation is needed.",
+		},
+		"drs_placement": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Request a DRS placement recommendation at plan time when creating the virtual machine without host_system_id, failing the plan if the cluster has insufficient capacity.",
+		},
+		"upgrade_tools": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "An arbitrary value that, when changed to a new non-empty value on an existing virtual machine, upgrades VMware Tools in the guest if it is out of date.",
+		},
+		"upgrade_tools_installer_options": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The command line options passed to the VMware Tools installer when upgrading VMware Tools through upgrade_tools.",
+		},
+		"upgrade_tools_timeout": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      10,
+			Description:  "The amount of time, in minutes, to wait for a VMware Tools upgrade to complete and VMware Tools to be running again.",
+			ValidateFunc: validation.IntAtLeast(1),
+		},
 		"sata_controller_count": {
 			Type:         schema.TypeInt,
 			Optional:     true,
@@ -258,6 +291,7 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			MaxItems:    1,
 			Elem:        &schema.Resource{Schema: vmworkflow.VirtualMachineOvfDeploySchema()},
 		},
+		"ovf_signer": ovfdeploy.SignerSchema(),
 		"reboot_required": {
 			Type:        schema.TypeBool,
 			Computed:    true,
@@ -268,6 +302,16 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			Computed:    true,
 			Description: "The state of VMware Tools in the guest. This will determine the proper course of action for some device operations.",
 		},
+		"vmware_tools_version": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The version of VMware Tools installed in the guest.",
+		},
+		"vmware_tools_version_status": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The version status of VMware Tools installed in the guest, compared to the version available on the host.",
+		},
 		"vmx_path": {
 			Type:        schema.TypeString,
 			Computed:    true,
@@ -283,8 +327,19 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 			Computed:    true,
 			Description: "The power state of the virtual machine.",
 		},
+		"consolidation_needed": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the disks of the virtual machine need to be consolidated.",
+		},
+		"placement_datastore_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The datastore recommended by DRS for the virtual machine when it was created with drs_placement.",
+		},
 		vSphereTagAttributeKey:    tagsSchema(),
 		customattribute.ConfigKey: customattribute.ConfigSchema(),
+		deletionProtectionKey:     deletionProtectionSchema(),
 	}
 	structure.MergeSchema(s, schemaVirtualMachineConfigSpec())
 	structure.MergeSchema(s, schemaVirtualMachineGuestInfo())
@@ -317,6 +372,21 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		return err
 	}
 
+	// Place the virtual machine through DRS if requested. The recommended host
+	// is used as host_system_id by the deploy workflows below, and the
+	// recommended datastore is recorded in placement_datastore_id.
+	if d.Get("drs_placement").(bool) && d.Get("host_system_id").(string) == "" && len(d.Get("ovf_deploy").([]interface{})) == 0 {
+		host, ds, err := resourceVSphereVirtualMachinePlaceWithDRS(d, client)
+		if err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] %s: DRS placed virtual machine on host %q", resourceVSphereVirtualMachineIDString(d), host.Value)
+		_ = d.Set("host_system_id", host.Value)
+		if ds != nil {
+			_ = d.Set("placement_datastore_id", ds.Value)
+		}
+	}
+
 	var vm *object.VirtualMachine
 	// This is where we process our various VM deploy workflows. We expect the ID
 	// of the resource to be set in the workflow to ensure that any post-create
@@ -437,6 +507,8 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 	// Check to see if VMware Tools is running.
 	if vprops.Guest != nil {
 		_ = d.Set("vmware_tools_status", vprops.Guest.ToolsRunningStatus)
+		_ = d.Set("vmware_tools_version", vprops.Guest.ToolsVersion)
+		_ = d.Set("vmware_tools_version_status", vprops.Guest.ToolsVersionStatus)
 	}
 
 	// Resource pool
@@ -491,6 +563,12 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 		return fmt.Errorf("VMX datastore %s not found", dp.Datastore)
 	}
 	_ = d.Set("datastore_id", ds.Reference().Value)
+	// DRS does not always recommend a datastore, ie: when the candidates are
+	// restricted to datastore_id, in which case the virtual machine was placed
+	// on its VMX datastore.
+	if d.Get("drs_placement").(bool) && d.Get("placement_datastore_id").(string) == "" {
+		_ = d.Set("placement_datastore_id", ds.Reference().Value)
+	}
 	_ = d.Set("vmx_path", dp.Path)
 
 	// Read general VM config info
@@ -574,6 +652,8 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 		d.Set("power_state", "suspended")
 	}
 
+	_ = d.Set("consolidation_needed", vprops.Runtime.ConsolidationNeeded != nil && *vprops.Runtime.ConsolidationNeeded)
+
 	log.Printf("[DEBUG] %s: Read complete", resourceVSphereVirtualMachineIDString(d))
 	return nil
 }
@@ -816,6 +896,26 @@ func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{
 		return fmt.Errorf("error running VM migration: %s", err)
 	}
 
+	// Upgrade VMware Tools if the trigger was changed.
+	if d.HasChange("upgrade_tools") && d.Get("upgrade_tools").(string) != "" {
+		if err := virtualmachine.UpgradeTools(
+			client,
+			vm,
+			d.Get("upgrade_tools_installer_options").(string),
+			d.Get("upgrade_tools_timeout").(int),
+		); err != nil {
+			return fmt.Errorf("error upgrading VMware Tools: %s", err)
+		}
+	}
+
+	// Consolidate the disks last, so that a failed consolidation does not
+	// block any of the other changes.
+	if d.Get("consolidate_disks").(bool) {
+		if _, err := virtualmachine.ConsolidateDisks(vm, timeout); err != nil {
+			return fmt.Errorf("error consolidating virtual machine disks: %s", err)
+		}
+	}
+
 	// All done with updates.
 	log.Printf("[DEBUG] %s: Update complete", resourceVSphereVirtualMachineIDString(d))
 	return resourceVSphereVirtualMachineRead(d, meta)
@@ -858,6 +958,22 @@ func resourceVSphereVirtualMachineUpdateReconfigureWithSDRS(
 }
 
 func resourceVSphereVirtualMachineDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	id := d.Id()
+	vm, err := virtualmachine.FromUUID(client, id)
+	if err != nil {
+		return fmt.Errorf("cannot locate virtual machine with UUID %q: %s", id, err)
+	}
+	if err := checkDeletionProtection(d, meta, vm.Reference()); err != nil {
+		return err
+	}
+	return resourceVSphereVirtualMachineApplyDelete(d, meta)
+}
+
+// resourceVSphereVirtualMachineApplyDelete processes the deletion part of
+// resourceVSphereVirtualMachineDelete. It is also used to roll back a failed
+// create, which is not subject to deletion protection.
+func resourceVSphereVirtualMachineApplyDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] %s: Performing delete", resourceVSphereVirtualMachineIDString(d))
 	client := meta.(*Client).vimClient
 	timeout := meta.(*Client).timeout
@@ -964,12 +1080,23 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 	if len(d.Get("ovf_deploy").([]interface{})) > 0 {
 		localOvfPath := d.Get("ovf_deploy.0.local_ovf_path").(string)
 		remoteOvfURL := d.Get("ovf_deploy.0.remote_ovf_url").(string)
+		sourceDatastoreID := d.Get("ovf_deploy.0.source_datastore_id").(string)
+		sourceDatastorePath := d.Get("ovf_deploy.0.source_datastore_path").(string)
 
-		if localOvfPath == "" && remoteOvfURL == "" {
-			return fmt.Errorf("either local ovf/ova path or remote ovf/ova url is required, both can't be empty")
+		sources := 0
+		for _, v := range []string{localOvfPath, remoteOvfURL, sourceDatastoreID} {
+			if v != "" {
+				sources++
+			}
 		}
-		if localOvfPath != "" && remoteOvfURL != "" {
-			return fmt.Errorf("both local ovf/ova path and remote ovf/ova url are provided, please specify only one source")
+		if sources == 0 {
+			return fmt.Errorf("one of local ovf/ova path, remote ovf/ova url or source datastore is required")
+		}
+		if sources > 1 {
+			return fmt.Errorf("more than one of local ovf/ova path, remote ovf/ova url and source datastore are provided, please specify only one source")
+		}
+		if (sourceDatastoreID == "") != (sourceDatastorePath == "") {
+			return fmt.Errorf("source_datastore_id and source_datastore_path must be set together")
 		}
 		if localOvfPath != "" {
 			if _, err := os.Stat(localOvfPath); os.IsNotExist(err) {
@@ -1008,9 +1135,7 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 				if strings.HasSuffix(k, ".#") {
 					k = strings.TrimSuffix(k, ".#")
 				}
-				// To maintain consistency with other timeout options, timeout does not
-				// need to ForceNew
-				if k == "clone.0.timeout" {
+				if !virtualMachineCloneKeyForcesNew(k) {
 					continue
 				}
 				_ = d.ForceNew(k)
@@ -1018,6 +1143,17 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 		}
 	}
 
+	// Validate vApp properties against the property definitions of the
+	// virtual machine, template or OVF.
+	if err := vAppPropertiesDiffOperation(d, meta.(*Client)); err != nil {
+		return err
+	}
+
+	// Plan a disk consolidation if one is needed and enabled.
+	if d.Id() != "" && d.Get("consolidate_disks").(bool) && d.Get("consolidation_needed").(bool) {
+		_ = d.SetNew("consolidation_needed", false)
+	}
+
 	// Validate hardware version changes.
 	cv, tv := d.GetChange("hardware_version")
 	err := virtualmachine.ValidateHardwareVersion(cv.(int), tv.(int))
@@ -1025,6 +1161,17 @@ func resourceVSphereVirtualMachineCustomizeDiff(_ context.Context, d *schema.Res
 		return err
 	}
 
+	// Place the virtual machine through DRS if requested.
+	if err := resourceVSphereVirtualMachineCustomizeDiffPlacementOperation(d, client); err != nil {
+		return err
+	}
+
+	// Validate the guest OS and virtual hardware against what the target
+	// cluster or host supports.
+	if err := resourceVSphereVirtualMachineCustomizeDiffConfigOptionOperation(d, client); err != nil {
+		return err
+	}
+
 	// Validate that the config has the necessary components for vApp support.
 	// Note that for clones the data is prepopulated in
 	// ValidateVirtualMachineClone.
@@ -1049,6 +1196,196 @@ func resourceVSphereVirtualMachineCustomizeDiffResourcePoolOperation(d *schema.R
 	return nil
 }
 
+// virtualMachineCloneKeyForcesNew returns true if a change of the supplied
+// key in the clone block forces a new virtual machine. To maintain
+// consistency with other timeout options, timeout does not need to ForceNew.
+// Encrypted passwords are only used when the virtual machine is cloned, and
+// change when the public key of the vCenter Server is rotated, which must not
+// replace existing virtual machines. The guest log credentials are only used
+// to report customization failures.
+func virtualMachineCloneKeyForcesNew(k string) bool {
+	switch {
+	case k == "clone.0.timeout":
+		return false
+	case strings.HasSuffix(k, "admin_password_encrypted"):
+		return false
+	case strings.HasSuffix(k, ".guest_log_username"), strings.HasSuffix(k, ".guest_log_password"):
+		return false
+	}
+	return true
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffPlacementOperation requests a DRS
+// placement recommendation for a new virtual machine when drs_placement is
+// enabled and host_system_id is not set, failing the plan on DRS faults such
+// as insufficient CPU or memory capacity. The recommendation itself is not
+// planned, as it can change until apply: placement is requested again on
+// create. Placement is skipped when deploying from an OVF, as the virtual
+// hardware is then defined by the OVF, and when the placement inputs are not
+// known yet.
+func resourceVSphereVirtualMachineCustomizeDiffPlacementOperation(d *schema.ResourceDiff, client *govmomi.Client) error {
+	if d.Id() != "" || !d.Get("drs_placement").(bool) || len(d.Get("ovf_deploy").([]interface{})) > 0 {
+		return nil
+	}
+	if !d.GetRawConfig().GetAttr("host_system_id").IsNull() {
+		return nil
+	}
+	keys := []string{
+		"resource_pool_id",
+		"datastore_id",
+		"datastore_cluster_id",
+		"num_cpus",
+		"memory",
+		"cpu_reservation",
+		"memory_reservation",
+		"scsi_type",
+		"disk",
+	}
+	if !structure.ValuesAvailable("", keys, d) {
+		log.Printf("[DEBUG] %s: Virtual machine placement inputs not known yet, skipping DRS placement", resourceVSphereVirtualMachineIDString(d))
+		return nil
+	}
+	_, _, err := resourceVSphereVirtualMachinePlaceWithDRS(d, client)
+	return err
+}
+
+// resourceVSphereVirtualMachinePlaceWithDRS requests a DRS placement
+// recommendation for a new virtual machine from the cluster of its resource
+// pool, and returns the recommended host and datastore.
+func resourceVSphereVirtualMachinePlaceWithDRS(d virtualMachinePlacementGetter, client *govmomi.Client) (*types.ManagedObjectReference, *types.ManagedObjectReference, error) {
+	if err := viapi.ValidateVirtualCenter(client); err != nil {
+		return nil, nil, errors.New("drs_placement requires vCenter")
+	}
+	poolID := d.Get("resource_pool_id").(string)
+	pool, err := resourcepool.FromID(client, poolID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find resource pool ID %q: %s", poolID, err)
+	}
+	pprops, err := resourcepool.Properties(pool)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pprops.Owner.Type != "ClusterComputeResource" {
+		return nil, nil, fmt.Errorf("drs_placement requires resource pool %q to belong to a cluster", poolID)
+	}
+	cluster, err := clustercomputeresource.FromID(client, pprops.Owner.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+	enabled, err := clustercomputeresource.DrsEnabled(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !enabled {
+		return nil, nil, fmt.Errorf("drs_placement requires DRS to be enabled on cluster %q", cluster.InventoryPath)
+	}
+
+	spec, err := expandVirtualMachinePlacementSpec(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clustercomputeresource.PlaceVM(cluster, spec)
+}
+
+// resourceVSphereVirtualMachineCustomizeDiffConfigOptionOperation validates
+// the guest ID, CPU, memory, firmware and device counts of the virtual machine
+// against the config option and config target of the environment browser of
+// the target host or cluster, reporting all unsupported settings at once.
+// Validation is skipped when deploying from an OVF, as the virtual hardware
+// is then defined by the OVF, and when any of the values are not known yet.
+// The host, guest ID and hardware version are optional and computed: when
+// they are not known, validation is done against the cluster, without the
+// guest OS checks, and against the default hardware version respectively.
+func resourceVSphereVirtualMachineCustomizeDiffConfigOptionOperation(d *schema.ResourceDiff, client *govmomi.Client) error {
+	if len(d.Get("ovf_deploy").([]interface{})) > 0 {
+		return nil
+	}
+	keys := []string{
+		"resource_pool_id",
+		"num_cpus",
+		"memory",
+		"firmware",
+		"scsi_type",
+	}
+	if !structure.ValuesAvailable("", keys, d) {
+		log.Printf("[DEBUG] %s: Virtual machine placement or hardware not known yet, skipping config option validation", resourceVSphereVirtualMachineIDString(d))
+		return nil
+	}
+	keys = append(keys, "host_system_id", "guest_id", "hardware_version", "scsi_controller_count", "sata_controller_count", "ide_controller_count", "disk", "network_interface")
+	if d.Id() != "" && !d.HasChanges(keys...) {
+		return nil
+	}
+	var hostID, guestID string
+	var hardwareVersion int
+	if d.NewValueKnown("host_system_id") {
+		hostID = d.Get("host_system_id").(string)
+	}
+	if d.NewValueKnown("guest_id") {
+		guestID = d.Get("guest_id").(string)
+	}
+	if d.NewValueKnown("hardware_version") {
+		hardwareVersion = d.Get("hardware_version").(int)
+	}
+
+	var ref types.ManagedObjectReference
+	var hostRef *types.ManagedObjectReference
+	if hostID != "" {
+		host, err := hostsystem.FromID(client, hostID)
+		if err != nil {
+			return fmt.Errorf("could not find host with ID %q: %s", hostID, err)
+		}
+		ref = host.Reference()
+		hostRef = &ref
+	} else {
+		poolID := d.Get("resource_pool_id").(string)
+		if poolID == "" {
+			return nil
+		}
+		pool, err := resourcepool.FromID(client, poolID)
+		if err != nil {
+			return fmt.Errorf("could not find resource pool ID %q: %s", poolID, err)
+		}
+		pprops, err := resourcepool.Properties(pool)
+		if err != nil {
+			return err
+		}
+		ref = pprops.Owner
+	}
+	b, err := computeresource.EnvironmentBrowserFromReference(client, ref)
+	if err != nil {
+		return err
+	}
+
+	var key string
+	if hardwareVersion > 0 {
+		key = virtualmachine.GetHardwareVersionID(hardwareVersion)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), provider.DefaultAPITimeout)
+	defer cancel()
+	opt, err := b.ConfigOption(ctx, key, guestID, hostRef)
+	if err != nil {
+		return fmt.Errorf("error querying config options of %q: %s", ref.Value, err)
+	}
+	target, err := b.ConfigTarget(ctx, hostRef)
+	if err != nil {
+		return fmt.Errorf("error querying config target of %q: %s", ref.Value, err)
+	}
+
+	r := &envbrowse.VirtualMachineRequirements{
+		GuestID:           guestID,
+		NumCPUs:           d.Get("num_cpus").(int),
+		MemoryMB:          int64(d.Get("memory").(int)),
+		Firmware:          d.Get("firmware").(string),
+		SCSIType:          d.Get("scsi_type").(string),
+		Disks:             len(d.Get("disk").([]interface{})),
+		SCSIControllers:   d.Get("scsi_controller_count").(int),
+		SATAControllers:   d.Get("sata_controller_count").(int),
+		IDEControllers:    d.Get("ide_controller_count").(int),
+		NetworkInterfaces: len(d.Get("network_interface").([]interface{})),
+	}
+	return r.Validate(opt, target)
+}
+
 func datastoreClusterDiffOperation(d *schema.ResourceDiff, client *govmomi.Client) error {
 	if !structure.ValuesAvailable("", []string{"datastore_cluster_id", "datastore_id"}, d) {
 		log.Printf("[DEBUG] DatastoreClusterDiffOperation: datastore_id or datastore_cluster_id value depends on a computed value from another resource. Skipping validation.")
@@ -1174,6 +1511,10 @@ func resourceVSphereVirtualMachineImport(d *schema.ResourceData, meta interface{
 	// have not been changed.
 	rs := resourceVSphereVirtualMachine().Schema
 	_ = d.Set("force_power_off", rs["force_power_off"].Default)
+	_ = d.Set("consolidate_disks", rs["consolidate_disks"].Default)
+	_ = d.Set("drs_placement", rs["drs_placement"].Default)
+	_ = d.Set(deletionProtectionKey, rs[deletionProtectionKey].Default)
+	_ = d.Set("upgrade_tools_timeout", rs["upgrade_tools_timeout"].Default)
 	_ = d.Set("migrate_wait_timeout", rs["migrate_wait_timeout"].Default)
 	_ = d.Set("shutdown_wait_timeout", rs["shutdown_wait_timeout"].Default)
 	_ = d.Set("wait_for_guest_ip_timeout", rs["wait_for_guest_ip_timeout"].Default)
@@ -1354,6 +1695,12 @@ func resourceVsphereMachineDeployOvfAndOva(d *schema.ResourceData, meta interfac
 	timeout := meta.(*Client).timeout
 
 	ovfParams := NewOvfHelperParamsFromVMResource(d)
+	ovfParams.UploadOptions = meta.(*Client).ovfUploadOptions
+	ovfParams.DownloadCache = meta.(*Client).ovfDownloadCache
+	ovfParams.SignatureOptions = ovfdeploy.SignatureOptions{
+		Policy:          d.Get("ovf_deploy.0.ovf_signature_policy").(string),
+		TrustedCABundle: d.Get("ovf_deploy.0.ovf_trusted_ca_bundle").(string),
+	}
 	ovfHelper, err := ovfdeploy.NewOvfHelper(client, ovfParams)
 	if err != nil {
 		return nil, fmt.Errorf("while extracting OVF parameters: %s", err)
@@ -1369,6 +1716,9 @@ func resourceVsphereMachineDeployOvfAndOva(d *schema.ResourceData, meta interfac
 	if err != nil {
 		return nil, fmt.Errorf("error while importing ovf/ova template, %s", err)
 	}
+	if err := d.Set("ovf_signer", ovfdeploy.FlattenSigner(ovfHelper.Signer)); err != nil {
+		return nil, err
+	}
 
 	dataCenterID := d.Get("datacenter_id").(string)
 	if dataCenterID == "" {
@@ -1707,10 +2057,13 @@ func resourceVSphereVirtualMachinePostDeployChanges(d *schema.ResourceData, meta
 		}
 		var timeout int
 		var customizationSpec types.CustomizationSpec
+		var customizationKeyPrefix string
 		if hasCustomizeInCloneConfig {
+			customizationKeyPrefix = "clone.0.customize.0."
 			timeout = d.Get("clone.0.customize.0.timeout").(int)
 			customizationSpec = guestoscustomizations.ExpandCustomizationSpec(d, family, true)
 		} else {
+			customizationKeyPrefix = "clone.0.customization_spec.0."
 			timeout = d.Get("clone.0.customization_spec.0.timeout").(int)
 			goscName := d.Get("clone.0.customization_spec.0.id").(string)
 			specItem, err := guestoscustomizations.FromName(client, goscName)
@@ -1725,10 +2078,18 @@ func resourceVSphereVirtualMachinePostDeployChanges(d *schema.ResourceData, meta
 			customizationSpec = specItem.Spec
 		}
 
-		cw = newVirtualMachineCustomizationWaiter(client, vm, timeout)
+		var guestAuth types.BaseGuestAuthentication
+		if v, ok := d.GetOk(customizationKeyPrefix + "guest_log_username"); ok {
+			guestAuth = &types.NamePasswordAuthentication{
+				Username: v.(string),
+				Password: d.Get(customizationKeyPrefix + "guest_log_password").(string),
+			}
+		}
+
+		cw = newVirtualMachineCustomizationWaiter(client, vm, timeout, guestAuth)
 		if err := virtualmachine.Customize(vm, customizationSpec); err != nil {
 			// Roll back the VMs as per the error handling in reconfigure.
-			if derr := resourceVSphereVirtualMachineDelete(d, meta); derr != nil {
+			if derr := resourceVSphereVirtualMachineApplyDelete(d, meta); derr != nil {
 				return fmt.Errorf(formatVirtualMachinePostCloneRollbackError, vm.InventoryPath, err, derr)
 			}
 			d.SetId("")
@@ -1802,7 +2163,7 @@ func resourceVSphereVirtualMachineRollbackCreate(
 	// keep_on_remove were attached, but just in case, we run this through delete
 	// to make sure to safely remove any disk that may have been attached as part
 	// of this process if it was flagged as such.
-	if err := resourceVSphereVirtualMachineDelete(d, meta); err != nil {
+	if err := resourceVSphereVirtualMachineApplyDelete(d, meta); err != nil {
 		return fmt.Errorf(formatVirtualMachinePostCloneRollbackError, vm.InventoryPath, origErr, err)
 	}
 	return fmt.Errorf("error reconfiguring virtual machine: %s", origErr)
@@ -1978,19 +2339,21 @@ func resourceVSphereVirtualMachineIDString(d structure.ResourceIDStringer) strin
 
 func NewOvfHelperParamsFromVMResource(d *schema.ResourceData) *ovfdeploy.OvfHelperParams {
 	ovfParams := &ovfdeploy.OvfHelperParams{
-		AllowUnverifiedSSL: d.Get("ovf_deploy.0.allow_unverified_ssl_cert").(bool),
-		DatastoreID:        d.Get("datastore_id").(string),
-		DeploymentOption:   d.Get("ovf_deploy.0.deployment_option").(string),
-		DiskProvisioning:   d.Get("ovf_deploy.0.disk_provisioning").(string),
-		FilePath:           d.Get("ovf_deploy.0.local_ovf_path").(string),
-		Folder:             d.Get("folder").(string),
-		HostID:             d.Get("host_system_id").(string),
-		IPAllocationPolicy: d.Get("ovf_deploy.0.ip_allocation_policy").(string),
-		IPProtocol:         d.Get("ovf_deploy.0.ip_protocol").(string),
-		Name:               d.Get("name").(string),
-		NetworkMappings:    d.Get("ovf_deploy.0.ovf_network_map").(map[string]interface{}),
-		OvfURL:             d.Get("ovf_deploy.0.remote_ovf_url").(string),
-		PoolID:             d.Get("resource_pool_id").(string),
+		AllowUnverifiedSSL:  d.Get("ovf_deploy.0.allow_unverified_ssl_cert").(bool),
+		DatastoreID:         d.Get("datastore_id").(string),
+		DeploymentOption:    d.Get("ovf_deploy.0.deployment_option").(string),
+		DiskProvisioning:    d.Get("ovf_deploy.0.disk_provisioning").(string),
+		FilePath:            d.Get("ovf_deploy.0.local_ovf_path").(string),
+		Folder:              d.Get("folder").(string),
+		HostID:              d.Get("host_system_id").(string),
+		IPAllocationPolicy:  d.Get("ovf_deploy.0.ip_allocation_policy").(string),
+		IPProtocol:          d.Get("ovf_deploy.0.ip_protocol").(string),
+		Name:                d.Get("name").(string),
+		NetworkMappings:     d.Get("ovf_deploy.0.ovf_network_map").(map[string]interface{}),
+		OvfURL:              d.Get("ovf_deploy.0.remote_ovf_url").(string),
+		PoolID:              d.Get("resource_pool_id").(string),
+		SourceDatastoreID:   d.Get("ovf_deploy.0.source_datastore_id").(string),
+		SourceDatastorePath: d.Get("ovf_deploy.0.source_datastore_path").(string),
 	}
 	return ovfParams
 }
@@ -18,7 +18,11 @@ func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVSphereVirtualMachineSnapshotCreate,
 		Read:   resourceVSphereVirtualMachineSnapshotRead,
+		Update: resourceVSphereVirtualMachineSnapshotUpdate,
 		Delete: resourceVSphereVirtualMachineSnapshotDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereVirtualMachineSnapshotImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"virtual_machine_uuid": {
@@ -29,12 +33,10 @@ func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 			"snapshot_name": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"description": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"memory": {
 				Type:     schema.TypeBool,
@@ -49,12 +51,15 @@ func resourceVSphereVirtualMachineSnapshot() *schema.Resource {
 			"remove_children": {
 				Type:     schema.TypeBool,
 				Optional: true,
-				ForceNew: true,
 			},
 			"consolidate": {
 				Type:     schema.TypeBool,
 				Optional: true,
-				ForceNew: true,
+			},
+			"revert_on_apply": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "An arbitrary value that, when changed to a new non-empty value, reverts the virtual machine to the snapshot.",
 			},
 		},
 	}
@@ -138,20 +143,69 @@ func resourceVSphereVirtualMachineSnapshotRead(d *schema.ResourceData, meta inte
 	client := meta.(*Client).vimClient
 	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
 	if err != nil {
-		return fmt.Errorf("Error while getting the VirtualMachine :%s", err)
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout) // This is 5 mins
-	defer cancel()
-	snapshot, err := vm.FindSnapshot(ctx, d.Id())
-	if err != nil {
-		if strings.Contains(err.Error(), "no snapshots for this VM") || strings.Contains(err.Error(), "snapshot \""+d.Get("snapshot_name").(string)+"\" not found") {
-			log.Printf("[DEBUG] Error While finding the Snapshot: %v", err)
+		if virtualmachine.IsUUIDNotFoundError(err) {
+			log.Printf("[DEBUG] Virtual machine of snapshot %q not found, removing the snapshot from state", d.Id())
 			d.SetId("")
 			return nil
 		}
-		log.Printf("[DEBUG] Error While finding the Snapshot: %v", err)
+		return fmt.Errorf("Error while getting the VirtualMachine :%s", err)
+	}
+	snapshot, err := virtualmachine.SnapshotFromID(vm, d.Id())
+	if err != nil {
 		return fmt.Errorf("Error while finding the Snapshot :%s", err)
 	}
-	log.Printf("[DEBUG] Snapshot found: %v", snapshot)
+	if snapshot == nil {
+		log.Printf("[DEBUG] Snapshot %q not found, removing it from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	log.Printf("[DEBUG] Snapshot found: %v", snapshot.Snapshot)
+	_ = d.Set("snapshot_name", snapshot.Name)
+	_ = d.Set("description", snapshot.Description)
 	return nil
 }
+
+func resourceVSphereVirtualMachineSnapshotUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).vimClient
+	vm, err := virtualmachine.FromUUID(client, d.Get("virtual_machine_uuid").(string))
+	if err != nil {
+		return fmt.Errorf("Error while getting the VirtualMachine :%s", err)
+	}
+	ref := types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: d.Id()}
+	if d.HasChanges("snapshot_name", "description") {
+		if err := virtualmachine.RenameSnapshot(vm, ref, d.Get("snapshot_name").(string), d.Get("description").(string)); err != nil {
+			return fmt.Errorf("error renaming snapshot %q: %s", d.Id(), err)
+		}
+	}
+	if d.HasChange("revert_on_apply") && d.Get("revert_on_apply").(string) != "" {
+		if err := virtualmachine.RevertToSnapshot(vm, ref, false); err != nil {
+			return fmt.Errorf("error reverting to snapshot %q: %s", d.Id(), err)
+		}
+	}
+	return resourceVSphereVirtualMachineSnapshotRead(d, meta)
+}
+
+func resourceVSphereVirtualMachineSnapshotImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid import ID %q, expected <virtual_machine_uuid>:<snapshot name or managed object ID>", d.Id())
+	}
+	client := meta.(*Client).vimClient
+	vm, err := virtualmachine.FromUUID(client, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate virtual machine with UUID %q: %s", parts[0], err)
+	}
+	snapshot, err := virtualmachine.SnapshotFromNameOrID(vm, parts[1])
+	if err != nil {
+		return nil, err
+	}
+	d.SetId(snapshot.Snapshot.Value)
+	_ = d.Set("virtual_machine_uuid", parts[0])
+	_ = d.Set("snapshot_name", snapshot.Name)
+	_ = d.Set("description", snapshot.Description)
+	// The memory of the virtual machine is only part of the snapshot if the
+	// virtual machine was powered on in the snapshot.
+	_ = d.Set("memory", snapshot.State == types.VirtualMachinePowerStatePoweredOn)
+	_ = d.Set("quiesce", snapshot.Quiesced)
+	return []*schema.ResourceData{d}, nil
+}
@@ -43,6 +43,61 @@ func TestAccResourceVSphereVirtualMachineSnapshot_basic(t *testing.T) {
 	})
 }
 
+func TestAccResourceVSphereVirtualMachineSnapshot_renameAndImport(t *testing.T) {
+	var id string
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			RunSweepers()
+			testAccPreCheck(t)
+			testAccResourceVSphereVirtualMachineSnapshotPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot", false),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfigNamed(true, "terraform-test-snapshot", "Managed by Terraform"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVirtualMachineSnapshotExists("vsphere_virtual_machine_snapshot.snapshot", true),
+					func(s *terraform.State) error {
+						id = s.RootModule().Resources["vsphere_virtual_machine_snapshot.snapshot"].Primary.ID
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfigNamed(true, "terraform-test-snapshot-renamed", "Renamed by Terraform"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine_snapshot.snapshot", "snapshot_name", "terraform-test-snapshot-renamed"),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine_snapshot.snapshot", "description", "Renamed by Terraform"),
+					func(s *terraform.State) error {
+						if newID := s.RootModule().Resources["vsphere_virtual_machine_snapshot.snapshot"].Primary.ID; newID != id {
+							return fmt.Errorf("expected snapshot %s to be renamed in place, got %s", id, newID)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:      "vsphere_virtual_machine_snapshot.snapshot",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"consolidate",
+					"remove_children",
+					"revert_on_apply",
+				},
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					rs := s.RootModule().Resources["vsphere_virtual_machine_snapshot.snapshot"]
+					return fmt.Sprintf("%s:%s", rs.Primary.Attributes["virtual_machine_uuid"], rs.Primary.Attributes["snapshot_name"]), nil
+				},
+				Config: testAccResourceVSphereVirtualMachineSnapshotConfigNamed(true, "terraform-test-snapshot-renamed", "Renamed by Terraform"),
+			},
+		},
+	})
+}
+
 func testAccResourceVSphereVirtualMachineSnapshotPreCheck(t *testing.T) {
 	if os.Getenv("TF_VAR_VSPHERE_DATACENTER") == "" {
 		t.Skip("set TF_VAR_VSPHERE_DATACENTER to run vsphere_virtual_machine_snapshot acceptance tests")
@@ -140,6 +195,10 @@ func testAccCheckVirtualMachineHasNoSnapshots(n string) resource.TestCheckFunc {
 }
 
 func testAccResourceVSphereVirtualMachineSnapshotConfig(enabled bool) string {
+	return testAccResourceVSphereVirtualMachineSnapshotConfigNamed(enabled, "terraform-test-snapshot", "Managed by Terraform")
+}
+
+func testAccResourceVSphereVirtualMachineSnapshotConfigNamed(enabled bool, name, description string) string {
 	return fmt.Sprintf(`
 %s
 
@@ -211,8 +270,8 @@ resource "vsphere_virtual_machine" "vm" {
 resource "vsphere_virtual_machine_snapshot" "snapshot" {
   count                = "${var.snapshot_enabled == "true" ? 1 : 0 }"
   virtual_machine_uuid = "${vsphere_virtual_machine.vm.uuid}"
-  snapshot_name        = "terraform-test-snapshot"
-  description          = "Managed by Terraform"
+  snapshot_name        = "%s"
+  description          = "%s"
   memory               = true
   quiesce              = true
 }
@@ -223,5 +282,7 @@ resource "vsphere_virtual_machine_snapshot" "snapshot" {
 		testhelper.CombineConfigs(testhelper.ConfigDataRootDC1(), testhelper.ConfigDataRootHost1(), testhelper.ConfigDataRootHost2(), testhelper.ConfigResDS1(), testhelper.ConfigDataRootComputeCluster1(), testhelper.ConfigResResourcePool1(), testhelper.ConfigDataRootPortGroup1()),
 		os.Getenv("TF_VAR_VSPHERE_TEMPLATE"),
 		enabled,
+		name,
+		description,
 	)
 }
@@ -39,6 +39,23 @@ const (
 	testAccResourceVSphereVirtualMachineIsoFile           = "fake.iso"
 )
 
+func TestVirtualMachineCloneKeyForcesNew(t *testing.T) {
+	for k, expected := range map[string]bool{
+		"clone.0.template_uuid":                                                 true,
+		"clone.0.customize.0.windows_options.0.admin_password":                  true,
+		"clone.0.timeout":                                                       false,
+		"clone.0.customize.0.windows_options.0.admin_password_encrypted":        false,
+		"clone.0.customize.0.windows_options.0.domain_admin_password_encrypted": false,
+		"clone.0.customize.0.guest_log_username":                                false,
+		"clone.0.customize.0.guest_log_password":                                false,
+		"clone.0.customization_spec.0.guest_log_username":                       false,
+	} {
+		if actual := virtualMachineCloneKeyForcesNew(k); actual != expected {
+			t.Errorf("%s: expected ForceNew %t, got %t", k, expected, actual)
+		}
+	}
+}
+
 func TestAccResourceVSphereVirtualMachine_basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck: func() {
@@ -81,6 +81,8 @@ func resourceVSphereVmfsDatastore() *schema.Resource {
 	s[vSphereTagAttributeKey] = tagsSchema()
 	// Add custom attributes schema
 	s[customattribute.ConfigKey] = customattribute.ConfigSchema()
+	// Add deletion protection schema
+	s[deletionProtectionKey] = deletionProtectionSchema()
 
 	return &schema.Resource{
 		Create:        resourceVSphereVmfsDatastoreCreate,
@@ -351,6 +353,9 @@ func resourceVSphereVmfsDatastoreDelete(d *schema.ResourceData, meta interface{}
 	if err != nil {
 		return fmt.Errorf("cannot find datastore: %s", err)
 	}
+	if err := checkDeletionProtection(d, meta, ds.Reference()); err != nil {
+		return err
+	}
 
 	// This is a race that more than likely will only come up during tests, but
 	// we still want to guard against it - when working with datastores that end
@@ -474,6 +479,7 @@ func resourceVSphereVmfsDatastoreImport(d *schema.ResourceData, meta interface{}
 	}
 	d.SetId(id)
 	_ = d.Set("host_system_id", hsID)
+	_ = d.Set(deletionProtectionKey, resourceVSphereVmfsDatastore().Schema[deletionProtectionKey].Default)
 
 	return []*schema.ResourceData{d}, nil
 }
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"strings"
 
 	"github.com/mitchellh/copystructure"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/contentlibrary"
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/ovfdeploy"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/spbm"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
 	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/viapi"
@@ -753,10 +756,8 @@ func expandVAppConfig(d *schema.ResourceData, client *govmomi.Client) (*types.Vm
 				},
 			}
 
-			newValue, ok := newMap[p.Id]
-			if ok {
-				prop.Info.Value = newValue.(string)
-				delete(newMap, p.Id)
+			if newValue, ok := popVAppPropertyValue(newMap, p); ok {
+				prop.Info.Value = newValue
 			}
 			props = append(props, prop)
 		} else {
@@ -777,15 +778,12 @@ func expandVAppConfig(d *schema.ResourceData, client *govmomi.Client) (*types.Vm
 					},
 				}
 
-				newValue, ok := newMap[p.Id]
-				if ok {
-					prop.Info.Value = newValue.(string)
-					delete(newMap, p.Id)
+				if newValue, ok := popVAppPropertyValue(newMap, p); ok {
+					prop.Info.Value = newValue
 				}
 				props = append(props, prop)
 			} else {
-				_, ok := newMap[p.Id]
-				if ok {
+				if _, ok := popVAppPropertyValue(newMap, p); ok {
 					return nil, fmt.Errorf("vApp property with userConfigurable=false specified in vapp.properties: %+v", reflect.ValueOf(newMap).MapKeys())
 				}
 			}
@@ -801,6 +799,133 @@ func expandVAppConfig(d *schema.ResourceData, client *govmomi.Client) (*types.Vm
 	}, nil
 }
 
+// popVAppPropertyValue returns the value of the supplied property in
+// vapp.properties, set either by its qualified class.key.instance ID or by its
+// ID, and removes it from the map.
+func popVAppPropertyValue(values map[string]interface{}, p types.VAppPropertyInfo) (string, bool) {
+	for _, id := range []string{virtualmachine.VAppPropertyQualifiedID(p.ClassId, p.Id, p.InstanceId), p.Id} {
+		if v, ok := values[id]; ok {
+			delete(values, id)
+			return v.(string), true
+		}
+	}
+	return "", false
+}
+
+// unknownVariableValue is the placeholder for values that are not known at
+// plan time in maps read from a ResourceDiff.
+const unknownVariableValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+// vAppPropertiesDiffOperation validates vapp.properties against the vApp
+// property definitions of the virtual machine, or of the template or OVF it is
+// created from. Validation is skipped if the definitions cannot be determined
+// at plan time.
+func vAppPropertiesDiffOperation(d *schema.ResourceDiff, c *Client) error {
+	if len(d.Get("vapp").([]interface{})) == 0 || !d.NewValueKnown("vapp.0.properties") {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChange("vapp") {
+		return nil
+	}
+	values := make(map[string]string)
+	unknown := make(map[string]bool)
+	for k, v := range d.Get("vapp.0.properties").(map[string]interface{}) {
+		values[k] = v.(string)
+		unknown[k] = values[k] == unknownVariableValue
+	}
+
+	defs, err := vAppPropertyDefinitions(d, c)
+	if err != nil {
+		return err
+	}
+	if defs == nil {
+		log.Printf("[DEBUG] %s: vApp property definitions not available, skipping validation", resourceVSphereVirtualMachineIDString(d))
+		return nil
+	}
+	return virtualmachine.ValidateVAppProperties(defs, values, unknown, d.Get("ovf_deploy.0.enable_hidden_properties").(bool))
+}
+
+// vAppPropertyDefinitions returns the vApp property definitions of the virtual
+// machine if it exists, otherwise of the template it is cloned from or the
+// OVF it is deployed from. nil is returned if these are not known.
+func vAppPropertyDefinitions(d *schema.ResourceDiff, c *Client) ([]*virtualmachine.VAppPropertyDefinition, error) {
+	client := c.vimClient
+	switch {
+	case d.Id() != "":
+		return vAppPropertyDefinitionsFromVM(client, d.Id())
+	case len(d.Get("ovf_deploy").([]interface{})) > 0:
+		for _, k := range []string{"local_ovf_path", "remote_ovf_url", "source_datastore_id", "source_datastore_path"} {
+			if !d.NewValueKnown("ovf_deploy.0." + k) {
+				return nil, nil
+			}
+		}
+		filePath := d.Get("ovf_deploy.0.local_ovf_path").(string)
+		isLocal := true
+		if u := d.Get("ovf_deploy.0.remote_ovf_url").(string); u != "" {
+			filePath = u
+			isLocal = false
+		}
+		var desc string
+		var err error
+		if dsID := d.Get("ovf_deploy.0.source_datastore_id").(string); dsID != "" {
+			var src *ovfdeploy.DatastoreSource
+			src, err = ovfdeploy.NewDatastoreSource(client, dsID, d.Get("ovf_deploy.0.source_datastore_path").(string))
+			if err != nil {
+				return nil, err
+			}
+			filePath = src.String()
+			desc, err = c.ovfDescriptorCache.Get(src, src.IsOva())
+		} else {
+			src := ovfdeploy.NewSource(filePath, isLocal, d.Get("ovf_deploy.0.allow_unverified_ssl_cert").(bool))
+			desc, err = c.ovfDescriptorCache.Get(src, strings.HasSuffix(filePath, ".ova"))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the ovf descriptor of %s to validate vapp.properties: %s", filePath, err)
+		}
+		e, err := ovfdeploy.ParseOvfDescriptor(desc)
+		if err != nil {
+			return nil, err
+		}
+		defs := []*virtualmachine.VAppPropertyDefinition{}
+		for _, section := range ovfdeploy.ProductSections(e) {
+			for _, p := range section.Property {
+				def, err := virtualmachine.VAppPropertyDefinitionFromOvf(section, p)
+				if err != nil {
+					return nil, err
+				}
+				defs = append(defs, def)
+			}
+		}
+		return defs, nil
+	case len(d.Get("clone").([]interface{})) > 0:
+		tUUID := d.Get("clone.0.template_uuid").(string)
+		if !d.NewValueKnown("clone.0.template_uuid") || contentlibrary.IsContentLibraryItem(c.restClient, tUUID) {
+			return nil, nil
+		}
+		return vAppPropertyDefinitionsFromVM(client, tUUID)
+	}
+	return nil, nil
+}
+
+func vAppPropertyDefinitionsFromVM(client *govmomi.Client, uuid string) ([]*virtualmachine.VAppPropertyDefinition, error) {
+	vm, err := virtualmachine.FromUUID(client, uuid)
+	if err != nil {
+		return nil, err
+	}
+	vprops, err := virtualmachine.Properties(vm)
+	if err != nil {
+		return nil, err
+	}
+	if vprops.Config.VAppConfig == nil {
+		return nil, fmt.Errorf("virtual machine %s lacks a vApp configuration and cannot have vApp properties set on it", uuid)
+	}
+	defs := []*virtualmachine.VAppPropertyDefinition{}
+	for _, p := range vprops.Config.VAppConfig.GetVmConfigInfo().Property {
+		defs = append(defs, virtualmachine.VAppPropertyDefinitionFromInfo(p))
+	}
+	return defs, nil
+}
+
 // flattenVAppConfig reads in the vAppConfig from a running virtual machine
 // and sets all keys in vapp.
 func flattenVAppConfig(d *schema.ResourceData, config types.BaseVmConfigInfo) error {
@@ -816,11 +941,25 @@ func flattenVAppConfig(d *schema.ResourceData, config types.BaseVmConfigInfo) er
 		// No props to read is a no-op
 		return nil
 	}
+	// Properties are saved with their qualified ID if it is the one used in
+	// the configuration, or if their ID is not unique.
+	current := make(map[string]interface{})
+	if vapp := d.Get("vapp").([]interface{}); len(vapp) > 0 && vapp[0] != nil {
+		current, _ = vapp[0].(map[string]interface{})["properties"].(map[string]interface{})
+	}
+	ids := make(map[string]int)
+	for _, v := range props {
+		ids[v.Id]++
+	}
 	vac := make(map[string]interface{})
 	for _, v := range props {
 		if *v.UserConfigurable {
 			if v.Value != "" && v.Value != v.DefaultValue {
-				vac[v.Id] = v.Value
+				id := v.Id
+				if qid := virtualmachine.VAppPropertyQualifiedID(v.ClassId, v.Id, v.InstanceId); ids[v.Id] > 1 || current[qid] != nil {
+					id = qid
+				}
+				vac[id] = v.Value
 			}
 		}
 	}
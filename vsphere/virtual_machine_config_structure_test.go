@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestPopVAppPropertyValue(t *testing.T) {
+	p := types.VAppPropertyInfo{ClassId: "vami", Id: "ip0", InstanceId: "VM_1"}
+	values := map[string]interface{}{
+		"vami.ip0.VM_1": "10.0.0.1",
+		"ip0":           "10.0.0.2",
+	}
+	if v, ok := popVAppPropertyValue(values, p); !ok || v != "10.0.0.1" {
+		t.Fatalf("expected the value of the qualified ID, got %q, %t", v, ok)
+	}
+	if _, ok := values["vami.ip0.VM_1"]; ok {
+		t.Fatal("expected the qualified ID to be removed")
+	}
+	if v, ok := popVAppPropertyValue(values, p); !ok || v != "10.0.0.2" {
+		t.Fatalf("expected the value of the ID, got %q, %t", v, ok)
+	}
+	if len(values) != 0 {
+		t.Fatalf("expected all values to be removed, got %v", values)
+	}
+	if _, ok := popVAppPropertyValue(values, p); ok {
+		t.Fatal("expected no value")
+	}
+}
+
+func TestFlattenVAppConfigQualifiedIDs(t *testing.T) {
+	d := testVirtualMachinePlacementData(t, map[string]interface{}{
+		"vapp": []interface{}{
+			map[string]interface{}{
+				"properties": map[string]interface{}{
+					"vami.gateway.VM_1": "10.0.0.254",
+					"hostname":          "vm",
+				},
+			},
+		},
+	})
+	userConfigurable := true
+	config := &types.VmConfigInfo{Property: []types.VAppPropertyInfo{
+		{ClassId: "vami", Id: "ip0", InstanceId: "VM_1", Value: "10.0.0.1", UserConfigurable: &userConfigurable},
+		{ClassId: "vami", Id: "ip0", InstanceId: "VM_2", Value: "10.0.0.2", UserConfigurable: &userConfigurable},
+		{ClassId: "vami", Id: "gateway", InstanceId: "VM_1", Value: "10.0.0.254", UserConfigurable: &userConfigurable},
+		{Id: "hostname", Value: "vm", UserConfigurable: &userConfigurable},
+		{Id: "domain", Value: "example.com", DefaultValue: "example.com", UserConfigurable: &userConfigurable},
+	}}
+	if err := flattenVAppConfig(d, config); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"vami.ip0.VM_1":     "10.0.0.1",
+		"vami.ip0.VM_2":     "10.0.0.2",
+		"vami.gateway.VM_1": "10.0.0.254",
+		"hostname":          "vm",
+	}
+	if actual := d.Get("vapp.0.properties").(map[string]interface{}); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
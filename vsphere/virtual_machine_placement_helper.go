@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"fmt"
+
+	"github.com/kube-cloud/terraform-provider-vsphere/vsphere/internal/helper/structure"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// virtualMachinePlacementGetter is the subset of ResourceData and ResourceDiff
+// used by resourceVSphereVirtualMachinePlaceWithDRS.
+type virtualMachinePlacementGetter interface {
+	Get(string) interface{}
+}
+
+// expandVirtualMachinePlacementSpec returns the PlacementSpec to request a DRS
+// placement recommendation for a new virtual machine. The spec includes the
+// disks that are created with the virtual machine, so that DRS accounts for
+// their size and datastores. Attached disks already exist and are not
+// included.
+func expandVirtualMachinePlacementSpec(d virtualMachinePlacementGetter) (types.PlacementSpec, error) {
+	spec := types.PlacementSpec{
+		PlacementType: string(types.PlacementSpecPlacementTypeCreate),
+		ConfigSpec: &types.VirtualMachineConfigSpec{
+			Name:     d.Get("name").(string),
+			GuestId:  d.Get("guest_id").(string),
+			NumCPUs:  int32(d.Get("num_cpus").(int)),
+			MemoryMB: int64(d.Get("memory").(int)),
+			CpuAllocation: &types.ResourceAllocationInfo{
+				Reservation: structure.Int64Ptr(int64(d.Get("cpu_reservation").(int))),
+			},
+			MemoryAllocation: &types.ResourceAllocationInfo{
+				Reservation: structure.Int64Ptr(int64(d.Get("memory_reservation").(int))),
+			},
+		},
+	}
+
+	podID := d.Get("datastore_cluster_id").(string)
+	dsID := d.Get("datastore_id").(string)
+	if podID != "" {
+		spec.StoragePods = []types.ManagedObjectReference{{Type: "StoragePod", Value: podID}}
+	} else if dsID != "" {
+		spec.Datastores = []types.ManagedObjectReference{{Type: "Datastore", Value: dsID}}
+	}
+
+	var l object.VirtualDeviceList
+	var controller types.BaseVirtualController
+	for _, di := range d.Get("disk").([]interface{}) {
+		disk, ok := di.(map[string]interface{})
+		if !ok || disk["attach"].(bool) {
+			continue
+		}
+		if controller == nil {
+			scsi, err := l.CreateSCSIController(d.Get("scsi_type").(string))
+			if err != nil {
+				return spec, fmt.Errorf("error creating SCSI controller for placement: %s", err)
+			}
+			l = append(l, scsi)
+			spec.ConfigSpec.DeviceChange = append(spec.ConfigSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+				Device:    scsi,
+			})
+			controller = scsi.(types.BaseVirtualController)
+		}
+
+		// With a datastore cluster, the datastore of the disks is selected by
+		// Storage DRS and is left to DRS to recommend. A disk datastore that is
+		// not known yet is displayed as <computed>.
+		var ds types.ManagedObjectReference
+		if podID == "" {
+			ds = types.ManagedObjectReference{Type: "Datastore", Value: dsID}
+			if v, _ := disk["datastore_id"].(string); v != "" && v != "<computed>" {
+				ds.Value = v
+				if !placementSpecHasDatastore(spec, v) {
+					spec.Datastores = append(spec.Datastores, ds)
+				}
+			}
+		}
+		vd := l.CreateDisk(controller, ds, "")
+		vd.CapacityInKB = int64(disk["size"].(int)) * 1024 * 1024
+		backing := vd.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		backing.ThinProvisioned = structure.BoolPtr(disk["thin_provisioned"].(bool))
+		backing.EagerlyScrub = structure.BoolPtr(disk["eagerly_scrub"].(bool))
+		if ds.Value == "" {
+			backing.Datastore = nil
+		}
+		l = append(l, vd)
+		spec.ConfigSpec.DeviceChange = append(spec.ConfigSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+			Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+			FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+			Device:        vd,
+		})
+	}
+	return spec, nil
+}
+
+// placementSpecHasDatastore returns true if the datastore with the supplied
+// ID is a candidate datastore of spec.
+func placementSpecHasDatastore(spec types.PlacementSpec, id string) bool {
+	for _, ds := range spec.Datastores {
+		if ds.Value == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testVirtualMachinePlacementData(t *testing.T, raw map[string]interface{}) *schema.ResourceData {
+	config := map[string]interface{}{
+		"name":             "vm",
+		"resource_pool_id": "resgroup-1",
+		"guest_id":         "otherLinux64Guest",
+		"num_cpus":         2,
+		"memory":           2048,
+	}
+	for k, v := range raw {
+		config[k] = v
+	}
+	return schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, config)
+}
+
+func TestExpandVirtualMachinePlacementSpec(t *testing.T) {
+	d := testVirtualMachinePlacementData(t, map[string]interface{}{
+		"datastore_id":       "datastore-1",
+		"memory_reservation": 1024,
+		"disk": []interface{}{
+			map[string]interface{}{"label": "disk0", "size": 20, "thin_provisioned": true},
+			map[string]interface{}{"label": "disk1", "size": 100, "unit_number": 1, "datastore_id": "datastore-2", "thin_provisioned": false},
+			map[string]interface{}{"label": "disk2", "attach": true, "path": "vm/disk2.vmdk", "unit_number": 2, "datastore_id": "datastore-3"},
+		},
+	})
+	spec, err := expandVirtualMachinePlacementSpec(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.PlacementType != string(types.PlacementSpecPlacementTypeCreate) {
+		t.Fatalf("expected a create placement, got %s", spec.PlacementType)
+	}
+	if spec.ConfigSpec.NumCPUs != 2 || spec.ConfigSpec.MemoryMB != 2048 || *spec.ConfigSpec.MemoryAllocation.Reservation != 1024 {
+		t.Fatalf("unexpected compute settings in %+v", spec.ConfigSpec)
+	}
+	if len(spec.Datastores) != 2 || spec.Datastores[0].Value != "datastore-1" || spec.Datastores[1].Value != "datastore-2" {
+		t.Fatalf("expected datastore-1 and datastore-2 as candidate datastores, got %v", spec.Datastores)
+	}
+
+	var disks []*types.VirtualDisk
+	var controllers int
+	for _, dc := range spec.ConfigSpec.DeviceChange {
+		s := dc.GetVirtualDeviceConfigSpec()
+		switch dev := s.Device.(type) {
+		case *types.VirtualDisk:
+			if s.FileOperation != types.VirtualDeviceConfigSpecFileOperationCreate {
+				t.Fatalf("expected disks to be created, got %q", s.FileOperation)
+			}
+			disks = append(disks, dev)
+		case types.BaseVirtualSCSIController:
+			controllers++
+		}
+	}
+	if controllers != 1 || len(disks) != 2 {
+		t.Fatalf("expected 1 controller and 2 disks, got %d and %d", controllers, len(disks))
+	}
+	for i, expected := range []struct {
+		capacityInKB int64
+		datastore    string
+		thin         bool
+	}{
+		{20 * 1024 * 1024, "datastore-1", true},
+		{100 * 1024 * 1024, "datastore-2", false},
+	} {
+		backing := disks[i].Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		if disks[i].CapacityInKB != expected.capacityInKB {
+			t.Errorf("disk %d: expected capacity %d, got %d", i, expected.capacityInKB, disks[i].CapacityInKB)
+		}
+		if backing.Datastore == nil || backing.Datastore.Value != expected.datastore {
+			t.Errorf("disk %d: expected datastore %s, got %v", i, expected.datastore, backing.Datastore)
+		}
+		if *backing.ThinProvisioned != expected.thin {
+			t.Errorf("disk %d: expected thin provisioned %t", i, expected.thin)
+		}
+	}
+}
+
+func TestExpandVirtualMachinePlacementSpecDatastoreCluster(t *testing.T) {
+	d := testVirtualMachinePlacementData(t, map[string]interface{}{
+		"datastore_cluster_id": "group-p1",
+		"disk": []interface{}{
+			map[string]interface{}{"label": "disk0", "size": 20},
+		},
+	})
+	spec, err := expandVirtualMachinePlacementSpec(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.StoragePods) != 1 || spec.StoragePods[0].Value != "group-p1" || len(spec.Datastores) != 0 {
+		t.Fatalf("expected only the datastore cluster as a candidate, got %v and %v", spec.StoragePods, spec.Datastores)
+	}
+	var found bool
+	for _, dc := range spec.ConfigSpec.DeviceChange {
+		if disk, ok := dc.GetVirtualDeviceConfigSpec().Device.(*types.VirtualDisk); ok {
+			found = true
+			if ds := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo).Datastore; ds != nil {
+				t.Fatalf("expected the disk datastore to be left to DRS, got %v", ds)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected the disk in the placement spec")
+	}
+}